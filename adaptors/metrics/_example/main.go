@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/metrics"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+)
+
+func main() {
+	app := iris2.New()
+
+	ws := websocket.New(websocket.Config{Endpoint: "/ws"})
+	ws.OnConnection(func(c websocket.Connection) {
+		c.Emit("hello", "world")
+	})
+	app.Adapt(ws)
+
+	m := metrics.New(metrics.Config{
+		// the request durations' histogram buckets, in seconds
+		Buckets: []float64{.01, .05, .1, .5, 1},
+	})
+	m.TrackWebsocket(ws)
+	// adapt it after the websocket, its routes are registered on Boot.
+	app.Adapt(m)
+
+	app.Get("/users/:id", func(ctx *iris2.Context) {
+		ctx.Writef("user %s", ctx.Param("id"))
+	}).ChangeName("user")
+
+	app.Get("/slow", func(ctx *iris2.Context) {
+		time.Sleep(300 * time.Millisecond)
+		ctx.Writef("done")
+	}).ChangeName("slow")
+
+	// http://localhost:8080/users/42
+	// http://localhost:8080/slow
+	// http://localhost:8080/metrics
+	app.Listen(":8080")
+}
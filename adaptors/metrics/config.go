@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"github.com/imdario/mergo"
+)
+
+const (
+	// DefaultNamespace is the prefix of the metrics' names, "iris2"
+	DefaultNamespace = "iris2"
+	// DefaultEndpoint is the path which the metrics are exposed, "/metrics"
+	DefaultEndpoint = "/metrics"
+)
+
+// DefaultBuckets are the upper bounds (in seconds) of the request duration's histogram buckets,
+// they are the same as the Prometheus' client default ones.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Config the configs for the metrics adaptor
+type Config struct {
+	// Namespace is the prefix of the metrics' names, i.e iris2_http_requests_total. Default is "iris2"
+	Namespace string
+	// Buckets are the upper bounds, in seconds and in increasing order,
+	// of the request duration's histogram buckets. Default is the DefaultBuckets
+	Buckets []float64
+	// Endpoint is the path which the text exposition handler is registered to,
+	// the route's name is "iris2-metrics". Default is "/metrics"
+	Endpoint string
+	// DisableEndpoint, if true, the Endpoint is not registered,
+	// the Handler can be registered manually. Default is false
+	DisableEndpoint bool
+}
+
+// DefaultConfig returns the default configs for the metrics adaptor
+func DefaultConfig() Config {
+	return Config{
+		Namespace: DefaultNamespace,
+		Buckets:   DefaultBuckets,
+		Endpoint:  DefaultEndpoint,
+	}
+}
+
+// MergeSingle merges the default with the given config and returns the result
+func (c Config) MergeSingle(cfg Config) (config Config) {
+	config = cfg
+	mergo.Merge(&config, c)
+	return
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-iris2/iris2"
+)
+
+// ContentType is the content type of the Prometheus' text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Handler returns the handler which sends the metrics in the Prometheus' text exposition format.
+func (m *Metrics) Handler() iris2.HandlerFunc {
+	return func(ctx *iris2.Context) {
+		ctx.SetContentType(ContentType)
+		ctx.Write(m.Expose())
+	}
+}
+
+// Expose returns the metrics in the Prometheus' text exposition format.
+func (m *Metrics) Expose() []byte {
+	m.mu.Lock()
+	keys := make([]seriesKey, 0, len(m.series))
+	snapshot := make(map[seriesKey]series, len(m.series))
+	for k, s := range m.series {
+		keys = append(keys, k)
+		snapshot[k] = series{count: s.count, sum: s.sum, buckets: append([]uint64(nil), s.buckets...)}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		if a.subdomain != b.subdomain {
+			return a.subdomain < b.subdomain
+		}
+		return a.code < b.code
+	})
+
+	ns := m.config.Namespace
+	b := new(bytes.Buffer)
+
+	requests := ns + "_http_requests_total"
+	writeHeader(b, requests, "counter", "Total number of HTTP requests by route, method, subdomain and status code.")
+	for _, k := range keys {
+		writeSample(b, requests, labels(k, ""), float64(snapshot[k].count))
+	}
+
+	duration := ns + "_http_request_duration_seconds"
+	writeHeader(b, duration, "histogram", "The HTTP request latencies in seconds by route, method, subdomain and status code.")
+	for _, k := range keys {
+		s := snapshot[k]
+		var cumulative uint64
+		for i, upper := range m.config.Buckets {
+			cumulative += s.buckets[i]
+			writeSample(b, duration+"_bucket", labels(k, formatFloat(upper)), float64(cumulative))
+		}
+		writeSample(b, duration+"_bucket", labels(k, "+Inf"), float64(s.count))
+		writeSample(b, duration+"_sum", labels(k, ""), s.sum)
+		writeSample(b, duration+"_count", labels(k, ""), float64(s.count))
+	}
+
+	inFlight := ns + "_http_requests_in_flight"
+	writeHeader(b, inFlight, "gauge", "Number of the HTTP requests which are being served.")
+	writeSample(b, inFlight, "", float64(m.InFlight()))
+
+	websockets := ns + "_websocket_connections"
+	writeHeader(b, websockets, "gauge", "Number of the connected websocket clients.")
+	writeSample(b, websockets, "", float64(m.WebsocketConnections()))
+
+	queueDepth := ns + "_websocket_send_queue_depth"
+	writeHeader(b, queueDepth, "gauge", "Number of the messages which wait to be written to the websocket clients.")
+	writeSample(b, queueDepth, "", float64(m.WebsocketQueueDepth()))
+
+	dropped := ns + "_websocket_dropped_messages_total"
+	writeHeader(b, dropped, "counter", "The websocket messages which dropped because of the full send queues.")
+	writeSample(b, dropped, "", float64(m.WebsocketDroppedMessages()))
+
+	slowDisconnects := ns + "_websocket_slow_consumer_disconnects_total"
+	writeHeader(b, slowDisconnects, "counter", "The websocket clients which disconnected because of their full send queue.")
+	writeSample(b, slowDisconnects, "", float64(m.WebsocketSlowConsumerDisconnects()))
+
+	return b.Bytes()
+}
+
+func writeHeader(b *bytes.Buffer, name, typ, help string) {
+	b.WriteString("# HELP " + name + " " + help + "\n")
+	b.WriteString("# TYPE " + name + " " + typ + "\n")
+}
+
+func writeSample(b *bytes.Buffer, name, labels string, value float64) {
+	b.WriteString(name)
+	b.WriteString(labels)
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+// labels returns the series' labels, the le is the histogram's bucket upper bound, if not empty.
+func labels(k seriesKey, le string) string {
+	s := `{route="` + labelReplacer.Replace(k.route) +
+		`",method="` + labelReplacer.Replace(k.method) +
+		`",subdomain="` + labelReplacer.Replace(k.subdomain) +
+		`",code="` + strconv.Itoa(k.code) + `"`
+	if le != "" {
+		s += `,le="` + le + `"`
+	}
+	return s + "}"
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,194 @@
+// Package metrics provides a Prometheus-style metrics adaptor for Iris,
+// it records the requests' counters and durations of every route, labelled by
+// the route's name, method, subdomain and status code, the in-flight requests
+// and the websocket connections and their send queues and exposes them in the Prometheus' text format.
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+)
+
+// EndpointRouteName is the name of the route of the text exposition handler.
+const EndpointRouteName = "iris2-metrics"
+
+type (
+	// Metrics is the metrics adaptor, see New.
+	Metrics struct {
+		config Config
+
+		mu     sync.Mutex
+		series map[seriesKey]*series
+
+		inFlight   int64 // atomic
+		websockets int64 // atomic
+		// the tracked websocket servers, protected by the mu.
+		websocketServers []websocket.Server
+	}
+
+	seriesKey struct {
+		route     string
+		method    string
+		subdomain string
+		code      int
+	}
+
+	// series are the counter and the duration's histogram of a route and a status code.
+	series struct {
+		count   uint64
+		sum     float64
+		buckets []uint64 // not cumulative, one per config.Buckets
+	}
+)
+
+// New returns a new metrics policy adaptor, i.e
+// app.Adapt(metrics.New()).
+//
+// The routes are wrapped at the Boot state, adapt it after
+// any adaptor which registers routes on Boot (i.e the websocket).
+func New(cfg ...Config) *Metrics {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = c.MergeSingle(cfg[0])
+	}
+
+	return &Metrics{
+		config: c,
+		series: make(map[seriesKey]*series),
+	}
+}
+
+// Adapt implements the iris' adaptor, it wraps the middleware of every registered route
+// and registers the text exposition handler to the config's Endpoint.
+func (m *Metrics) Adapt(frame *iris2.Policies) {
+	evt := iris2.EventPolicy{
+		Boot: func(f *iris2.Framework) {
+			repo, ok := f.Routes().(iris2.RouteRepository)
+			if !ok {
+				f.Log("metrics: the framework's routes are read-only, metrics adaptor stops")
+				return
+			}
+
+			var routes []iris2.RouteInfo
+			repo.Visit(func(r iris2.RouteInfo) {
+				routes = append(routes, r)
+			})
+			for _, r := range routes {
+				if r.Name() == EndpointRouteName {
+					continue
+				}
+				repo.ChangeMiddleware(r, append(iris2.Middleware{m.wrap(r)}, r.Middleware()...))
+			}
+
+			if !m.config.DisableEndpoint && f.Routes().Lookup(EndpointRouteName) == nil {
+				f.Get(m.config.Endpoint, m.Handler()).ChangeName(EndpointRouteName)
+			}
+		},
+	}
+
+	evt.Adapt(frame)
+}
+
+// wrap returns the handler which records the requests of the route,
+// it's the first handler of the route's middleware.
+func (m *Metrics) wrap(r iris2.RouteInfo) iris2.HandlerFunc {
+	name, method, subdomain := r.Name(), r.Method(), r.Subdomain()
+
+	return func(ctx *iris2.Context) {
+		atomic.AddInt64(&m.inFlight, 1)
+		start := time.Now()
+		defer func() {
+			atomic.AddInt64(&m.inFlight, -1)
+			key := seriesKey{route: name, method: method, subdomain: subdomain, code: ctx.ResponseWriter.StatusCode()}
+			m.observe(key, time.Since(start))
+		}()
+
+		ctx.Next()
+	}
+}
+
+func (m *Metrics) observe(key seriesKey, d time.Duration) {
+	seconds := d.Seconds()
+
+	m.mu.Lock()
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{buckets: make([]uint64, len(m.config.Buckets))}
+		m.series[key] = s
+	}
+	s.count++
+	s.sum += seconds
+	for i, upper := range m.config.Buckets {
+		if seconds <= upper {
+			s.buckets[i]++
+			break
+		}
+	}
+	m.mu.Unlock()
+}
+
+// InFlight returns the number of the requests which are being served.
+func (m *Metrics) InFlight() int64 {
+	return atomic.LoadInt64(&m.inFlight)
+}
+
+// WebsocketConnections returns the number of the connected websocket clients, see TrackWebsocket.
+func (m *Metrics) WebsocketConnections() int64 {
+	return atomic.LoadInt64(&m.websockets)
+}
+
+// WebsocketQueueDepth returns the number of the messages which wait to be written
+// to the websocket clients, see TrackWebsocket.
+func (m *Metrics) WebsocketQueueDepth() (depth int64) {
+	m.mu.Lock()
+	servers := m.websocketServers
+	m.mu.Unlock()
+	for _, server := range servers {
+		depth += server.QueueDepth()
+	}
+	return
+}
+
+// WebsocketDroppedMessages returns the number of the messages which dropped
+// because of the full send queues of the websocket clients, see TrackWebsocket.
+func (m *Metrics) WebsocketDroppedMessages() (dropped uint64) {
+	m.mu.Lock()
+	servers := m.websocketServers
+	m.mu.Unlock()
+	for _, server := range servers {
+		dropped += server.DroppedMessages()
+	}
+	return
+}
+
+// WebsocketSlowConsumerDisconnects returns the number of the websocket clients
+// which disconnected because of their full send queue, see TrackWebsocket.
+func (m *Metrics) WebsocketSlowConsumerDisconnects() (disconnects uint64) {
+	m.mu.Lock()
+	servers := m.websocketServers
+	m.mu.Unlock()
+	for _, server := range servers {
+		disconnects += server.SlowConsumerDisconnects()
+	}
+	return
+}
+
+// TrackWebsocket tracks the connections of a websocket server, i.e
+// ws := websocket.New(websocket.Config{Endpoint: "/ws"})
+// m.TrackWebsocket(ws).
+func (m *Metrics) TrackWebsocket(server websocket.Server) {
+	m.mu.Lock()
+	m.websocketServers = append(m.websocketServers, server)
+	m.mu.Unlock()
+
+	server.OnConnection(func(c websocket.Connection) {
+		atomic.AddInt64(&m.websockets, 1)
+		c.OnDisconnect(func() {
+			atomic.AddInt64(&m.websockets, -1)
+		})
+	})
+}
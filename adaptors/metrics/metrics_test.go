@@ -0,0 +1,149 @@
+package metrics_test
+
+import (
+	"net/http"
+	nethttptest "net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/metrics"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+	"github.com/go-iris2/iris2/httptest"
+	gorilla "github.com/gorilla/websocket"
+)
+
+// expectSample fails if the exposition doesn't contain the sample line.
+func expectSample(t *testing.T, exposition string, sample string) {
+	t.Helper()
+	for _, line := range strings.Split(exposition, "\n") {
+		if line == sample {
+			return
+		}
+	}
+	t.Fatalf("expected the sample '%s' but got:\n%s", sample, exposition)
+}
+
+func TestMetricsExposition(t *testing.T) {
+	app := iris2.New()
+	m := metrics.New(metrics.Config{Namespace: "myapp", Buckets: []float64{0.05, 10}})
+	app.Adapt(m)
+	var inFlight int64
+	app.Get("/users/:id", func(ctx *iris2.Context) {
+		inFlight = m.InFlight()
+		ctx.WriteString("user")
+	}).ChangeName("user")
+	app.Get("/slow", func(ctx *iris2.Context) {
+		time.Sleep(100 * time.Millisecond)
+		ctx.EmitError(http.StatusServiceUnavailable)
+	}).ChangeName(`slow "route"`)
+	e := httptest.New(app, t)
+
+	e.GET("/users/1").Expect().Status(http.StatusOK)
+	e.GET("/users/2").Expect().Status(http.StatusOK)
+	e.GET("/slow").Expect().Status(http.StatusServiceUnavailable)
+	if inFlight != 1 {
+		t.Fatalf("expected one request in flight while serving but got %d", inFlight)
+	}
+
+	r := e.GET(metrics.DefaultEndpoint).Expect().Status(http.StatusOK)
+	r.Header("Content-Type").Equal(metrics.ContentType)
+	body := r.Body().Raw()
+
+	expectSample(t, body, "# HELP myapp_http_requests_total Total number of HTTP requests by route, method, subdomain and status code.")
+	expectSample(t, body, "# TYPE myapp_http_requests_total counter")
+	expectSample(t, body, `myapp_http_requests_total{route="user",method="GET",subdomain="",code="200"} 2`)
+	// the label values are escaped.
+	expectSample(t, body, `myapp_http_requests_total{route="slow \"route\"",method="GET",subdomain="",code="503"} 1`)
+
+	// the buckets are cumulative.
+	expectSample(t, body, "# TYPE myapp_http_request_duration_seconds histogram")
+	expectSample(t, body, `myapp_http_request_duration_seconds_bucket{route="user",method="GET",subdomain="",code="200",le="0.05"} 2`)
+	expectSample(t, body, `myapp_http_request_duration_seconds_bucket{route="user",method="GET",subdomain="",code="200",le="10"} 2`)
+	expectSample(t, body, `myapp_http_request_duration_seconds_bucket{route="user",method="GET",subdomain="",code="200",le="+Inf"} 2`)
+	expectSample(t, body, `myapp_http_request_duration_seconds_count{route="user",method="GET",subdomain="",code="200"} 2`)
+	expectSample(t, body, `myapp_http_request_duration_seconds_bucket{route="slow \"route\"",method="GET",subdomain="",code="503",le="0.05"} 0`)
+	expectSample(t, body, `myapp_http_request_duration_seconds_bucket{route="slow \"route\"",method="GET",subdomain="",code="503",le="10"} 1`)
+	sum := regexp.MustCompile(`(?m)^myapp_http_request_duration_seconds_sum\{route="slow \\"route\\"",method="GET",subdomain="",code="503"\} 0\.1\d*$`)
+	if !sum.MatchString(body) {
+		t.Fatalf("expected the sum of the slow route's durations but got:\n%s", body)
+	}
+
+	// the exposition's request is still in flight, its own route is not recorded.
+	expectSample(t, body, "myapp_http_requests_in_flight 0")
+	if strings.Contains(body, metrics.EndpointRouteName) {
+		t.Fatalf("expected the metrics' endpoint not to be recorded but got:\n%s", body)
+	}
+	expectSample(t, body, "# TYPE myapp_websocket_connections gauge")
+	expectSample(t, body, "myapp_websocket_connections 0")
+	expectSample(t, body, "# TYPE myapp_websocket_dropped_messages_total counter")
+	expectSample(t, body, "myapp_websocket_slow_consumer_disconnects_total 0")
+}
+
+func TestMetricsDisableEndpoint(t *testing.T) {
+	app := iris2.New()
+	m := metrics.New(metrics.Config{DisableEndpoint: true})
+	app.Adapt(m)
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.WriteString("index")
+	}).ChangeName("index")
+	app.Get("/internal/metrics", m.Handler())
+	e := httptest.New(app, t)
+
+	e.GET("/").Expect().Status(http.StatusOK)
+	e.GET(metrics.DefaultEndpoint).Expect().Status(http.StatusNotFound)
+	body := e.GET("/internal/metrics").Expect().Status(http.StatusOK).Body().Raw()
+	expectSample(t, body, `iris2_http_requests_total{route="index",method="GET",subdomain="",code="200"} 1`)
+}
+
+func TestMetricsWebsocket(t *testing.T) {
+	app := iris2.New()
+	ws := websocket.New(websocket.Config{Endpoint: "/socket"})
+	app.Adapt(ws)
+	m := metrics.New()
+	m.TrackWebsocket(ws)
+	app.Adapt(m)
+
+	connected := make(chan struct{})
+	disconnected := make(chan struct{})
+	ws.OnConnection(func(c websocket.Connection) {
+		c.On("ready", func() {
+			close(connected)
+		})
+		c.OnDisconnect(func() {
+			close(disconnected)
+		})
+	})
+	app.Boot()
+	srv := nethttptest.NewServer(app.Router)
+	defer srv.Close()
+
+	conn, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/socket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ready, err := websocket.SerializeMessage("ready", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.WriteMessage(gorilla.TextMessage, ready); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-connected:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for the connection")
+	}
+	expectSample(t, string(m.Expose()), "iris2_websocket_connections 1")
+
+	conn.Close()
+	select {
+	case <-disconnected:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for the disconnect")
+	}
+	// the disconnect listeners are called in order, the metrics' one first.
+	expectSample(t, string(m.Expose()), "iris2_websocket_connections 0")
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/sessions"
+)
+
+func main() {
+	app := iris2.New()
+
+	mySessions := sessions.NewCookie(sessions.CookieConfig{
+		Config: sessions.Config{
+			Cookie:   "mysession",
+			Expires:  time.Hour * 2,
+			HTTPOnly: true,
+		},
+		// AES-256 keys, the first one encrypts, all of them decrypt.
+		// Prepend a new key to rotate them, remove the old one when its sessions are expired.
+		Keys: [][]byte{
+			[]byte(os.Getenv("SESSION_KEY")),     // 32 bytes
+			[]byte(os.Getenv("SESSION_OLD_KEY")), // 32 bytes
+		},
+		// the session is splitted to the "mysession", "mysession_1"... cookies,
+		// up to 4 cookies of 4000 bytes by default.
+		MaxChunks: 4,
+		OnError: func(res http.ResponseWriter, req *http.Request, err error) {
+			// i.e sessions.ErrCookieTooLarge
+			app.Log("%s", err)
+		},
+	})
+
+	app.Adapt(mySessions)
+
+	app.Get("/set", func(ctx *iris2.Context) {
+		// change the session before writing the body,
+		// the cookies are sent by the response's headers.
+		ctx.Session().Set("name", "iris")
+		ctx.Session().SetFlash("msg", "saved on the client")
+		ctx.Writef("All ok session setted to: %s", ctx.Session().GetString("name"))
+	})
+
+	app.Get("/get", func(ctx *iris2.Context) {
+		ctx.Writef("The name on the /set was: %s, %s",
+			ctx.Session().GetString("name"), ctx.Session().GetFlashString("msg"))
+	})
+
+	app.Get("/destroy", func(ctx *iris2.Context) {
+		// removes the session's cookies
+		ctx.SessionDestroy()
+	})
+
+	app.Listen(":8080")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/go-iris2/iris2"
@@ -20,22 +21,33 @@ func main() {
 		// -1 means expire when browser closes
 		// or set a value, like 2 hours:
 		Expires: time.Hour * 2,
+		// the session is destroyed if it's not used for 30 minutes,
+		// each request of the session starts it again.
+		IdleTimeout: time.Minute * 30,
 		// the length of the sessionid's cookie's value
 		CookieLength: 32,
 		// if you want to invalid cookies on different subdomains
 		// of the same host, then enable it
 		DisableSubdomainPersistence: false,
+		// the browsers send the cookie only over https and not on cross-site requests
+		CookieSecure:   true,
+		CookieSameSite: http.SameSiteLaxMode,
+		// BindIP: true, // the cookie is not valid from another IP
 	})
 
 	// OPTIONALLY:
 	// import "github.com/go-iris2/iris2/adaptors/sessions/sessiondb/redis"
+	// or import "github.com/go-iris2/iris2/adaptors/sessions/sessiondb/sql"
+	// storage, err := sql.New(db, sql.Config{Dialect: sql.Postgres}) // db is a *database/sql.DB
+	// or import "github.com/go-iris2/iris2/adaptors/sessions/sessiondb/bolt"
+	// storage, err := bolt.New(bolt.Config{Path: "./sessions.db", Bucket: "myapp"})
 	// or import "github.com/go-iris2/iris2/sessions/sessiondb/$any_available_community_database"
 	// mySessions.UseDatabase(redis.New(...))
 
 	app.Adapt(mySessions) // Adapt the session manager we just created.
 
 	app.Get("/", func(ctx *iris2.Context) {
-		ctx.Writef("You should navigate to the /set, /get, /delete, /clear,/destroy instead")
+		ctx.Writef("You should navigate to the /set, /get, /delete, /clear, /login, /destroy instead")
 	})
 	app.Get("/set", func(ctx *iris2.Context) {
 
@@ -63,6 +75,12 @@ func main() {
 		ctx.Session().Clear()
 	})
 
+	app.Get("/login", func(ctx *iris2.Context) {
+		// give a new id to the session, keeping its values,
+		// after a login in order to protect against session fixation.
+		ctx.SessionRegenerate().Set("authenticated", true)
+	})
+
 	app.Get("/destroy", func(ctx *iris2.Context) {
 
 		//destroy, removes the entire session and cookie
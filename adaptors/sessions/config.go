@@ -1,6 +1,7 @@
 package sessions
 
 import (
+	"net/http"
 	"time"
 )
 
@@ -32,9 +33,18 @@ type (
 		// -1 means when browser closes
 		// > 0 is the time.Duration which the session cookies should expire.
 		//
+		// It's the absolute expiration, the session is destroyed after this duration
+		// from its creation even if it's used, see IdleTimeout for the sliding expiration.
+		//
 		// Defaults to infinitive/unlimited life duration(0)
 		Expires time.Duration
 
+		// IdleTimeout is the sliding expiration, the session is destroyed
+		// if it's not used for this duration, each request of the session starts it again.
+		//
+		// Defaults to 0, the sessions are not destroyed when they are idle
+		IdleTimeout time.Duration
+
 		// CookieLength the length of the sessionid's cookie's value, let it to 0 if you don't want to change it
 		//
 		// Defaults to 32
@@ -45,10 +55,32 @@ type (
 		// javascript. Defaults to false.
 		HTTPOnly bool
 
+		// CookieSecure sets the Secure flag of the session's cookie,
+		// the browsers send it only over https, enable it when serving over TLS.
+		// Defaults to false.
+		CookieSecure bool
+
+		// CookieSameSite is the SameSite attribute of the session's cookie,
+		// i.e http.SameSiteLaxMode or http.SameSiteStrictMode.
+		// Defaults to 0, no SameSite attribute.
+		CookieSameSite http.SameSite
+
+		// BindIP, if true, binds the sessions to the client's IP,
+		// the session is not found when the same cookie comes from a different IP.
+		// Keep it disabled when the clients may change their IPs, i.e mobile networks.
+		// Defaults to false.
+		BindIP bool
+
 		// SessionStorage is the database engine for the sessions, by default sessions
 		// are stored in RAM, and not saved anywhere
 		SessionStorage Database
 
+		// OnExpire, if not nil, is called with the session's id when a session is expired,
+		// by the Expires or the IdleTimeout, in memory or, if it's an ExpireNotifier, in the SessionStorage.
+		//
+		// Defaults to nil
+		OnExpire func(sid string)
+
 		// DisableSubdomainPersistence set it to true in order dissallow your q subdomains to have access to the session cookie
 		//
 		// Defaults to false
@@ -32,6 +32,27 @@ func SetCookie(cookie *http.Cookie, res http.ResponseWriter) {
 	}
 }
 
+// removeSetCookie removes the response's Set-Cookie headers of the cookies which their names match.
+func removeSetCookie(res http.ResponseWriter, match func(name string) bool) {
+	h := res.Header()
+	cookies := h["Set-Cookie"]
+	if len(cookies) == 0 {
+		return
+	}
+
+	kept := cookies[:0]
+	for _, c := range cookies {
+		if idx := strings.IndexByte(c, '='); idx <= 0 || !match(c[:idx]) {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		h.Del("Set-Cookie")
+		return
+	}
+	h["Set-Cookie"] = kept
+}
+
 // RemoveCookie deletes a cookie by it's name/key
 func RemoveCookie(name string, res http.ResponseWriter, req *http.Request) {
 	c, err := req.Cookie(name)
@@ -0,0 +1,333 @@
+package sessions
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/errors"
+	"gopkg.in/vmihailenco/msgpack.v2"
+)
+
+const (
+	// DefaultCookieChunkSize is the max length of a cookie's value of the cookie sessions, 4000 bytes
+	DefaultCookieChunkSize = 4000
+	// DefaultCookieMaxChunks is the max number of the cookies of a cookie session, 4
+	DefaultCookieMaxChunks = 4
+)
+
+var (
+	// ErrCookieTooLarge is passed to the CookieConfig.OnError when the encoded session
+	// doesn't fit on the CookieConfig.MaxChunks cookies, the session's changes are not sent to the client.
+	ErrCookieTooLarge = errors.New("sessions: cookie session of %d bytes exceeds the %d cookies of %d bytes")
+
+	errCookieKeys = errors.New("sessions: the cookie sessions need at least one AES key of 16, 24 or 32 bytes: %s")
+)
+
+// CookieConfig is the configuration for the cookie sessions, see NewCookie.
+type CookieConfig struct {
+	// Config are the options of the session's cookie, the Cookie is the name of the first cookie
+	// and the next chunks are named by the "_1", "_2"... suffixes.
+	// The SessionStorage is ignored, the session's values are stored on the cookies.
+	Config
+
+	// Keys are the AES-GCM keys, 16, 24 or 32 bytes each (AES-128, AES-192 or AES-256).
+	// The first key encrypts the sessions and all of them can decrypt,
+	// so a new key can be prepended in order to rotate them without logging out the users.
+	// Removing a key invalidates all the sessions which are encrypted by it.
+	//
+	// At least one key is required.
+	Keys [][]byte
+
+	// ChunkSize is the max length of a cookie's value. Defaults to 4000 bytes
+	ChunkSize int
+	// MaxChunks is the max number of the cookies of a session,
+	// larger sessions are not sent to the client, see OnError. Defaults to 4
+	MaxChunks int
+
+	// OnError, if not nil, is called when a session can't be stored on the cookies,
+	// i.e with the ErrCookieTooLarge. The invalid or expired incoming cookies are not errors,
+	// they are ignored and a new session is started. Defaults to nil
+	OnError func(http.ResponseWriter, *http.Request, error)
+}
+
+// cookiePayload is the encrypted content of the cookies.
+type cookiePayload struct {
+	ID      string                 `msgpack:"i"`
+	Values  map[string]interface{} `msgpack:"v"`
+	Flashes map[string]interface{} `msgpack:"f"`
+	// Created is the unix time, in nanoseconds, of the session's creation, checked against the Expires.
+	Created int64 `msgpack:"c"`
+	// Time is the unix time, in nanoseconds, of the last request, checked against the IdleTimeout.
+	Time int64 `msgpack:"t"`
+	// IP is the client's IP, if BindIP is enabled.
+	IP string `msgpack:"a"`
+}
+
+type cookieSessions struct {
+	config CookieConfig
+	aeads  []cipher.AEAD
+}
+
+var _ Sessions = &cookieSessions{}
+
+// NewCookie returns a new sessions manager which stores the sessions on the client,
+// on authenticated-encrypted (AES-GCM) cookies, instead of the server,
+// so any instance behind a load balancer can serve them.
+// It panics if the Keys are invalid.
+//
+// The session's changes are sent by the Set-Cookie headers,
+// so the session should be changed before writing the response's body.
+// The flash messages are kept on the cookies too, until they are fetched.
+func NewCookie(cfg CookieConfig) Sessions {
+	cfg.Config = cfg.Config.Validate()
+	if cfg.ChunkSize <= 0 {
+		cfg.ChunkSize = DefaultCookieChunkSize
+	}
+	if cfg.MaxChunks <= 0 {
+		cfg.MaxChunks = DefaultCookieMaxChunks
+	}
+
+	if len(cfg.Keys) == 0 {
+		panic(errCookieKeys.Format("no keys"))
+	}
+	aeads := make([]cipher.AEAD, len(cfg.Keys))
+	for i, key := range cfg.Keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			panic(errCookieKeys.Format(err))
+		}
+		aeads[i], err = cipher.NewGCM(block)
+		if err != nil {
+			panic(errCookieKeys.Format(err))
+		}
+	}
+
+	return &cookieSessions{config: cfg, aeads: aeads}
+}
+
+func (s *cookieSessions) Adapt(frame *iris2.Policies) {
+	policy := iris2.SessionsPolicy{
+		Start:      s.Start,
+		Destroy:    s.Destroy,
+		Regenerate: s.Regenerate,
+	}
+
+	policy.Adapt(frame)
+}
+
+// Start starts the session for the particular net/http request,
+// the session is decrypted from the request's cookies, if they are valid, otherwise a new one is started.
+func (s *cookieSessions) Start(res http.ResponseWriter, req *http.Request) iris2.Session {
+	sess := &session{
+		values:  make(map[string]interface{}),
+		flashes: make(map[string]*flashMessage),
+	}
+
+	if p, ok := s.decode(req); ok {
+		sess.sid = p.ID
+		sess.created = time.Unix(0, p.Created)
+		if p.Values != nil {
+			sess.values = p.Values
+		}
+		for key, v := range p.Flashes {
+			sess.flashes[key] = &flashMessage{value: v}
+		}
+	} else {
+		sess.sid = sessionIDGenerator(s.config.CookieLength)
+		sess.created = time.Now()
+	}
+
+	sess.onSave = func(sess *session) {
+		s.save(sess, res, req)
+	}
+	// We always set the cookies, the IdleTimeout starts again.
+	s.save(sess, res, req)
+	return sess
+}
+
+// Regenerate gives a new id to the request's session, keeping its values, and sends the new cookies.
+//
+// Note that the previous cookies are still valid until they expire,
+// the cookie sessions are not stored on the server.
+func (s *cookieSessions) Regenerate(res http.ResponseWriter, req *http.Request, current iris2.Session) iris2.Session {
+	sess, ok := current.(*session)
+	if !ok || sess.onSave == nil {
+		return current
+	}
+
+	sess.mu.Lock()
+	sess.sid = sessionIDGenerator(s.config.CookieLength)
+	sess.mu.Unlock()
+
+	s.save(sess, res, req)
+	return sess
+}
+
+// Destroy removes the session's cookies
+func (s *cookieSessions) Destroy(res http.ResponseWriter, req *http.Request) {
+	removeSetCookie(res, s.isChunk)
+	for _, name := range s.requestChunks(req) {
+		RemoveCookie(name, res, req)
+	}
+}
+
+// End does nothing, the changes are sent by the Set-Cookie headers.
+func (s *cookieSessions) End(iris2.Session) {}
+
+// DestroyByID does nothing, the cookie sessions are not stored on the server,
+// remove the Keys in order to invalidate all the sessions.
+func (s *cookieSessions) DestroyByID(string) {}
+
+// DestroyAll does nothing, the cookie sessions are not stored on the server,
+// remove the Keys in order to invalidate all the sessions.
+func (s *cookieSessions) DestroyAll() {}
+
+// chunkName returns the name of the i cookie of a session.
+func (s *cookieSessions) chunkName(i int) string {
+	if i == 0 {
+		return s.config.Cookie
+	}
+	return s.config.Cookie + "_" + strconv.Itoa(i)
+}
+
+// requestChunks returns the names of the session's cookies which the request has.
+func (s *cookieSessions) requestChunks(req *http.Request) (names []string) {
+	for i := 0; i < s.config.MaxChunks; i++ {
+		name := s.chunkName(i)
+		if _, err := req.Cookie(name); err != nil {
+			break
+		}
+		names = append(names, name)
+	}
+	return
+}
+
+func (s *cookieSessions) decode(req *http.Request) (p cookiePayload, ok bool) {
+	var value string
+	for _, name := range s.requestChunks(req) {
+		value += GetCookie(name, req)
+	}
+	if value == "" {
+		return
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return
+	}
+
+	var plain []byte
+	for _, aead := range s.aeads {
+		n := aead.NonceSize()
+		if len(data) < n {
+			return
+		}
+		// the cookie's name is authenticated too, the values can't be moved to another cookie session.
+		if plain, err = aead.Open(nil, data[:n], data[n:], []byte(s.config.Cookie)); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	if err = msgpack.Unmarshal(plain, &p); err != nil || p.ID == "" {
+		return
+	}
+	if s.config.Expires > 0 && time.Since(time.Unix(0, p.Created)) > s.config.Expires {
+		return
+	}
+	if s.config.IdleTimeout > 0 && time.Since(time.Unix(0, p.Time)) > s.config.IdleTimeout {
+		return
+	}
+	if s.config.BindIP && p.IP != clientIP(req) {
+		return
+	}
+
+	return p, true
+}
+
+func (s *cookieSessions) encode(sess *session, req *http.Request) (string, error) {
+	p := cookiePayload{
+		Created: sess.created.UnixNano(),
+		Time:    time.Now().UnixNano(),
+	}
+	if s.config.BindIP {
+		p.IP = clientIP(req)
+	}
+
+	sess.mu.RLock()
+	p.ID = sess.sid
+	p.Values = sess.values
+	p.Flashes = make(map[string]interface{}, len(sess.flashes))
+	for key, v := range sess.flashes {
+		// the fetched flash messages are not sent back.
+		if !v.shouldRemove {
+			p.Flashes[key] = v.value
+		}
+	}
+	plain, err := msgpack.Marshal(p)
+	sess.mu.RUnlock()
+	if err != nil {
+		return "", err
+	}
+
+	aead := s.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plain)+aead.Overhead())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, []byte(s.config.Cookie))), nil
+}
+
+// save sends the session's cookies, it replaces the cookies which are already set by this response.
+func (s *cookieSessions) save(sess *session, res http.ResponseWriter, req *http.Request) {
+	value, err := s.encode(sess, req)
+	if err == nil && len(value) > s.config.ChunkSize*s.config.MaxChunks {
+		err = ErrCookieTooLarge.Format(len(value), s.config.MaxChunks, s.config.ChunkSize)
+	}
+	if err != nil {
+		if s.config.OnError != nil {
+			s.config.OnError(res, req, err)
+		}
+		return
+	}
+
+	removeSetCookie(res, s.isChunk)
+
+	n := 0
+	for ; len(value) > 0; n++ {
+		chunk := value
+		if len(chunk) > s.config.ChunkSize {
+			chunk = chunk[:s.config.ChunkSize]
+		}
+		value = value[len(chunk):]
+		SetCookie(buildCookie(s.config.Config, s.chunkName(n), chunk, req.URL.Host, sess.created), res)
+	}
+
+	// remove the previous chunks which are not needed anymore.
+	if previous := s.requestChunks(req); len(previous) > n {
+		for _, name := range previous[n:] {
+			RemoveCookie(name, res, req)
+		}
+	}
+}
+
+// isChunk reports whether the name is the name of one of the session's cookies.
+func (s *cookieSessions) isChunk(name string) bool {
+	if name == s.config.Cookie {
+		return true
+	}
+	if !strings.HasPrefix(name, s.config.Cookie+"_") {
+		return false
+	}
+	i, err := strconv.Atoi(name[len(s.config.Cookie)+1:])
+	return err == nil && i > 0 && i < s.config.MaxChunks
+}
@@ -0,0 +1,271 @@
+package sessions
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testCookieKey  = bytes.Repeat([]byte("k"), 32)
+	testCookieKey2 = bytes.Repeat([]byte("n"), 16)
+)
+
+// cookieRequest returns a request with the cookies which the res sets, the removed ones are skipped.
+func cookieRequest(res *httptest.ResponseRecorder) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range res.Result().Cookies() {
+		if c.MaxAge >= 0 && c.Value != "" {
+			req.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+	}
+	return req
+}
+
+// setCookies returns the cookies which the res sets by their names.
+func setCookies(res *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, c := range res.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return cookies
+}
+
+func TestCookieSessions(t *testing.T) {
+	s := NewCookie(CookieConfig{Config: Config{Cookie: "sid"}, Keys: [][]byte{testCookieKey}})
+
+	res := httptest.NewRecorder()
+	sess := s.Start(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	sess.Set("name", "iris")
+	sess.SetFlash("message", "welcome")
+
+	// the values are not readable by the client.
+	value := setCookies(res)["sid"].Value
+	if strings.Contains(value, "iris") || strings.Contains(value, sess.ID()) {
+		t.Fatalf("expected an encrypted cookie but got '%s'", value)
+	}
+
+	next := httptest.NewRecorder()
+	reloaded := s.Start(next, cookieRequest(res))
+	if reloaded.ID() != sess.ID() || reloaded.GetString("name") != "iris" {
+		t.Fatalf("expected the session '%s' with its values but got '%s' %v", sess.ID(), reloaded.ID(), reloaded.GetAll())
+	}
+	if reloaded.GetFlashString("message") != "welcome" {
+		t.Fatal("expected the flash message to be kept on the cookie")
+	}
+
+	// the fetched flash messages are not sent back.
+	last := s.Start(httptest.NewRecorder(), cookieRequest(next))
+	if last.ID() != sess.ID() || last.HasFlash() {
+		t.Fatalf("expected the session without the fetched flash message but got %v", last.GetFlashes())
+	}
+}
+
+func TestCookieSessionsTampered(t *testing.T) {
+	s := NewCookie(CookieConfig{Config: Config{Cookie: "sid"}, Keys: [][]byte{testCookieKey}})
+
+	res := httptest.NewRecorder()
+	sess := s.Start(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	sess.Set("role", "user")
+	value := setCookies(res)["sid"].Value
+
+	flipped := []byte(value)
+	i := len(flipped) / 2
+	if flipped[i] == 'A' {
+		flipped[i] = 'B'
+	} else {
+		flipped[i] = 'A'
+	}
+
+	for name, tampered := range map[string]string{
+		"flipped":   string(flipped),
+		"truncated": value[:len(value)-4],
+		"short":     value[:8],
+		"invalid":   value + "!",
+		"appended":  value + "AAAA",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: tampered})
+		started := s.Start(httptest.NewRecorder(), req)
+		if started.ID() == sess.ID() || started.Get("role") != nil {
+			t.Fatalf("expected a new session for the %s cookie", name)
+		}
+	}
+
+	// the cookie's name is authenticated, the value can't be moved to another cookie session.
+	other := NewCookie(CookieConfig{Config: Config{Cookie: "other"}, Keys: [][]byte{testCookieKey}})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "other", Value: value})
+	if started := other.Start(httptest.NewRecorder(), req); started.ID() == sess.ID() {
+		t.Fatal("expected a new session for a value of another cookie")
+	}
+}
+
+func TestCookieSessionsKeys(t *testing.T) {
+	old := NewCookie(CookieConfig{Config: Config{Cookie: "sid"}, Keys: [][]byte{testCookieKey}})
+	res := httptest.NewRecorder()
+	sess := old.Start(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	sess.Set("name", "iris")
+
+	// a new key is prepended, the sessions of the previous key are still valid and they are re-encrypted.
+	rotated := NewCookie(CookieConfig{Config: Config{Cookie: "sid"}, Keys: [][]byte{testCookieKey2, testCookieKey}})
+	next := httptest.NewRecorder()
+	if reloaded := rotated.Start(next, cookieRequest(res)); reloaded.ID() != sess.ID() || reloaded.GetString("name") != "iris" {
+		t.Fatal("expected the session of the previous key to be decrypted")
+	}
+
+	removed := NewCookie(CookieConfig{Config: Config{Cookie: "sid"}, Keys: [][]byte{testCookieKey2}})
+	if reloaded := removed.Start(httptest.NewRecorder(), cookieRequest(next)); reloaded.ID() != sess.ID() {
+		t.Fatal("expected the session which is re-encrypted by the new key to be decrypted")
+	}
+	if reloaded := removed.Start(httptest.NewRecorder(), cookieRequest(res)); reloaded.ID() == sess.ID() {
+		t.Fatal("expected the sessions of a removed key to be invalid")
+	}
+
+	for _, keys := range [][][]byte{nil, {[]byte("short")}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected a panic for the keys %v", keys)
+				}
+			}()
+			NewCookie(CookieConfig{Config: Config{Cookie: "sid"}, Keys: keys})
+		}()
+	}
+}
+
+func TestCookieSessionsChunks(t *testing.T) {
+	var errs []error
+	s := NewCookie(CookieConfig{
+		Config:    Config{Cookie: "sid"},
+		Keys:      [][]byte{testCookieKey},
+		ChunkSize: 100,
+		MaxChunks: 8,
+		OnError: func(_ http.ResponseWriter, _ *http.Request, err error) {
+			errs = append(errs, err)
+		},
+	}).(*cookieSessions)
+
+	res := httptest.NewRecorder()
+	sess := s.Start(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	large := strings.Repeat("a", 250)
+	sess.Set("large", large)
+
+	cookies := setCookies(res)
+	n := len(cookies)
+	if n < 4 {
+		t.Fatalf("expected the session on at least 4 cookies but got %d", n)
+	}
+	for i := 0; i < n; i++ {
+		c := cookies[s.chunkName(i)]
+		if c == nil || c.Value == "" || len(c.Value) > 100 || (i < n-1 && len(c.Value) != 100) {
+			t.Fatalf("expected the chunk %d of the session but got %v", i, c)
+		}
+	}
+	// the Set-Cookie headers of the previous saves are replaced.
+	if got := len(res.Header()["Set-Cookie"]); got != n {
+		t.Fatalf("expected one Set-Cookie per chunk but got %d", got)
+	}
+
+	next := httptest.NewRecorder()
+	reloaded := s.Start(next, cookieRequest(res))
+	if reloaded.ID() != sess.ID() || reloaded.GetString("large") != large {
+		t.Fatal("expected the session to be joined from its chunks")
+	}
+
+	// a missing chunk invalidates the session.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookieRequest(res).Cookies() {
+		if c.Name != s.chunkName(1) {
+			req.AddCookie(c)
+		}
+	}
+	if started := s.Start(httptest.NewRecorder(), req); started.ID() == sess.ID() {
+		t.Fatal("expected a new session for the missing chunk")
+	}
+
+	// a smaller session removes the chunks which are not needed anymore.
+	reloaded.Delete("large")
+	cookies = setCookies(next)
+	m := 0
+	for ; m < n; m++ {
+		if c := cookies[s.chunkName(m)]; c == nil || c.MaxAge < 0 {
+			break
+		}
+	}
+	if m == 0 || m == n {
+		t.Fatalf("expected the smaller session on fewer than %d cookies but got %d", n, m)
+	}
+	for i := m; i < n; i++ {
+		if c := cookies[s.chunkName(i)]; c == nil || c.MaxAge >= 0 {
+			t.Fatalf("expected the chunk %d to be removed but got %v", i, c)
+		}
+	}
+	if reloaded = s.Start(httptest.NewRecorder(), cookieRequest(next)); reloaded.ID() != sess.ID() || reloaded.Get("large") != nil {
+		t.Fatal("expected the smaller session to be joined from its remaining chunks")
+	}
+
+	// a session which doesn't fit on the MaxChunks is not sent.
+	if len(errs) != 0 {
+		t.Fatalf("expected no errors but got %v", errs)
+	}
+	tooLarge := httptest.NewRecorder()
+	sess = s.Start(tooLarge, httptest.NewRequest(http.MethodGet, "/", nil))
+	sess.Set("large", strings.Repeat("a", 1000))
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "exceeds the 8 cookies of 100 bytes") {
+		t.Fatalf("expected the ErrCookieTooLarge but got %v", errs)
+	}
+	if reloaded := s.Start(httptest.NewRecorder(), cookieRequest(tooLarge)); reloaded.ID() != sess.ID() || reloaded.Get("large") != nil {
+		t.Fatal("expected the previous cookies of the session to be kept")
+	}
+}
+
+func TestCookieSessionsExpired(t *testing.T) {
+	s := NewCookie(CookieConfig{Config: Config{Cookie: "sid", Expires: time.Hour}, Keys: [][]byte{testCookieKey}}).(*cookieSessions)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	old := &session{sid: "old", created: time.Now().Add(-2 * time.Hour), values: map[string]interface{}{"k": "v"}}
+	value, err := s.encode(old, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.AddCookie(&http.Cookie{Name: "sid", Value: value})
+	if started := s.Start(httptest.NewRecorder(), req); started.ID() == "old" || started.Get("k") != nil {
+		t.Fatal("expected a new session instead of the expired one")
+	}
+
+	// the same payload is valid while it's not expired.
+	old.created = time.Now()
+	if value, err = s.encode(old, req); err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: value})
+	if started := s.Start(httptest.NewRecorder(), req); started.ID() != "old" {
+		t.Fatalf("expected the session 'old' but got '%s'", started.ID())
+	}
+}
+
+func TestCookieSessionsDestroy(t *testing.T) {
+	s := NewCookie(CookieConfig{Config: Config{Cookie: "sid"}, Keys: [][]byte{testCookieKey}, ChunkSize: 100})
+
+	res := httptest.NewRecorder()
+	s.Start(res, httptest.NewRequest(http.MethodGet, "/", nil)).Set("large", strings.Repeat("a", 150))
+
+	destroyed := httptest.NewRecorder()
+	req := cookieRequest(res)
+	s.Start(destroyed, req)
+	s.Destroy(destroyed, req)
+	cookies := setCookies(destroyed)
+	if len(cookies) != len(req.Cookies()) {
+		t.Fatalf("expected only the removals of the %d cookies but got %v", len(req.Cookies()), destroyed.Header()["Set-Cookie"])
+	}
+	for name, c := range cookies {
+		if c.MaxAge >= 0 || c.Value != "" {
+			t.Fatalf("expected the %s cookie to be removed but got %v", name, c)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package sessions
 
+import (
+	"time"
+)
+
 // Database is the interface which all session databases should implement
-// By design it doesn't support any type of cookie session like other frameworks,
-// I want to protect you, believe me, no context access (although we could)
+// By design a database doesn't store the sessions on the client, no context access (although we could),
+// use the NewCookie sessions manager for the encrypted cookie sessions instead.
 // The scope of the database is to session somewhere the sessions in order to
 //  keep them after restarting the server, nothing more.
 // the values are sessiond by the underline session, the check for new sessions, or
@@ -15,3 +19,40 @@ type Database interface {
 	Load(string) (map[string]interface{}, error)
 	Update(string, map[string]interface{})
 }
+
+// Transaction is the batch of a session's changes, see TransactionalDatabase.
+type Transaction struct {
+	// Set are the new or changed values.
+	Set map[string]interface{}
+	// Delete are the keys of the removed values.
+	Delete []string
+	// Values are all the session's values, after the changes,
+	// for the databases which store the values together.
+	Values map[string]interface{}
+	// TTL is the remaining life of the session, from the Config's Expires and IdleTimeout,
+	// the database can remove the session after it. 0 means unlimited life.
+	TTL time.Duration
+}
+
+// TransactionalDatabase is a Database which stores the changes of a session in batches,
+// once per request, instead of the whole values on each change.
+//
+// The sessions manager uses it, if the database implements it,
+// the Update is not called then.
+type TransactionalDatabase interface {
+	Database
+	// Commit stores the changes of a session, at the end of the request.
+	// A Transaction without changes is committed in order to refresh the TTL,
+	// when the Config's IdleTimeout is used.
+	Commit(sid string, tx Transaction) error
+	// Destroy removes a session.
+	Destroy(sid string) error
+	// DestroyAll removes all the sessions.
+	DestroyAll() error
+}
+
+// ExpireNotifier is a Database which removes the expired sessions by itself, i.e by their TTL,
+// the sessions manager registers a callback which calls the Config's OnExpire.
+type ExpireNotifier interface {
+	OnExpire(cb func(sid string))
+}
@@ -3,8 +3,6 @@ package sessions
 import (
 	"sync"
 	"time"
-
-	"github.com/go-iris2/iris2"
 )
 
 type (
@@ -17,71 +15,187 @@ type (
 		mu       sync.Mutex
 		sessions map[string]*session
 		database Database
+		// tx is the database if it's a TransactionalDatabase, otherwise nil.
+		tx TransactionalDatabase
+		// expires is the absolute and idleTimeout the sliding expiration of the sessions.
+		expires     time.Duration
+		idleTimeout time.Duration
+		onExpire    func(sid string)
+		// log is the framework's logger, set on Boot, for the errors of the TransactionalDatabase.
+		log func(format string, a ...interface{})
 	}
 )
 
 // newProvider returns a new sessions provider
-func newProvider(db Database) *provider {
-	return &provider{
-		sessions: make(map[string]*session, 0),
-		database: db,
+func newProvider(cfg Config) *provider {
+	p := &provider{
+		sessions:    make(map[string]*session, 0),
+		database:    cfg.SessionStorage,
+		expires:     cfg.Expires,
+		idleTimeout: cfg.IdleTimeout,
+		onExpire:    cfg.OnExpire,
+		log:         func(string, ...interface{}) {},
+	}
+
+	if tx, ok := cfg.SessionStorage.(TransactionalDatabase); ok {
+		p.tx = tx
 	}
+	if notifier, ok := cfg.SessionStorage.(ExpireNotifier); ok {
+		notifier.OnExpire(p.expired)
+	}
+	return p
 }
 
+// createdKey is the database's value which keeps the session's creation time,
+// as a time.RFC3339Nano string, for the absolute expiration of the reloaded sessions.
+// It's not one of the session's values.
+const createdKey = "_sessions_created"
+
 // newSession returns a new session from sessionid
-func (p *provider) newSession(sid string, expires time.Duration) *session {
+func (p *provider) newSession(sid string) *session {
+	values, created := p.loadSessionValues(sid)
 	sess := &session{
 		sid:      sid,
 		provider: p,
-		values:   p.loadSessionValues(sid),
+		values:   values,
 		flashes:  make(map[string]*flashMessage),
+		created:  created,
+	}
+	if p.tx != nil {
+		sess.changes = make(map[string]bool)
 	}
 
-	if expires > 0 { // if not unlimited life duration and no -1 (cookie remove action is based on browser's session)
-		sess.timeout = time.AfterFunc(expires, func() {
-			p.Destroy(sid)
+	p.schedule(sess)
+	return sess
+}
+
+// lifetime returns the remaining duration of the session, after a request,
+// 0 means unlimited life duration or until the browser closes (-1 Expires).
+func (p *provider) lifetime(sess *session) (d time.Duration) {
+	if p.idleTimeout > 0 {
+		d = p.idleTimeout
+	}
+	if p.expires > 0 {
+		if left := sess.created.Add(p.expires).Sub(time.Now()); d == 0 || left < d {
+			d = left
+		}
+	}
+	return
+}
+
+// outlived returns true if the session is older than the absolute expiration.
+func (p *provider) outlived(sess *session) bool {
+	return p.expires > 0 && time.Now().Sub(sess.created) >= p.expires
+}
+
+// schedule starts, or starts again, the session's timeout,
+// it's called before the session is registered or with the lock.
+func (p *provider) schedule(sess *session) {
+	d := p.lifetime(sess)
+	if d == 0 {
+		return
+	}
+	if sess.timeout == nil {
+		sess.timeout = time.AfterFunc(d, func() {
+			p.expire(sess)
 		})
+		return
 	}
+	sess.timeout.Reset(d)
+}
 
-	return sess
+// expire destroys the session if it's still registered, its id may be changed by a Regenerate.
+func (p *provider) expire(sess *session) {
+	p.mu.Lock()
+	sid := sess.ID()
+	found := p.sessions[sid] == sess
+	if found {
+		p.destroy(sid, true)
+	}
+	p.mu.Unlock()
+
+	if found && p.onExpire != nil {
+		p.onExpire(sid)
+	}
 }
 
-func (p *provider) loadSessionValues(sid string) map[string]interface{} {
+// expired is the callback of the ExpireNotifier database, the session is expired by the database.
+func (p *provider) expired(sid string) {
+	p.mu.Lock()
+	p.destroy(sid, true)
+	p.mu.Unlock()
+
+	if p.onExpire != nil {
+		p.onExpire(sid)
+	}
+}
+
+// loadSessionValues returns the session's values and its creation time, from the database,
+// the creation time is now if the session is new or it was stored without it.
+func (p *provider) loadSessionValues(sid string) (map[string]interface{}, time.Time) {
+	created := time.Now()
 	if p.database != nil {
 		dbValues, err := p.database.Load(sid)
 		if dbValues != nil && err == nil {
-			return dbValues
+			if v, ok := dbValues[createdKey].(string); ok {
+				if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+					created = t
+				}
+				delete(dbValues, createdKey)
+			}
+			return dbValues, created
 		}
 	}
 
-	return make(map[string]interface{})
+	return make(map[string]interface{}), created
 }
 
 // Init creates the session  and returns it
-func (p *provider) Init(sid string, expires time.Duration) iris2.Session {
-	newSession := p.newSession(sid, expires)
+func (p *provider) Init(sid string) *session {
+	newSession := p.newSession(sid)
 	p.mu.Lock()
 	p.sessions[sid] = newSession
 	p.mu.Unlock()
 	return newSession
 }
 
-// Read returns the store which sid parameter belongs
-func (p *provider) Read(sid string, expires time.Duration) iris2.Session {
+// Read returns the store which sid parameter belongs,
+// it returns nil if the session is expired.
+func (p *provider) Read(sid string) *session {
 	p.mu.Lock()
 	sess, found := p.sessions[sid]
+	if found && p.outlived(sess) {
+		// the timer may not be fired yet.
+		p.destroy(sid, true)
+		p.mu.Unlock()
+		return nil
+	}
+	if found {
+		p.schedule(sess)
+	}
 	p.mu.Unlock()
 	if found {
-		if p.sessions[sid].timeout != nil {
-			p.sessions[sid].timeout.Reset(expires)
-		}
 		sess.runFlashGC()
 		return sess
 	}
 
 	// When it is not in p.sessions it must be in de database
-	// p.Init loads it from there
-	return p.Init(sid, expires)
+	// the newSession loads it from there, with its creation time.
+	sess = p.newSession(sid)
+	if p.outlived(sess) {
+		// expired while it was stored.
+		if sess.timeout != nil {
+			sess.timeout.Stop()
+		}
+		p.mu.Lock()
+		p.destroyDb(sid)
+		p.mu.Unlock()
+		return nil
+	}
+	p.mu.Lock()
+	p.sessions[sid] = sess
+	p.mu.Unlock()
+	return sess
 }
 
 func (p *provider) Exist(sid string) bool {
@@ -97,18 +211,99 @@ func (p *provider) Exist(sid string) bool {
 	return found
 }
 
+// Regenerate moves the session, with its values, to the new sid.
+func (p *provider) Regenerate(sess *session, sid string) {
+	p.mu.Lock()
+	oldSid := sess.ID()
+	delete(p.sessions, oldSid)
+	p.destroyDb(oldSid)
+
+	sess.mu.Lock()
+	sess.sid = sid
+	if sess.changes != nil {
+		// all the values are stored on the next commit.
+		for key := range sess.values {
+			sess.changes[key] = false
+		}
+	}
+	sess.mu.Unlock()
+
+	p.sessions[sid] = sess
+	if p.tx == nil {
+		p.updateDb(sid, sess.storedValues())
+	}
+	p.mu.Unlock()
+}
+
+// End commits the session's changes to the TransactionalDatabase, if any.
+func (p *provider) End(sess *session) {
+	if p.tx == nil {
+		return
+	}
+
+	sess.mu.Lock()
+	if sess.values == nil || (len(sess.changes) == 0 && p.idleTimeout <= 0) {
+		// destroyed or not changed.
+		sess.mu.Unlock()
+		return
+	}
+
+	tx := Transaction{
+		Set:    make(map[string]interface{}),
+		Values: make(map[string]interface{}, len(sess.values)),
+		TTL:    p.lifetime(sess),
+	}
+	for key, removed := range sess.changes {
+		if removed {
+			tx.Delete = append(tx.Delete, key)
+		} else {
+			tx.Set[key] = sess.values[key]
+		}
+		delete(sess.changes, key)
+	}
+	for key, v := range sess.values {
+		tx.Values[key] = v
+	}
+	if len(tx.Values) > 0 {
+		created := sess.created.Format(time.RFC3339Nano)
+		tx.Values[createdKey] = created
+		// the databases which store the values by key may not have it yet.
+		tx.Set[createdKey] = created
+	}
+	sid := sess.sid
+	sess.mu.Unlock()
+
+	if err := p.tx.Commit(sid, tx); err != nil {
+		p.log("sessions: commit of the session '%s': %v", sid, err)
+	}
+}
+
 // Destroy destroys the session, removes all sessions and flash values,
 // the session itself and updates the registered session databases,
 // this called from sessionManager which removes the client's cookie also.
 func (p *provider) Destroy(sid string) {
 	p.mu.Lock()
-	if sess, found := p.sessions[sid]; found {
+	p.destroy(sid, true)
+	p.mu.Unlock()
+}
+
+// destroy is the Destroy without the lock, clear removes the session's values and flashes too.
+func (p *provider) destroy(sid string, clear bool) {
+	sess, found := p.sessions[sid]
+	if !found {
+		return
+	}
+	if clear {
+		sess.mu.Lock()
 		sess.values = nil
 		sess.flashes = nil
-		delete(p.sessions, sid)
-		p.updateDb(sid, nil)
+		sess.mu.Unlock()
 	}
-	p.mu.Unlock()
+	if sess.timeout != nil {
+		sess.timeout.Stop()
+	}
+	delete(p.sessions, sid)
+	p.destroyDb(sid)
 }
 
 // DestroyAll removes all sessions
@@ -116,9 +311,21 @@ func (p *provider) Destroy(sid string) {
 // Client's session cookie will still exist but it will be reseted on the next request.
 func (p *provider) DestroyAll() {
 	p.mu.Lock()
-	for _, sess := range p.sessions {
-		delete(p.sessions, sess.ID())
-		p.updateDb(sess.ID(), nil)
+	if p.tx != nil {
+		for sid, sess := range p.sessions {
+			if sess.timeout != nil {
+				sess.timeout.Stop()
+			}
+			delete(p.sessions, sid)
+		}
+		// removes the sessions which are not loaded too.
+		if err := p.tx.DestroyAll(); err != nil {
+			p.log("sessions: destroy all: %v", err)
+		}
+	} else {
+		for sid := range p.sessions {
+			p.destroy(sid, false)
+		}
 	}
 	p.mu.Unlock()
 }
@@ -128,3 +335,14 @@ func (p *provider) updateDb(sid string, values map[string]interface{}) {
 		p.database.Update(sid, values)
 	}
 }
+
+// destroyDb removes the session from the database.
+func (p *provider) destroyDb(sid string) {
+	if p.tx != nil {
+		if err := p.tx.Destroy(sid); err != nil {
+			p.log("sessions: destroy of the session '%s': %v", sid, err)
+		}
+		return
+	}
+	p.updateDb(sid, nil)
+}
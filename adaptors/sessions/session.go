@@ -27,6 +27,14 @@ type (
 		mu       sync.RWMutex
 		timeout  *time.Timer
 		provider *provider
+		// created is the time of the session's creation, for the absolute expiration.
+		created time.Time
+		// changes are the changed keys, true if removed, since the last commit
+		// to the provider's TransactionalDatabase, nil without it.
+		changes map[string]bool
+		// onSave, if not nil, is called after each change of the values or the flashes
+		// instead of the provider's database update, it's used by the cookie sessions.
+		onSave func(*session)
 	}
 
 	flashMessage struct {
@@ -40,7 +48,10 @@ var _ iris2.Session = &session{}
 
 // ID returns the session's id
 func (s *session) ID() string {
-	return s.sid
+	s.mu.RLock()
+	sid := s.sid
+	s.mu.RUnlock()
+	return sid
 }
 
 // Get returns the value of an entry by its key
@@ -212,6 +223,35 @@ func (s *session) GetAll() map[string]interface{} {
 	return items
 }
 
+// save stores the session's changes, flashes is true when only the flash messages are changed.
+func (s *session) save(flashes bool) {
+	if s.onSave != nil {
+		s.onSave(s)
+		return
+	}
+	// the changes of a TransactionalDatabase are committed at the end of the request.
+	if !flashes && s.changes == nil {
+		s.provider.updateDb(s.ID(), s.storedValues())
+	}
+}
+
+// storedValues returns a copy of the values, as they're stored to the database,
+// with the session's creation time, if not empty.
+func (s *session) storedValues() map[string]interface{} {
+	values := s.GetAll()
+	if len(values) > 0 {
+		values[createdKey] = s.created.Format(time.RFC3339Nano)
+	}
+	return values
+}
+
+// track records a change for the TransactionalDatabase, it's called with the lock.
+func (s *session) track(key string, removed bool) {
+	if s.changes != nil {
+		s.changes[key] = removed
+	}
+}
+
 // when running on the session manager removes any 'old' flash messages
 func (s *session) runFlashGC() {
 	s.mu.Lock()
@@ -238,12 +278,16 @@ func (s *session) HasFlash() bool {
 // This means that a message is meant to be displayed only on the first page served to the user
 func (s *session) GetFlash(key string) (v interface{}) {
 	s.mu.Lock()
-	if valueStorage, found := s.flashes[key]; found {
+	valueStorage, found := s.flashes[key]
+	if found {
 		valueStorage.shouldRemove = true
 		v = valueStorage.value
 	}
 	s.mu.Unlock()
 
+	if found {
+		s.save(true)
+	}
 	return
 }
 
@@ -268,6 +312,8 @@ func (s *session) GetFlashes() map[string]interface{} {
 		v.shouldRemove = true
 	}
 	s.mu.Unlock()
+
+	s.save(true)
 	return flashes
 }
 
@@ -283,9 +329,10 @@ func (s *session) VisitAll(cb func(k string, v interface{})) {
 func (s *session) Set(key string, value interface{}) {
 	s.mu.Lock()
 	s.values[key] = value
+	s.track(key, false)
 	s.mu.Unlock()
 
-	s.provider.updateDb(s.sid, s.values)
+	s.save(false)
 }
 
 // SetFlash sets a flash message by its key.
@@ -312,15 +359,18 @@ func (s *session) SetFlash(key string, value interface{}) {
 	s.mu.Lock()
 	s.flashes[key] = &flashMessage{value: value}
 	s.mu.Unlock()
+
+	s.save(true)
 }
 
 // Delete removes an entry by its key
 func (s *session) Delete(key string) {
 	s.mu.Lock()
 	delete(s.values, key)
+	s.track(key, true)
 	s.mu.Unlock()
 
-	s.provider.updateDb(s.sid, s.values)
+	s.save(false)
 }
 
 // DeleteFlash removes a flash message by its key
@@ -328,6 +378,8 @@ func (s *session) DeleteFlash(key string) {
 	s.mu.Lock()
 	delete(s.flashes, key)
 	s.mu.Unlock()
+
+	s.save(true)
 }
 
 // Clear removes all entries
@@ -335,10 +387,11 @@ func (s *session) Clear() {
 	s.mu.Lock()
 	for key := range s.values {
 		delete(s.values, key)
+		s.track(key, true)
 	}
 	s.mu.Unlock()
 
-	s.provider.updateDb(s.sid, s.values)
+	s.save(false)
 }
 
 // Clear removes all flash messages
@@ -348,4 +401,6 @@ func (s *session) ClearFlashes() {
 		delete(s.flashes, key)
 	}
 	s.mu.Unlock()
+
+	s.save(true)
 }
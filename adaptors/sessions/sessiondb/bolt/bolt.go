@@ -0,0 +1,289 @@
+// Package bolt provides an embedded sessions database on top of bbolt,
+// for the single-binary deployments, i.e
+//
+//	storage, err := bolt.New(bolt.Config{Path: "./sessions.db", Bucket: "myapp"})
+//	app.Adapt(sessions.New(sessions.Config{Cookie: "mysessionid", SessionStorage: storage}))
+//
+// The expiration timestamp is stored next to the values and the expired sessions are removed by the Cleaner.
+package bolt
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/go-iris2/iris2/adaptors/sessions"
+	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/leveldb"
+	"github.com/go-iris2/iris2/errors"
+)
+
+const (
+	// DefaultBucket is the default bucket of the sessions, "sessions"
+	DefaultBucket = "sessions"
+	// DefaultCleanTimeout is the default duration between the Cleaner's runs, one hour
+	DefaultCleanTimeout = time.Hour
+	// DefaultMaxAge is the default life of the sessions without a TTL, one year
+	DefaultMaxAge = time.Second * 31556926
+	// DefaultOpenTimeout is the default duration to wait for the file's lock, one second
+	DefaultOpenTimeout = time.Second
+)
+
+var (
+	errPathMissing = errors.New("sessiondb/bolt: the Config.Path is required")
+	errExpired     = errors.New("sessiondb/bolt: session '%s' is expired")
+)
+
+// Config the configuration of the bolt sessions database
+type Config struct {
+	// Path is the path of the database's file, it's created if it doesn't exist. Required
+	Path string
+	// Mode is the mode of a new database's file. Default is 0600
+	Mode os.FileMode
+	// Bucket is the bucket of the sessions, use one per app if the file is shared. Default is "sessions"
+	Bucket string
+	// OpenTimeout is the duration to wait for the file's lock,
+	// a bolt database can be opened by one process only. Default is one second
+	OpenTimeout time.Duration
+	// CleanTimeout is the duration between the Cleaner's runs,
+	// which remove the expired sessions. Default is one hour
+	CleanTimeout time.Duration
+	// MaxAge is the life of the sessions without a TTL,
+	// which are stored by the Update or the Commit without the sessions' Expires and IdleTimeout.
+	// Default is one year
+	MaxAge time.Duration
+}
+
+// Storage is the bolt sessions database,
+// it's a sessions.TransactionalDatabase and a sessions.ExpireNotifier.
+type Storage struct {
+	db     *bolt.DB
+	config Config
+	bucket []byte
+
+	mu       sync.RWMutex
+	onExpire func(sid string)
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+var (
+	_ sessions.TransactionalDatabase = &Storage{}
+	_ sessions.ExpireNotifier        = &Storage{}
+)
+
+// New opens, or creates, the database's file and its bucket and starts the Cleaner,
+// stop them by the Close.
+func New(cfg Config) (*Storage, error) {
+	if cfg.Path == "" {
+		return nil, errPathMissing
+	}
+	if cfg.Mode == 0 {
+		cfg.Mode = 0600
+	}
+	if cfg.Bucket == "" {
+		cfg.Bucket = DefaultBucket
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = DefaultOpenTimeout
+	}
+	if cfg.CleanTimeout <= 0 {
+		cfg.CleanTimeout = DefaultCleanTimeout
+	}
+	if cfg.MaxAge <= 0 {
+		cfg.MaxAge = DefaultMaxAge
+	}
+
+	db, err := bolt.Open(cfg.Path, cfg.Mode, &bolt.Options{Timeout: cfg.OpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Storage{
+		db:     db,
+		config: cfg,
+		bucket: []byte(cfg.Bucket),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(s.bucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	go s.Cleaner()
+	return s, nil
+}
+
+// a record is the 8 bytes (big endian) unix time, in nanoseconds, of the expiration
+// and the values, serialized as the leveldb's.
+
+func encodeRecord(values map[string]interface{}, deathTime time.Time) ([]byte, error) {
+	data, err := leveldb.SerializeBytes(values)
+	if err != nil {
+		return nil, err
+	}
+	rec := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(rec, uint64(deathTime.UnixNano()))
+	copy(rec[8:], data)
+	return rec, nil
+}
+
+func isExpired(rec []byte, now time.Time) bool {
+	return len(rec) < 8 || int64(binary.BigEndian.Uint64(rec)) < now.UnixNano()
+}
+
+// Load loads the values of the session, an error is returned if it's not found or it's expired.
+func (s *Storage) Load(sid string) (map[string]interface{}, error) {
+	var data []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		rec := tx.Bucket(s.bucket).Get([]byte(sid))
+		if rec == nil {
+			return fmt.Errorf("could not read session: not found")
+		}
+		if isExpired(rec, time.Now()) {
+			// the Cleaner will remove it.
+			return errExpired.Format(sid)
+		}
+		// the rec is valid only inside the transaction.
+		data = append(data, rec[8:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{})
+	if err = leveldb.DeserializeBytes(data, &values); err != nil {
+		return nil, fmt.Errorf("could not deserialize session: %v", err)
+	}
+	return values, nil
+}
+
+// Update stores all the values of the session, it removes the session if they are empty.
+func (s *Storage) Update(sid string, values map[string]interface{}) {
+	if len(values) == 0 {
+		s.Destroy(sid)
+		return
+	}
+	s.save(sid, values, s.config.MaxAge)
+}
+
+// Commit stores the session's values, the Cleaner removes them after the TTL, if any,
+// otherwise after the MaxAge.
+func (s *Storage) Commit(sid string, tx sessions.Transaction) error {
+	if len(tx.Values) == 0 {
+		return s.Destroy(sid)
+	}
+	ttl := tx.TTL
+	if ttl <= 0 {
+		ttl = s.config.MaxAge
+	}
+	return s.save(sid, tx.Values, ttl)
+}
+
+func (s *Storage) save(sid string, values map[string]interface{}, ttl time.Duration) error {
+	rec, err := encodeRecord(values, time.Now().Add(ttl))
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Put([]byte(sid), rec)
+	})
+}
+
+// Destroy removes the session
+func (s *Storage) Destroy(sid string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(s.bucket).Delete([]byte(sid))
+	})
+}
+
+// DestroyAll removes all the sessions of the bucket
+func (s *Storage) DestroyAll() error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(s.bucket); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+		_, err := tx.CreateBucket(s.bucket)
+		return err
+	})
+}
+
+// OnExpire registers the callback which is called with the id of the sessions
+// which are removed by the Cleaner because they are expired.
+func (s *Storage) OnExpire(cb func(sid string)) {
+	s.mu.Lock()
+	s.onExpire = cb
+	s.mu.Unlock()
+}
+
+// Cleaner removes the expired sessions every CleanTimeout, until the Close.
+// It's started by the New.
+func (s *Storage) Cleaner() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.CleanTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			if err := s.Clean(); err != nil {
+				println("Error clean sessions of the bucket '" + s.config.Bucket + "' of the database Bolt(" + s.config.Path + "): " + err.Error())
+			}
+		}
+	}
+}
+
+// Clean removes the expired sessions and calls the OnExpire callback for each one of them.
+func (s *Storage) Clean() error {
+	var expired []string
+	now := time.Now()
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(s.bucket)
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if isExpired(v, now) {
+				expired = append(expired, string(k))
+			}
+		}
+		// the keys are removed after the scan, a Delete while iterating skips the next key.
+		for _, sid := range expired {
+			if err := b.Delete([]byte(sid)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	cb := s.onExpire
+	s.mu.RUnlock()
+	if cb != nil {
+		for _, sid := range expired {
+			cb(sid)
+		}
+	}
+	return nil
+}
+
+// Close stops the Cleaner and closes the database.
+func (s *Storage) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		<-s.done
+	})
+	return s.db.Close()
+}
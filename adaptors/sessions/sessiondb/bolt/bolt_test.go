@@ -0,0 +1,165 @@
+package bolt
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/go-iris2/iris2/adaptors/sessions"
+	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/leveldb"
+)
+
+func newTestStorage(t *testing.T, cfg Config) *Storage {
+	cfg.Path = filepath.Join(t.TempDir(), "sessions.db")
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+	})
+	return s
+}
+
+func TestStorage(t *testing.T) {
+	s := newTestStorage(t, Config{Bucket: "myapp"})
+
+	if _, err := New(Config{}); err != errPathMissing {
+		t.Fatalf("expected the missing path error but got %v", err)
+	}
+	if _, err := s.Load("none"); err == nil {
+		t.Fatalf("expected an error for a session which doesn't exist")
+	}
+
+	err := s.Commit("sid", sessions.Transaction{
+		Values: map[string]interface{}{"name": "iris", "visits": 1},
+		TTL:    time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := s.Load("sid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["name"] != "iris" || len(values) != 2 {
+		t.Fatalf("expected the committed values but got %v", values)
+	}
+
+	// the values are serialized as the leveldb's, after the expiration.
+	var data []byte
+	s.db.View(func(tx *bolt.Tx) error {
+		data = append(data, tx.Bucket([]byte("myapp")).Get([]byte("sid"))[8:]...)
+		return nil
+	})
+	stored := make(map[string]interface{})
+	if err = leveldb.DeserializeBytes(data, &stored); err != nil || stored["name"] != "iris" {
+		t.Fatalf("expected the leveldb's serialization but got %v, %v", stored, err)
+	}
+
+	s.Update("sid", map[string]interface{}{"name": "iris2"})
+	if values, _ = s.Load("sid"); values["name"] != "iris2" || len(values) != 1 {
+		t.Fatalf("expected the updated values but got %v", values)
+	}
+
+	// empty values remove the session.
+	if err = s.Commit("sid", sessions.Transaction{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Load("sid"); err == nil {
+		t.Fatalf("expected the session to be removed")
+	}
+}
+
+func TestStorageExpired(t *testing.T) {
+	s := newTestStorage(t, Config{})
+
+	if err := s.save("expired", map[string]interface{}{"k": "v"}, -time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// the expired session is not loaded, even before the Clean.
+	if _, err := s.Load("expired"); err == nil {
+		t.Fatalf("expected an error for an expired session")
+	}
+
+	// a Commit without a TTL lives for the MaxAge.
+	if err := s.Commit("maxage", sessions.Transaction{Values: map[string]interface{}{"k": "v"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("maxage"); err != nil {
+		t.Fatalf("expected the session to live for the MaxAge but got %v", err)
+	}
+}
+
+func TestStorageClean(t *testing.T) {
+	s := newTestStorage(t, Config{})
+
+	var (
+		mu      sync.Mutex
+		expired []string
+	)
+	s.OnExpire(func(sid string) {
+		mu.Lock()
+		expired = append(expired, sid)
+		mu.Unlock()
+	})
+
+	for _, sid := range []string{"expired1", "expired2"} {
+		if err := s.save(sid, map[string]interface{}{"k": sid}, -time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Update("alive", map[string]interface{}{"k": "alive"})
+
+	if err := s.Clean(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	sort.Strings(expired)
+	got := expired
+	mu.Unlock()
+	if len(got) != 2 || got[0] != "expired1" || got[1] != "expired2" {
+		t.Fatalf("expected the expired sessions to be notified but got %v", got)
+	}
+	var count int
+	s.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(s.bucket).Stats().KeyN
+		return nil
+	})
+	if count != 1 {
+		t.Fatalf("expected only the alive session to be kept but %d are stored", count)
+	}
+	if _, err := s.Load("alive"); err != nil {
+		t.Fatalf("expected the alive session to be kept but got %v", err)
+	}
+}
+
+func TestStorageDestroyAll(t *testing.T) {
+	s := newTestStorage(t, Config{})
+
+	for _, sid := range []string{"a", "b"} {
+		s.Update(sid, map[string]interface{}{"k": sid})
+	}
+	if err := s.Destroy("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("a"); err == nil {
+		t.Fatalf("expected the destroyed session to be removed")
+	}
+	if err := s.DestroyAll(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Load("b"); err == nil {
+		t.Fatalf("expected all the sessions to be removed")
+	}
+
+	// the bucket is created again.
+	s.Update("c", map[string]interface{}{"k": "c"})
+	if _, err := s.Load("c"); err != nil {
+		t.Fatalf("expected a new session after the DestroyAll but got %v", err)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Sirupsen/logrus"
 	"github.com/go-iris2/iris2/adaptors/sessions"
@@ -13,9 +17,26 @@ import (
 // fileStorage structure for the file-storage
 type fileStorage struct {
 	path string
+
+	mu       sync.RWMutex
+	onExpire func(sid string)
+}
+
+// record is the content of a session's file which is written by the Commit,
+// the Update writes the values only.
+type record struct {
+	Data      []byte
+	DeathTime time.Time
 }
 
-// New returns a new session storage instance
+var (
+	_ sessions.TransactionalDatabase = &fileStorage{}
+	_ sessions.ExpireNotifier        = &fileStorage{}
+)
+
+// New returns a new session storage instance,
+// it's a sessions.TransactionalDatabase and a sessions.ExpireNotifier,
+// the expired sessions are removed when they are loaded.
 func New(p string) sessions.Database {
 	_, err := os.Stat(p)
 	if os.IsNotExist(err) {
@@ -27,15 +48,39 @@ func New(p string) sessions.Database {
 	return &fileStorage{path: p}
 }
 
+var errInvalidSessionID = fmt.Errorf("invalid session id")
+
+// filename returns the session's file inside the session-dir,
+// false if the sid is not a plain file name, i.e it contains a path separator or it's "..".
+func (d *fileStorage) filename(sid string) (string, bool) {
+	if sid == "" || sid == "." || sid == ".." || strings.ContainsAny(sid, `/\`) || filepath.Base(sid) != sid {
+		return "", false
+	}
+	return filepath.Join(d.path, filepath.Base(sid)), true
+}
+
 // Load loads the values to the underline
 func (d *fileStorage) Load(sid string) (map[string]interface{}, error) {
 	values := make(map[string]interface{})
 
-	val, err := ioutil.ReadFile(d.path + "/" + sid)
+	filename, ok := d.filename(sid)
+	if !ok {
+		return nil, errInvalidSessionID
+	}
+	val, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("could not read session: %v", err)
 	}
 
+	var rec record
+	if err = msgpack.Unmarshal(val, &rec); err == nil && rec.Data != nil {
+		if !rec.DeathTime.IsZero() && time.Since(rec.DeathTime) > 0 {
+			d.expire(sid)
+			return nil, fmt.Errorf("session expired")
+		}
+		val = rec.Data
+	}
+
 	err = msgpack.Unmarshal(val, &values)
 	if err != nil {
 		return nil, fmt.Errorf("could not deserialize session: %v", err)
@@ -47,12 +92,89 @@ func (d *fileStorage) Load(sid string) (map[string]interface{}, error) {
 
 // Update updates the session storage
 func (d *fileStorage) Update(sid string, newValues map[string]interface{}) {
+	filename, ok := d.filename(sid)
+	if !ok {
+		return
+	}
 	if newValues == nil || len(newValues) == 0 {
-		go os.Remove(d.path + "/" + sid)
+		go os.Remove(filename)
 	} else {
 		val, err := msgpack.Marshal(newValues)
 		if err == nil {
-			ioutil.WriteFile(d.path+"/"+sid, val, 0600)
+			ioutil.WriteFile(filename, val, 0600)
 		}
 	}
 }
+
+// Commit writes the session's values, they expire after the TTL, if any
+func (d *fileStorage) Commit(sid string, tx sessions.Transaction) error {
+	filename, ok := d.filename(sid)
+	if !ok {
+		return errInvalidSessionID
+	}
+	if len(tx.Values) == 0 {
+		return d.Destroy(sid)
+	}
+
+	var err error
+	var rec record
+	if rec.Data, err = msgpack.Marshal(tx.Values); err != nil {
+		return err
+	}
+	if tx.TTL > 0 {
+		rec.DeathTime = time.Now().Add(tx.TTL)
+	}
+
+	val, err := msgpack.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, val, 0600)
+}
+
+// Destroy removes the session's file
+func (d *fileStorage) Destroy(sid string) error {
+	filename, ok := d.filename(sid)
+	if !ok {
+		return errInvalidSessionID
+	}
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// DestroyAll removes all the files of the session-dir
+func (d *fileStorage) DestroyAll() error {
+	files, err := filepath.Glob(d.path + "/*")
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err = os.Remove(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnExpire registers the callback which is called with the id of the expired sessions,
+// when they are loaded.
+func (d *fileStorage) OnExpire(cb func(sid string)) {
+	d.mu.Lock()
+	d.onExpire = cb
+	d.mu.Unlock()
+}
+
+func (d *fileStorage) expire(sid string) {
+	if filename, ok := d.filename(sid); ok {
+		os.Remove(filename)
+	}
+
+	d.mu.RLock()
+	cb := d.onExpire
+	d.mu.RUnlock()
+	if cb != nil {
+		cb(sid)
+	}
+}
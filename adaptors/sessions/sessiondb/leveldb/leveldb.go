@@ -15,7 +15,14 @@ import (
 	"gopkg.in/vmihailenco/msgpack.v2"
 )
 
-// New returns a database interface
+var (
+	_ sessions.TransactionalDatabase = &impl{}
+	_ sessions.ExpireNotifier        = &impl{}
+)
+
+// New returns a database interface,
+// it's a sessions.TransactionalDatabase and a sessions.ExpireNotifier,
+// the expired sessions are removed by the Cleaner.
 func New(cfg ...Config) sessions.Database {
 	var ldb = new(impl)
 	ldb.doCloseUp = make(chan bool)
@@ -100,13 +107,22 @@ func (ldb *impl) clean(key, value []byte) {
 	if err = msgpack.Unmarshal(value, &rec); err != nil {
 		kill = true // Cleaning erroneous entries
 	}
+	var expired bool
 	if time.Since(rec.DeathTime) > 0 {
 		kill = true // Cleaning deceased entries
+		expired = err == nil
 	}
 	if kill {
 		err = ldb.DB.Delete(key, (*opt.WriteOptions)(ldb.Cfg.WriteOptions))
 		if err != nil {
 			println("Error delete key='" + string(key) + "' from database LevelDB(" + ldb.Cfg.Path + "): " + err.Error())
+		} else if expired {
+			ldb.onExpireMu.RLock()
+			cb := ldb.onExpire
+			ldb.onExpireMu.RUnlock()
+			if cb != nil {
+				cb(string(key))
+			}
 		}
 	}
 }
@@ -146,8 +162,12 @@ func (ldb *impl) Load(id string) (map[string]interface{}, error) {
 	if err = msgpack.Unmarshal(val, &rec); err != nil {
 		return nil, fmt.Errorf("could not read session: %v", err)
 	}
+	if time.Since(rec.DeathTime) > 0 {
+		// the Cleaner will remove it.
+		return nil, fmt.Errorf("session expired")
+	}
 
-	err = msgpack.Unmarshal(rec.Data, &ret)
+	err = DeserializeBytes(rec.Data, &ret)
 	if err != nil {
 		return nil, fmt.Errorf("could not deserialize session: %v", err)
 	}
@@ -181,7 +201,64 @@ func (ldb *impl) Update(id string, values map[string]interface{}) {
 	}
 }
 
+// Commit stores the session's values, the Cleaner removes them after the TTL, if any,
+// otherwise after the MaxAge.
+func (ldb *impl) Commit(id string, tx sessions.Transaction) error {
+	if len(tx.Values) == 0 {
+		return ldb.Destroy(id)
+	}
+
+	var err error
+	var rec record.Record
+	if rec.Data, err = SerializeBytes(tx.Values); err != nil {
+		return err
+	}
+	maxAge := ldb.Cfg.MaxAge
+	if tx.TTL > 0 {
+		maxAge = tx.TTL
+	}
+	rec.DeathTime = time.Now().In(time.Local).Add(maxAge)
+
+	val, err := SerializeBytes(rec)
+	if err != nil {
+		return err
+	}
+	return ldb.DB.Put([]byte(id), val, (*opt.WriteOptions)(ldb.Cfg.WriteOptions))
+}
+
+// Destroy removes the session
+func (ldb *impl) Destroy(id string) error {
+	return ldb.DB.Delete([]byte(id), (*opt.WriteOptions)(ldb.Cfg.WriteOptions))
+}
+
+// DestroyAll removes all the sessions
+func (ldb *impl) DestroyAll() error {
+	batch := new(leveldb.Batch)
+	iter := ldb.DB.NewIterator(nil, (*opt.ReadOptions)(ldb.Cfg.ReadOptions))
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return ldb.DB.Write(batch, (*opt.WriteOptions)(ldb.Cfg.WriteOptions))
+}
+
+// OnExpire registers the callback which is called with the id of the sessions
+// which are removed by the Cleaner because they are expired.
+func (ldb *impl) OnExpire(cb func(sid string)) {
+	ldb.onExpireMu.Lock()
+	ldb.onExpire = cb
+	ldb.onExpireMu.Unlock()
+}
+
 // SerializeBytes serializa bytes using gob encoder and returns them
 func SerializeBytes(m interface{}) ([]byte, error) {
 	return msgpack.Marshal(m)
 }
+
+// DeserializeBytes converts the bytes of the SerializeBytes to the m
+func DeserializeBytes(b []byte, m interface{}) error {
+	return msgpack.Unmarshal(b, m)
+}
@@ -20,6 +20,8 @@ type impl struct {
 	Err         error
 	doCloseUp   chan bool
 	doCloseDone sync.WaitGroup
+	onExpireMu  sync.RWMutex
+	onExpire    func(sid string)
 }
 
 // Config the leveldb configuration used inside sessions
@@ -2,6 +2,8 @@ package redis
 
 import (
 	"fmt"
+	"strings"
+
 	"github.com/go-iris2/iris2/adaptors/sessions"
 	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/redis/service"
 	"gopkg.in/vmihailenco/msgpack.v2"
@@ -12,7 +14,14 @@ type redisStorage struct {
 	redis *service.Service
 }
 
-// New returns a new redis redisStorage
+var _ sessions.TransactionalDatabase = &redisStorage{}
+
+// New returns a new redis redisStorage,
+// it's a sessions.TransactionalDatabase, the sessions expire by their TTL.
+//
+// Each session is a redis hash, one field per value, a Commit sets and removes only the changed values.
+//
+// Set a Prefix if the redis database is shared, the DestroyAll removes all the entries of the Prefix.
 func New(cfg ...service.Config) sessions.Database {
 	return &redisStorage{redis: service.New(cfg...)}
 }
@@ -34,26 +43,89 @@ func (d *redisStorage) Load(sid string) (map[string]interface{}, error) {
 		}
 	}
 
-	val, err := d.redis.GetBytes(sid)
+	fields, err := d.redis.GetHash(sid)
+	if err != nil && strings.HasPrefix(err.Error(), "WRONGTYPE") {
+		// stored by a previous version, all the values together.
+		val, err := d.redis.GetBytes(sid)
+		if err != nil {
+			return nil, fmt.Errorf("fetching session from redis failed: %v", err)
+		}
+		if err := msgpack.Unmarshal(val, &values); err != nil {
+			return nil, fmt.Errorf("error decoding session: %v", err)
+		}
+		return values, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("fetching session from redis failed: %v", err)
 	}
 
-	if err := msgpack.Unmarshal(val, &values); err != nil {
-		return nil, fmt.Errorf("error decoding session: %v", err)
+	for key, data := range fields {
+		var value interface{}
+		if err := msgpack.Unmarshal(data, &value); err != nil {
+			return nil, fmt.Errorf("error decoding session's value '%s': %v", key, err)
+		}
+		values[key] = value
 	}
-
 	return values, nil
 }
 
+// encode returns the values encoded, one per field.
+func encode(values map[string]interface{}) (map[string][]byte, error) {
+	fields := make(map[string][]byte, len(values))
+	for key, value := range values {
+		data, err := msgpack.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = data
+	}
+	return fields, nil
+}
+
 // Update updates the real redis store
 func (d *redisStorage) Update(sid string, newValues map[string]interface{}) {
 	if len(newValues) == 0 {
 		d.redis.Delete(sid)
 	} else {
-		data, err := msgpack.Marshal(newValues)
+		fields, err := encode(newValues)
 		if err == nil {
-			d.redis.Set(sid, data) //set/update all the values
+			d.redis.ReplaceHash(sid, fields, 0) //set/update all the values
+		}
+	}
+}
+
+// Commit sets the changed values and removes the deleted ones, they expire after the TTL, if any,
+// otherwise after the MaxAgeSeconds.
+// All the values are stored if the session is not stored yet, i.e it's expired or it's stored by a previous version.
+func (d *redisStorage) Commit(sid string, tx sessions.Transaction) error {
+	if len(tx.Values) == 0 {
+		return d.redis.Delete(sid)
+	}
+	stored, err := d.redis.IsHash(sid)
+	if err != nil {
+		return err
+	}
+	if !stored {
+		fields, err := encode(tx.Values)
+		if err != nil {
+			return err
 		}
+		return d.redis.ReplaceHash(sid, fields, tx.TTL)
 	}
+
+	fields, err := encode(tx.Set)
+	if err != nil {
+		return err
+	}
+	return d.redis.UpdateHash(sid, fields, tx.Delete, tx.TTL)
+}
+
+// Destroy removes the session
+func (d *redisStorage) Destroy(sid string) error {
+	return d.redis.Delete(sid)
+}
+
+// DestroyAll removes all the sessions of the Prefix
+func (d *redisStorage) DestroyAll() error {
+	return d.redis.DeleteAll()
 }
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"io"
+	"sync"
 	"time"
 
 	"github.com/garyburd/redigo/redis"
@@ -12,6 +14,8 @@ var (
 	ErrRedisClosed = errors.New("Redis is already closed")
 	// ErrKeyNotFound an error with message 'Key $thekey doesn't found'
 	ErrKeyNotFound = errors.New("Key '%s' doesn't found")
+	// ErrPrefixRequired an error with message 'A Prefix is required to delete all the entries'
+	ErrPrefixRequired = errors.New("A Prefix is required to delete all the entries")
 )
 
 // Service the Redis service, contains the config and the redis pool
@@ -54,6 +58,21 @@ func (r *Service) Set(key string, value []byte) (err error) { // map[interface{}
 	return
 }
 
+// SetTTL same as Set but the entry expires after the ttl instead of the MaxAgeSeconds,
+// if the ttl is not positive then it's the same as Set.
+func (r *Service) SetTTL(key string, value []byte, ttl time.Duration) (err error) {
+	if ttl <= 0 {
+		return r.Set(key, value)
+	}
+	c := r.pool.Get()
+	defer c.Close()
+	if err = c.Err(); err != nil {
+		return
+	}
+	_, err = c.Do("PSETEX", r.Config.Prefix+key, int64(ttl/time.Millisecond), value)
+	return
+}
+
 // Get returns value, err by its key
 // you can use utils.Deserialize((.Get("yourkey"),&theobject{})
 //returns nil and a filled error if something wrong happens
@@ -196,6 +215,259 @@ func (r *Service) Delete(key string) error {
 	return nil
 }
 
+// DeleteAll removes all the entries of the Config's Prefix,
+// it returns an error if the Prefix is empty in order to not remove the whole database.
+func (r *Service) DeleteAll() error {
+	if r.Config.Prefix == "" {
+		return ErrPrefixRequired
+	}
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return err
+	}
+
+	// SCAN doesn't block the server as the KEYS does.
+	cursor := 0
+	for {
+		reply, err := redis.Values(c.Do("SCAN", cursor, "MATCH", r.Config.Prefix+"*", "COUNT", 100))
+		if err != nil {
+			return err
+		}
+		if cursor, err = redis.Int(reply[0], nil); err != nil {
+			return err
+		}
+		keys, err := redis.Values(reply[1], nil)
+		if err != nil {
+			return err
+		}
+		if len(keys) > 0 {
+			if _, err = c.Do("DEL", keys...); err != nil {
+				return err
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+// GetHash returns the fields of the hash of the key and their values,
+// it returns ErrKeyNotFound if the hash doesn't exist.
+func (r *Service) GetHash(key string) (map[string][]byte, error) {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return nil, err
+	}
+
+	reply, err := redis.ByteSlices(c.Do("HGETALL", r.Config.Prefix+key))
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) == 0 {
+		return nil, ErrKeyNotFound.Format(key)
+	}
+	fields := make(map[string][]byte, len(reply)/2)
+	for i := 0; i+1 < len(reply); i += 2 {
+		fields[string(reply[i])] = reply[i+1]
+	}
+	return fields, nil
+}
+
+// Exists reports whether the key exists, it's not expired.
+func (r *Service) Exists(key string) (bool, error) {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return false, err
+	}
+
+	return redis.Bool(c.Do("EXISTS", r.Config.Prefix+key))
+}
+
+// IsHash reports whether the key exists and it's a hash.
+func (r *Service) IsHash(key string) (bool, error) {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return false, err
+	}
+
+	typ, err := redis.String(c.Do("TYPE", r.Config.Prefix+key))
+	return typ == "hash", err
+}
+
+// UpdateHash sets and removes the fields of the hash of the key, atomically,
+// the hash expires after the ttl, if the ttl is not positive then after the MaxAgeSeconds.
+func (r *Service) UpdateHash(key string, set map[string][]byte, remove []string, ttl time.Duration) error {
+	return r.hashTx(key, false, set, remove, ttl)
+}
+
+// ReplaceHash replaces the hash of the key, or any other value of the key, with the fields, atomically,
+// the hash expires after the ttl, if the ttl is not positive then after the MaxAgeSeconds.
+func (r *Service) ReplaceHash(key string, fields map[string][]byte, ttl time.Duration) error {
+	return r.hashTx(key, true, fields, nil, ttl)
+}
+
+func (r *Service) hashTx(key string, replace bool, set map[string][]byte, remove []string, ttl time.Duration) error {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return err
+	}
+
+	key = r.Config.Prefix + key
+	c.Send("MULTI")
+	if replace {
+		c.Send("DEL", key)
+	}
+	if len(set) > 0 {
+		args := redis.Args{}.Add(key)
+		for field, value := range set {
+			args = args.Add(field, value)
+		}
+		c.Send("HMSET", args...)
+	}
+	if len(remove) > 0 {
+		c.Send("HDEL", redis.Args{}.Add(key).AddFlat(remove)...)
+	}
+	if ttl > 0 {
+		c.Send("PEXPIRE", key, int64(ttl/time.Millisecond))
+	} else {
+		c.Send("EXPIRE", key, r.Config.MaxAgeSeconds)
+	}
+	_, err := c.Do("EXEC")
+	return err
+}
+
+// Incr increments the counter of the key by one and returns its new value,
+// a new counter expires after the ttl.
+// It implements the middleware/ratelimit's Store.
+func (r *Service) Incr(key string, ttl time.Duration) (int64, error) {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := redis.Int64(c.Do("INCR", r.Config.Prefix+key))
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		if _, err = c.Do("PEXPIRE", r.Config.Prefix+key, int64(ttl/time.Millisecond)); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Count returns the value of the counter of the key,
+// zero if it doesn't exist or it's expired.
+// It implements the middleware/ratelimit's Store.
+func (r *Service) Count(key string) (int64, error) {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := redis.Int64(c.Do("GET", r.Config.Prefix+key))
+	if err == redis.ErrNil {
+		return 0, nil
+	}
+	return n, err
+}
+
+// Publish posts the message to the channel of the Config's Prefix.
+func (r *Service) Publish(channel string, message []byte) error {
+	c := r.pool.Get()
+	defer c.Close()
+	if err := c.Err(); err != nil {
+		return err
+	}
+	_, err := c.Do("PUBLISH", r.Config.Prefix+channel, message)
+	return err
+}
+
+// SubscribeRetryDelay is the delay before the Subscribe's reconnection, 1 second.
+const SubscribeRetryDelay = 1 * time.Second
+
+// subscription is the Subscribe's connection, it's re-subscribed when it's lost.
+type subscription struct {
+	mu     sync.Mutex
+	conn   redis.PubSubConn
+	closed bool
+}
+
+func (s *subscription) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrRedisClosed
+	}
+	s.closed = true
+	return s.conn.Close()
+}
+
+// Subscribe calls the cb on each message of the channel of the Config's Prefix,
+// on its own connection which is re-subscribed when it's lost, until the returned Closer is closed.
+func (r *Service) Subscribe(channel string, cb func(message []byte)) (io.Closer, error) {
+	channel = r.Config.Prefix + channel
+	subscribe := func() (redis.PubSubConn, error) {
+		conn := redis.PubSubConn{Conn: r.pool.Get()}
+		if err := conn.Subscribe(channel); err != nil {
+			conn.Close()
+			return conn, err
+		}
+		return conn, nil
+	}
+
+	conn, err := subscribe()
+	if err != nil {
+		return nil, err
+	}
+	s := &subscription{conn: conn}
+
+	go func() {
+		for {
+			switch v := conn.Receive().(type) {
+			case redis.Message:
+				cb(v.Data)
+				continue
+			case error:
+			default:
+				continue
+			}
+
+			// the connection is lost, re-subscribe unless it's closed.
+			conn.Close()
+			for {
+				s.mu.Lock()
+				closed := s.closed
+				s.mu.Unlock()
+				if closed {
+					return
+				}
+				if conn, err = subscribe(); err == nil {
+					break
+				}
+				time.Sleep(SubscribeRetryDelay)
+			}
+			s.mu.Lock()
+			if s.closed {
+				s.mu.Unlock()
+				conn.Close()
+				return
+			}
+			s.conn = conn
+			s.mu.Unlock()
+		}
+	}()
+	return s, nil
+}
+
 func dial(network string, addr string, pass string) (redis.Conn, error) {
 	if network == "" {
 		network = DefaultRedisNetwork
@@ -0,0 +1,373 @@
+// Package sql provides a sessions database on top of the database/sql,
+// for PostgreSQL, MySQL and SQLite, i.e
+//
+//	db, err := sql.Open("postgres", "postgres://localhost/mydb")
+//	storage, err := sessionsql.New(db, sessionsql.Config{Dialect: sessionsql.Postgres})
+//	app.Adapt(sessions.New(sessions.Config{Cookie: "mysessionid", SessionStorage: storage}))
+//
+// The table is created if it doesn't exist and the expired sessions are removed by the Cleaner.
+package sql
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-iris2/iris2/adaptors/sessions"
+	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/leveldb"
+	"github.com/go-iris2/iris2/errors"
+)
+
+// Dialect is the SQL dialect of the database, it changes the placeholders,
+// the column types and the upsert statement.
+type Dialect uint8
+
+const (
+	// SQLite is the dialect of SQLite, the default one
+	SQLite Dialect = iota
+	// Postgres is the dialect of PostgreSQL
+	Postgres
+	// MySQL is the dialect of MySQL and MariaDB
+	MySQL
+)
+
+const (
+	// DefaultTable is the default table of the sessions, "sessions"
+	DefaultTable = "sessions"
+	// DefaultCleanTimeout is the default duration between the Cleaner's runs, one hour
+	DefaultCleanTimeout = time.Hour
+	// DefaultMaxAge is the default life of the sessions without a TTL, one year
+	DefaultMaxAge = time.Second * 31556926
+)
+
+var (
+	errInvalidTable = errors.New("sessiondb/sql: invalid table name '%s'")
+	errExpired      = errors.New("sessiondb/sql: session '%s' is expired")
+
+	tableExp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+)
+
+// Config the configuration of the sql sessions database
+type Config struct {
+	// Dialect is the dialect of the database, SQLite, Postgres or MySQL. Default is SQLite
+	Dialect Dialect
+	// Table is the table of the sessions, it's created if it doesn't exist,
+	// letters, digits and underscores, with an optional schema, i.e "myschema.sessions".
+	// Default is "sessions"
+	Table string
+	// CleanTimeout is the duration between the Cleaner's runs,
+	// which remove the expired sessions. Default is one hour
+	CleanTimeout time.Duration
+	// MaxAge is the life of the sessions without a TTL,
+	// which are stored by the Update or the Commit without the sessions' Expires and IdleTimeout.
+	// Default is one year
+	MaxAge time.Duration
+	// DisableMigration, if true, the table is not created by the New. Default is false
+	DisableMigration bool
+}
+
+// Storage is the sql sessions database,
+// it's a sessions.TransactionalDatabase and a sessions.ExpireNotifier.
+type Storage struct {
+	db     *sql.DB
+	config Config
+
+	// the dialect's statements.
+	loadQuery    string
+	upsertQuery  string
+	deleteQuery  string
+	expiredQuery string
+	expireQuery  string
+	cleanQuery   string
+
+	mu       sync.RWMutex
+	onExpire func(sid string)
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	done      chan struct{}
+}
+
+var (
+	_ sessions.TransactionalDatabase = &Storage{}
+	_ sessions.ExpireNotifier        = &Storage{}
+)
+
+// New returns a new sql sessions database, the db's driver should be registered by the caller.
+// It creates the table, unless DisableMigration, and starts the Cleaner, stop it by the Close.
+func New(db *sql.DB, cfg ...Config) (*Storage, error) {
+	c := Config{}
+	if len(cfg) > 0 {
+		c = cfg[0]
+	}
+	if c.Table == "" {
+		c.Table = DefaultTable
+	}
+	if c.CleanTimeout <= 0 {
+		c.CleanTimeout = DefaultCleanTimeout
+	}
+	if c.MaxAge <= 0 {
+		c.MaxAge = DefaultMaxAge
+	}
+	if !tableExp.MatchString(c.Table) {
+		return nil, errInvalidTable.Format(c.Table)
+	}
+
+	s := &Storage{
+		db:     db,
+		config: c,
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	s.prepareQueries()
+
+	if !c.DisableMigration {
+		if err := s.Migrate(); err != nil {
+			return nil, err
+		}
+	}
+
+	go s.Cleaner()
+	return s, nil
+}
+
+// placeholder returns the n (1-based) placeholder of the dialect.
+func (s *Storage) placeholder(n int) string {
+	if s.config.Dialect == Postgres {
+		return "$" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+func (s *Storage) prepareQueries() {
+	t := s.config.Table
+	p1, p2, p3 := s.placeholder(1), s.placeholder(2), s.placeholder(3)
+
+	s.loadQuery = "SELECT data, expires_at FROM " + t + " WHERE sid = " + p1
+	s.deleteQuery = "DELETE FROM " + t + " WHERE sid = " + p1
+	s.expiredQuery = "SELECT sid FROM " + t + " WHERE expires_at < " + p1
+	s.expireQuery = "DELETE FROM " + t + " WHERE sid = " + p1 + " AND expires_at < " + p2
+	s.cleanQuery = "DELETE FROM " + t + " WHERE expires_at < " + p1
+
+	insert := "INSERT INTO " + t + " (sid, data, expires_at) VALUES (" + p1 + ", " + p2 + ", " + p3 + ")"
+	switch s.config.Dialect {
+	case Postgres:
+		s.upsertQuery = insert + " ON CONFLICT (sid) DO UPDATE SET data = EXCLUDED.data, expires_at = EXCLUDED.expires_at"
+	case MySQL:
+		s.upsertQuery = insert + " ON DUPLICATE KEY UPDATE data = VALUES(data), expires_at = VALUES(expires_at)"
+	default:
+		s.upsertQuery = strings.Replace(insert, "INSERT INTO", "INSERT OR REPLACE INTO", 1)
+	}
+}
+
+// Migrate creates the table, and its index on the expiration, if they don't exist.
+func (s *Storage) Migrate() error {
+	blob := "BLOB"
+	switch s.config.Dialect {
+	case Postgres:
+		blob = "BYTEA"
+	case MySQL:
+		blob = "LONGBLOB"
+	}
+
+	t := s.config.Table
+	if _, err := s.db.Exec("CREATE TABLE IF NOT EXISTS " + t + " (" +
+		"sid VARCHAR(255) NOT NULL PRIMARY KEY, " +
+		"data " + blob + " NOT NULL, " +
+		"expires_at BIGINT NOT NULL)"); err != nil {
+		return err
+	}
+
+	if s.config.Dialect == MySQL {
+		// MySQL doesn't support the IF NOT EXISTS of the indexes,
+		// the Cleaner scans the table without it.
+		return nil
+	}
+	schema, table := "", t
+	if idx := strings.IndexByte(t, '.'); idx > 0 {
+		schema, table = t[:idx+1], t[idx+1:]
+	}
+	index, on := table+"_expires_at", t
+	if s.config.Dialect == SQLite {
+		// SQLite takes the schema on the index's name instead of the table's,
+		// PostgreSQL creates the index on the table's schema.
+		index, on = schema+index, table
+	}
+	_, err := s.db.Exec("CREATE INDEX IF NOT EXISTS " + index + " ON " + on + " (expires_at)")
+	return err
+}
+
+// Load loads the values of the session, an error is returned if it's not found or it's expired.
+func (s *Storage) Load(sid string) (map[string]interface{}, error) {
+	var data []byte
+	var expiresAt int64
+	if err := s.db.QueryRow(s.loadQuery, sid).Scan(&data, &expiresAt); err != nil {
+		return nil, fmt.Errorf("could not read session: %v", err)
+	}
+	if time.Now().Unix() > expiresAt {
+		// the Cleaner will remove it.
+		return nil, errExpired.Format(sid)
+	}
+
+	values := make(map[string]interface{})
+	if err := leveldb.DeserializeBytes(data, &values); err != nil {
+		return nil, fmt.Errorf("could not deserialize session: %v", err)
+	}
+	return values, nil
+}
+
+// Update stores all the values of the session, it removes the session if they are empty.
+func (s *Storage) Update(sid string, values map[string]interface{}) {
+	if len(values) == 0 {
+		s.Destroy(sid)
+		return
+	}
+	s.save(sid, values, s.config.MaxAge)
+}
+
+// Commit stores the session's values, the Cleaner removes them after the TTL, if any,
+// otherwise after the MaxAge.
+func (s *Storage) Commit(sid string, tx sessions.Transaction) error {
+	if len(tx.Values) == 0 {
+		return s.Destroy(sid)
+	}
+	ttl := tx.TTL
+	if ttl <= 0 {
+		ttl = s.config.MaxAge
+	}
+	return s.save(sid, tx.Values, ttl)
+}
+
+func (s *Storage) save(sid string, values map[string]interface{}, ttl time.Duration) error {
+	data, err := leveldb.SerializeBytes(values)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(s.upsertQuery, sid, data, time.Now().Add(ttl).Unix())
+	return err
+}
+
+// Destroy removes the session
+func (s *Storage) Destroy(sid string) error {
+	_, err := s.db.Exec(s.deleteQuery, sid)
+	return err
+}
+
+// DestroyAll removes all the sessions
+func (s *Storage) DestroyAll() error {
+	_, err := s.db.Exec("DELETE FROM " + s.config.Table)
+	return err
+}
+
+// OnExpire registers the callback which is called with the id of the sessions
+// which are removed by the Cleaner because they are expired.
+func (s *Storage) OnExpire(cb func(sid string)) {
+	s.mu.Lock()
+	s.onExpire = cb
+	s.mu.Unlock()
+}
+
+// Cleaner removes the expired sessions every CleanTimeout, until the Close.
+// It's started by the New.
+func (s *Storage) Cleaner() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.config.CleanTimeout)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			if err := s.Clean(); err != nil {
+				println("Error clean sessions of the table '" + s.config.Table + "': " + err.Error())
+			}
+		}
+	}
+}
+
+// Clean removes the expired sessions and calls the OnExpire callback for each one of them.
+//
+// The callback is called only for the sessions which are removed,
+// not for the ones which are refreshed by a Commit while the Clean runs.
+func (s *Storage) Clean() error {
+	now := time.Now().Unix()
+
+	s.mu.RLock()
+	cb := s.onExpire
+	s.mu.RUnlock()
+
+	if cb == nil {
+		_, err := s.db.Exec(s.cleanQuery, now)
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	expired, err := s.expire(tx, now)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, sid := range expired {
+		cb(sid)
+	}
+	return nil
+}
+
+// expire removes the expired sessions, one by one, and returns the ids of the removed ones,
+// the expiration is checked again by the delete.
+func (s *Storage) expire(tx *sql.Tx, now int64) ([]string, error) {
+	rows, err := tx.Query(s.expiredQuery, now)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	for rows.Next() {
+		var sid string
+		if err = rows.Scan(&sid); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, sid)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var expired []string
+	for _, sid := range candidates {
+		res, err := tx.Exec(s.expireQuery, sid, now)
+		if err != nil {
+			return nil, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			expired = append(expired, sid)
+		}
+	}
+	return expired, nil
+}
+
+// Close stops the Cleaner, the db is not closed.
+func (s *Storage) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+		<-s.done
+	})
+	return nil
+}
@@ -0,0 +1,177 @@
+package sql
+
+import (
+	"database/sql"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2/adaptors/sessions"
+	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/leveldb"
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+)
+
+func newTestStorage(t *testing.T, cfg Config) (*Storage, *sql.DB) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "sessions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := New(db, cfg)
+	if err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		s.Close()
+		db.Close()
+	})
+	return s, db
+}
+
+func TestStorage(t *testing.T) {
+	s, db := newTestStorage(t, Config{Table: "main.my_sessions"})
+	// the migration runs once per New, the second one should find the table and the index.
+	if err := s.Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Load("none"); err == nil {
+		t.Fatalf("expected an error for a session which doesn't exist")
+	}
+
+	err := s.Commit("sid", sessions.Transaction{
+		Values: map[string]interface{}{"name": "iris", "visits": 1},
+		TTL:    time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := s.Load("sid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["name"] != "iris" || len(values) != 2 {
+		t.Fatalf("expected the committed values but got %v", values)
+	}
+
+	// the data is serialized as the leveldb's.
+	var data []byte
+	if err = db.QueryRow("SELECT data FROM my_sessions WHERE sid = ?", "sid").Scan(&data); err != nil {
+		t.Fatal(err)
+	}
+	stored := make(map[string]interface{})
+	if err = leveldb.DeserializeBytes(data, &stored); err != nil || stored["name"] != "iris" {
+		t.Fatalf("expected the leveldb's serialization but got %v, %v", stored, err)
+	}
+
+	s.Update("sid", map[string]interface{}{"name": "iris2"})
+	if values, _ = s.Load("sid"); values["name"] != "iris2" || len(values) != 1 {
+		t.Fatalf("expected the updated values but got %v", values)
+	}
+
+	// empty values remove the session.
+	if err = s.Commit("sid", sessions.Transaction{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Load("sid"); err == nil {
+		t.Fatalf("expected the session to be removed")
+	}
+
+	for _, sid := range []string{"a", "b"} {
+		s.Update(sid, map[string]interface{}{"k": sid})
+	}
+	if err = s.Destroy("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Load("a"); err == nil {
+		t.Fatalf("expected the destroyed session to be removed")
+	}
+	if err = s.DestroyAll(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Load("b"); err == nil {
+		t.Fatalf("expected all the sessions to be removed")
+	}
+}
+
+func TestStorageInvalidTable(t *testing.T) {
+	if _, err := New(nil, Config{Table: "sessions; DROP TABLE users"}); err == nil {
+		t.Fatalf("expected an error for an invalid table name")
+	}
+}
+
+func TestStorageClean(t *testing.T) {
+	s, db := newTestStorage(t, Config{})
+
+	var (
+		mu      sync.Mutex
+		expired []string
+	)
+	s.OnExpire(func(sid string) {
+		mu.Lock()
+		expired = append(expired, sid)
+		mu.Unlock()
+	})
+
+	for _, sid := range []string{"expired1", "expired2", "alive"} {
+		s.Update(sid, map[string]interface{}{"k": sid})
+	}
+	past := time.Now().Add(-time.Minute).Unix()
+	if _, err := db.Exec("UPDATE sessions SET expires_at = ? WHERE sid <> ?", past, "alive"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Load("expired1"); err == nil {
+		t.Fatalf("expected an error for an expired session before the Clean")
+	}
+	if err := s.Clean(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	sort.Strings(expired)
+	got := expired
+	mu.Unlock()
+	if len(got) != 2 || got[0] != "expired1" || got[1] != "expired2" {
+		t.Fatalf("expected the expired sessions to be notified but got %v", got)
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("expected only the alive session to be kept but %d are stored", count)
+	}
+}
+
+func TestStorageCleanRefreshed(t *testing.T) {
+	s, db := newTestStorage(t, Config{})
+
+	var notified []string
+	s.OnExpire(func(sid string) { notified = append(notified, sid) })
+
+	s.Update("refreshed", map[string]interface{}{"k": "v"})
+	past := time.Now().Add(-time.Minute).Unix()
+	if _, err := db.Exec("UPDATE sessions SET expires_at = ?", past); err != nil {
+		t.Fatal(err)
+	}
+	// the session is refreshed by a Commit after the select of the expired sessions,
+	// simulated by a select which returns the sessions which are not expired.
+	if err := s.Commit("refreshed", sessions.Transaction{Values: map[string]interface{}{"k": "v"}, TTL: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	s.expiredQuery = "SELECT sid FROM sessions WHERE expires_at >= ?"
+
+	if err := s.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if len(notified) != 0 {
+		t.Fatalf("expected the refreshed session to not be notified but got %v", notified)
+	}
+	if _, err := s.Load("refreshed"); err != nil {
+		t.Fatalf("expected the refreshed session to be kept but got %v", err)
+	}
+}
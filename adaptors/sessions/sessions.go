@@ -4,12 +4,13 @@
 package sessions
 
 import (
+	"crypto/rand"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/go-iris2/iris2"
-	"math/rand"
 )
 
 type (
@@ -27,6 +28,17 @@ type (
 		// Destroy kills the net/http session and remove the associated cookie
 		Destroy(http.ResponseWriter, *http.Request)
 
+		// Regenerate gives a new id to the request's session, keeping its values,
+		// and sends the new cookie, the old id is not valid anymore.
+		// Use it after a login in order to protect against session fixation,
+		// see the context.SessionRegenerate too.
+		Regenerate(http.ResponseWriter, *http.Request, iris2.Session) iris2.Session
+
+		// End stores the session's changes to the TransactionalDatabase, in one batch,
+		// it's called by Iris when the request is served.
+		// Call it after the handler if the sessions are used without Iris.
+		End(iris2.Session)
+
 		// DestroyByID removes the session entry
 		// from the server-side memory (and database if registered).
 		// Client's session cookie will still exist but it will be reseted on the next request.
@@ -51,7 +63,7 @@ type (
 func New(cfg Config) Sessions {
 	return &sessions{
 		config:   cfg.Validate(),
-		provider: newProvider(cfg.SessionStorage),
+		provider: newProvider(cfg),
 	}
 }
 
@@ -61,56 +73,109 @@ func (s *sessions) Adapt(frame *iris2.Policies) {
 	// If they contains an Adapt func then the policy is an adaptor too and this Adapt func is called
 	// by Iris on .Adapt(...)
 	policy := iris2.SessionsPolicy{
-		Start:   s.Start,
-		Destroy: s.Destroy,
+		Start:      s.Start,
+		Destroy:    s.Destroy,
+		Regenerate: s.Regenerate,
+		End:        s.End,
 	}
 
 	policy.Adapt(frame)
+
+	iris2.EventPolicy{Boot: func(f *iris2.Framework) {
+		s.provider.log = f.Log
+	}}.Adapt(frame)
 }
 
 // Start starts the session for the particular net/http request
 func (s *sessions) Start(res http.ResponseWriter, req *http.Request) iris2.Session {
-	var sess iris2.Session
-
-	clientIP := req.RemoteAddr
-	idx := strings.IndexByte(clientIP, ':')
-	if idx > 0 {
-		clientIP = clientIP[0:idx]
-	}
-
-	cookieName := GetCookie(s.config.Cookie, req)
-	sessionID := clientIP + "_" + cookieName
-	if cookieName != "" && s.provider.Exist(sessionID) {
-		sess = s.provider.Read(sessionID, s.config.Expires)
-	} else {
-		for {
-			cookieName = sessionIDGenerator(s.config.CookieLength)
-			sessionID = clientIP + "_" + cookieName
-			if !s.provider.Exist(sessionID) {
-				break
-			}
+	var sess *session
+
+	cookieValue := GetCookie(s.config.Cookie, req)
+	if validCookieValue(cookieValue) {
+		if sessionID := s.sessionID(req, cookieValue); s.provider.Exist(sessionID) {
+			// nil if it's expired.
+			sess = s.provider.Read(sessionID)
 		}
-		sess = s.provider.Init(sessionID, s.config.Expires)
+	}
+	if sess == nil {
+		cookieValue = s.newCookieValue(req)
+		sess = s.provider.Init(s.sessionID(req, cookieValue))
 	}
 	// We always use AddCookie
-	SetCookie(s.buildCookie(cookieName, req.URL.Host), res)
+	SetCookie(buildCookie(s.config, s.config.Cookie, cookieValue, req.URL.Host, sess.created), res)
 
 	return sess
 }
 
 // Destroy kills the net/http session and remove the associated cookie
 func (s *sessions) Destroy(res http.ResponseWriter, req *http.Request) {
-	cookieName := GetCookie(s.config.Cookie, req)
-	if cookieName == "" { // nothing to destroy
+	cookieValue := GetCookie(s.config.Cookie, req)
+	if cookieValue == "" { // nothing to destroy
 		return
 	}
 	RemoveCookie(s.config.Cookie, res, req)
-	clientIP := req.RemoteAddr
-	idx := strings.IndexByte(clientIP, ':')
-	if idx > 0 {
-		clientIP = clientIP[0:idx]
+	if validCookieValue(cookieValue) {
+		s.provider.Destroy(s.sessionID(req, cookieValue))
+	}
+}
+
+// Regenerate gives a new id to the request's session, keeping its values,
+// and sends the new cookie, the old id is not valid anymore.
+func (s *sessions) Regenerate(res http.ResponseWriter, req *http.Request, current iris2.Session) iris2.Session {
+	sess, ok := current.(*session)
+	if !ok || sess.provider != s.provider {
+		return current
+	}
+
+	cookieValue := s.newCookieValue(req)
+	s.provider.Regenerate(sess, s.sessionID(req, cookieValue))
+
+	// replace the cookie of the Start.
+	removeSetCookie(res, func(name string) bool { return name == s.config.Cookie })
+	SetCookie(buildCookie(s.config, s.config.Cookie, cookieValue, req.URL.Host, sess.created), res)
+	return sess
+}
+
+// End stores the session's changes to the TransactionalDatabase, in one batch.
+func (s *sessions) End(current iris2.Session) {
+	if sess, ok := current.(*session); ok && sess.provider == s.provider {
+		s.provider.End(sess)
 	}
-	s.provider.Destroy(clientIP + "_" + cookieName)
+}
+
+// sessionID returns the provider's id of the session's cookie value,
+// it's the cookie value or, if BindIP is enabled, the client's IP and the cookie value.
+func (s *sessions) sessionID(req *http.Request, cookieValue string) string {
+	if s.config.BindIP {
+		return clientIP(req) + "_" + cookieValue
+	}
+	return cookieValue
+}
+
+// validCookieValue returns true if the cookie value can be a session id,
+// the ids which contain a path separator or a ".." are rejected
+// because some of the databases, like the file, use the id as a file name.
+func validCookieValue(cookieValue string) bool {
+	return cookieValue != "" && !strings.ContainsAny(cookieValue, `/\`) && !strings.Contains(cookieValue, "..")
+}
+
+// newCookieValue returns a new unique cookie value.
+func (s *sessions) newCookieValue(req *http.Request) string {
+	for {
+		cookieValue := sessionIDGenerator(s.config.CookieLength)
+		if !s.provider.Exist(s.sessionID(req, cookieValue)) {
+			return cookieValue
+		}
+	}
+}
+
+// clientIP returns the client's IP, without the port.
+func clientIP(req *http.Request) string {
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return ip
 }
 
 // DestroyByID removes the session entry
@@ -131,15 +196,19 @@ func (s *sessions) DestroyAll() {
 	s.provider.DestroyAll()
 }
 
-func (s *sessions) buildCookie(sid, host string) *http.Cookie {
+// buildCookie returns the session's cookie, the name and the value, with the options of the config,
+// created is the time of the session's creation, the cookie expires at created.Add(config.Expires).
+func buildCookie(config Config, name, value, host string, created time.Time) *http.Cookie {
 	cookie := http.Cookie{
-		Name:     s.config.Cookie,
-		Value:    sid,
+		Name:     name,
+		Value:    value,
 		Path:     "/",
-		HttpOnly: s.config.HTTPOnly,
+		HttpOnly: config.HTTPOnly,
+		Secure:   config.CookieSecure,
+		SameSite: config.CookieSameSite,
 	}
 
-	if !s.config.DisableSubdomainPersistence {
+	if !config.DisableSubdomainPersistence {
 		requestDomain := host
 		if portIdx := strings.IndexByte(requestDomain, ':'); portIdx > 0 {
 			requestDomain = requestDomain[0:portIdx]
@@ -172,11 +241,11 @@ func (s *sessions) buildCookie(sid, host string) *http.Cookie {
 	// MaxAge=0 means no 'Max-Age' attribute specified.
 	// MaxAge<0 means delete cookie now, equivalently 'Max-Age: 0'
 	// MaxAge>0 means Max-Age attribute present and given in seconds
-	if s.config.Expires >= 0 {
-		if s.config.Expires == 0 { // unlimited life
+	if config.Expires >= 0 {
+		if config.Expires == 0 { // unlimited life
 			cookie.Expires = CookieExpireUnlimited
 		} else { // > 0
-			cookie.Expires = time.Now().Add(s.config.Expires)
+			cookie.Expires = created.Add(config.Expires)
 		}
 		cookie.MaxAge = int(cookie.Expires.Sub(time.Now()).Seconds())
 	}
@@ -184,27 +253,29 @@ func (s *sessions) buildCookie(sid, host string) *http.Cookie {
 }
 
 const (
-	letterBytes   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	letterIdxBits = 6                    // 6 bits to represent a letter index
-	letterIdxMask = 1<<letterIdxBits - 1 // All 1-bits, as many as letterIdxBits
-	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
+	letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// letterMax is the largest multiple of the len(letterBytes) which fits in a byte,
+	// the random bytes above it are skipped in order to keep the letters uniform.
+	letterMax = 256 - 256%len(letterBytes)
 )
 
-// SessionIDGenerator generates a random string of size n
+// sessionIDGenerator generates a random string of size n, from the crypto/rand
 func sessionIDGenerator(n int) string {
-	src := rand.NewSource(time.Now().UnixNano())
 	b := make([]byte, n)
-	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
-	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
-		if remain == 0 {
-			cache, remain = src.Int63(), letterIdxMax
+	random := make([]byte, n)
+	for i := 0; i < n; {
+		if _, err := rand.Read(random); err != nil {
+			panic("sessions: generate a session id: " + err.Error())
 		}
-		if idx := int(cache & letterIdxMask); idx < len(letterBytes) {
-			b[i] = letterBytes[idx]
-			i--
+		for _, r := range random {
+			if int(r) >= letterMax {
+				continue
+			}
+			b[i] = letterBytes[int(r)%len(letterBytes)]
+			if i++; i == n {
+				break
+			}
 		}
-		cache >>= letterIdxBits
-		remain--
 	}
 
 	return string(b)
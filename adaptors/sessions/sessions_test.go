@@ -0,0 +1,141 @@
+package sessions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryDatabase is a Database which keeps the values in memory and records the loaded ids.
+type memoryDatabase struct {
+	mu     sync.Mutex
+	loaded []string
+	values map[string]map[string]interface{}
+}
+
+func newMemoryDatabase() *memoryDatabase {
+	return &memoryDatabase{values: make(map[string]map[string]interface{})}
+}
+
+func (db *memoryDatabase) Load(sid string) (map[string]interface{}, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.loaded = append(db.loaded, sid)
+	values, ok := db.values[sid]
+	if !ok {
+		return nil, errFindParse.Format("session", sid, nil)
+	}
+	copied := make(map[string]interface{}, len(values))
+	for key, v := range values {
+		copied[key] = v
+	}
+	return copied, nil
+}
+
+func (db *memoryDatabase) Update(sid string, values map[string]interface{}) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if len(values) == 0 {
+		delete(db.values, sid)
+		return
+	}
+	copied := make(map[string]interface{}, len(values))
+	for key, v := range values {
+		copied[key] = v
+	}
+	db.values[sid] = copied
+}
+
+func TestStartInvalidCookie(t *testing.T) {
+	db := newMemoryDatabase()
+	s := New(Config{Cookie: "sid", SessionStorage: db})
+
+	for _, cookieValue := range []string{"../../etc/passwd", `..\secret`, "a/b", "..", "a..b"} {
+		db.mu.Lock()
+		db.loaded = nil
+		db.mu.Unlock()
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(&http.Cookie{Name: "sid", Value: cookieValue})
+		res := httptest.NewRecorder()
+		sess := s.Start(res, req)
+		if sess.ID() == cookieValue {
+			t.Fatalf("expected a new session id instead of the cookie value '%s'", cookieValue)
+		}
+		for _, sid := range db.loaded {
+			if sid == cookieValue {
+				t.Fatalf("expected the cookie value '%s' not to be loaded", cookieValue)
+			}
+		}
+	}
+}
+
+func TestSessionIDGenerator(t *testing.T) {
+	ids := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		id := sessionIDGenerator(DefaultCookieLength)
+		if len(id) != DefaultCookieLength {
+			t.Fatalf("expected an id of %d characters but got '%s'", DefaultCookieLength, id)
+		}
+		if strings.Trim(id, letterBytes) != "" {
+			t.Fatalf("expected an id of letters but got '%s'", id)
+		}
+		if ids[id] {
+			t.Fatalf("expected unique ids but got '%s' twice", id)
+		}
+		ids[id] = true
+	}
+
+	if id := sessionIDGenerator(1); len(id) != 1 {
+		t.Fatalf("expected an id of 1 character but got '%s'", id)
+	}
+}
+
+func TestSessionCreatedReload(t *testing.T) {
+	db := newMemoryDatabase()
+	cfg := Config{Cookie: "sid", Expires: time.Hour, SessionStorage: db}
+
+	res := httptest.NewRecorder()
+	sess := New(cfg).Start(res, httptest.NewRequest(http.MethodGet, "/", nil)).(*session)
+	sess.Set("name", "iris")
+	created := sess.created
+
+	// a new manager, i.e after a restart, loads the session with its creation time.
+	time.Sleep(10 * time.Millisecond)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: sess.ID()})
+	reloaded := New(cfg).Start(httptest.NewRecorder(), req).(*session)
+	if reloaded.ID() != sess.ID() {
+		t.Fatalf("expected the session '%s' but got '%s'", sess.ID(), reloaded.ID())
+	}
+	if !reloaded.created.Equal(created) {
+		t.Fatalf("expected the creation time %v but got %v", created, reloaded.created)
+	}
+	if values := reloaded.GetAll(); len(values) != 1 || values["name"] != "iris" {
+		t.Fatalf("expected only the session's values but got %v", values)
+	}
+}
+
+func TestSessionCreatedExpired(t *testing.T) {
+	db := newMemoryDatabase()
+	db.values["old"] = map[string]interface{}{
+		"name":     "iris",
+		createdKey: time.Now().Add(-2 * time.Hour).Format(time.RFC3339Nano),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "sid", Value: "old"})
+	sess := New(Config{Cookie: "sid", Expires: time.Hour, SessionStorage: db}).Start(httptest.NewRecorder(), req)
+	if sess.ID() == "old" || sess.Get("name") != nil {
+		t.Fatalf("expected a new session instead of the expired one")
+	}
+	db.mu.Lock()
+	_, found := db.values["old"]
+	db.mu.Unlock()
+	if found {
+		t.Fatal("expected the expired session to be removed from the database")
+	}
+}
@@ -4,6 +4,7 @@ package view
 
 import (
 	"io"
+	"io/fs"
 	"strings"
 
 	"github.com/go-iris2/iris2"
@@ -46,6 +47,26 @@ func (h *Adaptor) Binary(assetFn func(name string) ([]byte, error), namesFn func
 	return h
 }
 
+// FS optionally, use it in place of the Binary when template files are distributed
+// inside the app executable by an embed.FS, or any other fs.FS.
+// The names of the fsys' files are checked against the directory, i.e
+// view.HTML("./templates", ".html").FS(templatesFS) where templatesFS embeds the "templates" directory.
+func (h *Adaptor) FS(fsys fs.FS) *Adaptor {
+	assetFn := func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, name)
+	}
+	namesFn := func() (names []string) {
+		fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				names = append(names, name)
+			}
+			return nil
+		})
+		return
+	}
+	return h.Binary(assetFn, namesFn)
+}
+
 // Reload if setted to true the templates are reloading on each call,
 // use it when you're in development and you're boring of restarting
 // the whole app when you edit a template file
@@ -59,7 +80,7 @@ func (h *Adaptor) Reload(developmentMode bool) *Adaptor {
 // we use  that instead of just return New() iris2.RenderPolicy
 // for two reasons:
 // -  the user may need to edit the adaptor's fields
-//   like Directory, Binary
+//   like Directory, Binary, FS
 // - we need to adapt an event policy to add the engine to the external mux
 //   and load it.
 func (h *Adaptor) Adapt(frame *iris2.Policies) {
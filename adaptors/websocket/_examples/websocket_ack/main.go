@@ -0,0 +1,73 @@
+package main
+
+// Run first `go run main.go server`
+// and `go run main.go client` as many times as you want.
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+	"github.com/go-iris2/iris2/adaptors/websocket/client"
+)
+
+func main() {
+	if len(os.Args) == 2 && strings.ToLower(os.Args[1]) == "server" {
+		server()
+	} else if len(os.Args) == 2 && strings.ToLower(os.Args[1]) == "client" {
+		dial()
+	} else {
+		fmt.Println("websocket_ack [server|client]")
+	}
+}
+
+func server() {
+	app := iris2.New()
+	ws := websocket.New(websocket.Config{Endpoint: "/socket"})
+	ws.OnConnection(func(c websocket.Connection) {
+		// the result is the reply of the client's EmitWithAck.
+		c.OnAck("upper", func(message interface{}) (interface{}, error) {
+			s, ok := message.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a string but got %v", message)
+			}
+			return strings.ToUpper(s), nil
+		})
+
+		c.On("ready", func() {
+			// don't wait inside the listener, the reader waits for it.
+			go func() {
+				reply, err := c.EmitWithAck("name", "what's your name?", 5*time.Second)
+				fmt.Printf("client %s replied: %v, %v\n", c.ID(), reply, err)
+			}()
+		})
+	})
+	app.Adapt(ws)
+
+	app.Listen("0.0.0.0:9090")
+}
+
+func dial() {
+	c, err := client.Dial("ws://localhost:9090/socket")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer c.Close()
+
+	c.OnAck("name", func(message interface{}) (interface{}, error) {
+		return "gopher", nil
+	})
+	c.Emit("ready", "")
+
+	reply, err := c.EmitWithAck("upper", "hello", 5*time.Second)
+	fmt.Println(reply, err)
+
+	// a ReplyError of the server
+	_, err = c.EmitWithAck("upper", 42, 5*time.Second)
+	fmt.Println(err)
+
+	time.Sleep(time.Second)
+}
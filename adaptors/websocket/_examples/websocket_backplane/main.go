@@ -0,0 +1,41 @@
+package main
+
+// Run a redis on 127.0.0.1:6379, then `go run main.go :8080` and `go run main.go :8081`,
+// the clients of both servers chat in the same rooms.
+import (
+	"os"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/redis/service"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+	"github.com/go-iris2/iris2/adaptors/websocket/backplane/redis"
+)
+
+func main() {
+	addr := ":8080"
+	if len(os.Args) > 1 {
+		addr = os.Args[1]
+	}
+
+	backplane := redis.New(service.Config{Prefix: "chat:"})
+	defer backplane.Close()
+
+	app := iris2.New()
+
+	ws := websocket.New(websocket.Config{
+		Endpoint:  "/chat",
+		Backplane: backplane,
+	})
+	ws.OnConnection(func(c websocket.Connection) {
+		c.On("join", func(room string) {
+			c.Join(room)
+		})
+		c.On("chat", func(message string) {
+			// the connections of the room on all the servers receive it, once.
+			c.To("room1").Emit("chat", message)
+		})
+	})
+	app.Adapt(ws)
+
+	app.Listen(addr)
+}
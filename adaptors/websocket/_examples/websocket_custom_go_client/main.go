@@ -6,19 +6,16 @@ package main
 // which you can find here: https://github.com/kataras/go-websocket/issues/24
 import (
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/go-iris2/iris2"
 	"github.com/go-iris2/iris2/adaptors/websocket"
-
-	xwebsocket "golang.org/x/net/websocket"
+	"github.com/go-iris2/iris2/adaptors/websocket/client"
 )
 
-// WS is the current websocket connection
-var WS *xwebsocket.Conn
-
 func main() {
 	if len(os.Args) == 2 && strings.ToLower(os.Args[1]) == "server" {
 		ServerLoop()
@@ -31,92 +28,41 @@ func main() {
 
 /////////////////////////////////////////////////////////////////////////
 // client side
-func sendUntilErr(sendInterval int) {
-	i := 1
-	for {
-		time.Sleep(time.Duration(sendInterval) * time.Second)
-		err := SendMessage("2", "all", "objectupdate", "2.UsrSchedule_v1_1")
-		if err != nil {
-			fmt.Println("failed to send join message", err.Error())
-			return
-		}
-		fmt.Println("objectupdate", i)
-		i++
+
+// ClientLoop connects to websocket server, the keep send and recv data,
+// the client reconnects and re-joins its rooms automatically.
+func ClientLoop() {
+	c, err := client.Dial("ws://localhost:9090/socket", client.Config{
+		Header: http.Header{"Origin": []string{"http://localhost/"}},
+	})
+	if err != nil {
+		fmt.Println("failed to connect websocket", err.Error())
+		return
 	}
-}
+	defer c.Close()
 
-func recvUntilErr() {
-	var msg = make([]byte, 2048)
-	var n int
-	var err error
 	i := 1
-	for {
-		if n, err = WS.Read(msg); err != nil {
-			fmt.Println(err.Error())
-			return
-		}
-		fmt.Printf("%v Received: %s.%v\n", time.Now(), string(msg[:n]), i)
+	c.On("objectupdate", func(message string) {
+		fmt.Printf("%v Received: %s.%v\n", time.Now(), message, i)
 		i++
-	}
-
-}
+	})
+	c.OnDisconnect(func() { fmt.Println("disconnected, reconnecting...") })
 
-//ConnectWebSocket connect a websocket to host
-func ConnectWebSocket() error {
-	var origin = "http://localhost/"
-	var url = "ws://localhost:9090/socket"
-	var err error
-	WS, err = xwebsocket.Dial(url, "", origin)
-	return err
-}
-
-// CloseWebSocket closes the current websocket connection
-func CloseWebSocket() error {
-	if WS != nil {
-		return WS.Close()
-	}
-	return nil
-}
-
-// SendMessage broadcast a message to server
-func SendMessage(serverID, to, method, message string) error {
-	buffer := []byte(message)
-	return SendtBytes(serverID, to, method, buffer)
-}
-
-// SendtBytes broadcast a message to server
-func SendtBytes(serverID, to, method string, message []byte) error {
-	// look https://github.com/kataras/iris/tree/master/adaptors/websocket/message.go , client.go and client.js
-	// to understand the buffer line:
-	buffer := []byte(fmt.Sprintf("iris-websocket-message:%v;0;%v;%v;", method, serverID, to))
-	buffer = append(buffer, message...)
-	_, err := WS.Write(buffer)
-	if err != nil {
-		fmt.Println(err)
-		return err
+	// the server joins the connection to the "server2" room.
+	if err = c.Join("server2"); err != nil {
+		fmt.Println("failed to send join message", err.Error())
+		return
 	}
-	return nil
-}
 
-// ClientLoop connects to websocket server, the keep send and recv dataS
-func ClientLoop() {
-	for {
+	for j := 1; ; j++ {
 		time.Sleep(time.Second)
-		err := ConnectWebSocket()
-		if err != nil {
-			fmt.Println("failed to connect websocket", err.Error())
+		// serverID;to;objectID
+		if err = c.Emit("objectupdate", "2;all;2.UsrSchedule_v1_1"); err != nil {
+			fmt.Println("failed to send objectupdate message", err.Error())
 			continue
 		}
-		// time.Sleep(time.Second)
-		err = SendMessage("2", "all", "join", "dummy2")
-		go sendUntilErr(1)
-		recvUntilErr()
-		err = CloseWebSocket()
-		if err != nil {
-			fmt.Println("failed to close websocket", err.Error())
-		}
+		fmt.Println("objectupdate", j)
 	}
-
 }
 
 /////////////////////////////////////////////////////////////////////////
@@ -125,7 +71,7 @@ func ClientLoop() {
 // OnConnect handles incoming websocket connection
 func OnConnect(c websocket.Connection) {
 	fmt.Println("socket.OnConnect()")
-	c.On("join", func(message string) { OnJoin(message, c) })
+	c.On("join", func(room string) { OnJoin(room, c) })
 	c.On("objectupdate", func(message string) { OnObjectUpdated(message, c) })
 	// ok works too c.EmitMessage([]byte("dsadsa"))
 	c.OnDisconnect(func() { OnDisconnect(c) })
@@ -145,9 +91,9 @@ func ServerLoop() {
 }
 
 // OnJoin handles Join broadcast group request
-func OnJoin(message string, c websocket.Connection) {
+func OnJoin(room string, c websocket.Connection) {
 	t := time.Now()
-	c.Join("server2")
+	c.Join(room)
 	fmt.Println("OnJoin() time taken:", time.Since(t))
 }
 
@@ -0,0 +1,78 @@
+package main
+
+// Run first `go run main.go server`
+// and `go run main.go client gopher` as many times as you want, with different names.
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+	"github.com/go-iris2/iris2/adaptors/websocket/client"
+)
+
+func main() {
+	if len(os.Args) == 2 && strings.ToLower(os.Args[1]) == "server" {
+		server()
+	} else if len(os.Args) == 3 && strings.ToLower(os.Args[1]) == "client" {
+		dial(os.Args[2])
+	} else {
+		fmt.Println("websocket_presence [server|client <name>]")
+	}
+}
+
+func server() {
+	app := iris2.New()
+	ws := websocket.New(websocket.Config{Endpoint: "/socket"})
+
+	// names returns the names of the room's members, stored with Connection.Set.
+	names := func(room string) []string {
+		var members []string
+		for _, id := range ws.RoomMembers(room) {
+			if c := ws.GetConnection(id); c != nil {
+				members = append(members, c.GetString("name"))
+			}
+		}
+		return members
+	}
+
+	ws.OnJoin(func(room string, connID string) {
+		ws.GetConnection(connID).To(room).Emit("presence", names(room))
+	})
+	// fires on disconnect too, the connection is not a member anymore.
+	ws.OnLeave(func(room string, connID string) {
+		if members := ws.RoomMembers(room); len(members) > 0 {
+			ws.GetConnection(members[0]).To(room).Emit("presence", names(room))
+		}
+		fmt.Printf("rooms: %v\n", ws.Rooms())
+	})
+
+	ws.OnConnection(func(c websocket.Connection) {
+		c.On("join", func(name string) {
+			c.Set("name", name)
+			c.Join("lobby")
+			fmt.Printf("%s joined %v\n", name, c.Rooms())
+		})
+	})
+	app.Adapt(ws)
+
+	app.Listen("0.0.0.0:9090")
+}
+
+func dial(name string) {
+	c, err := client.Dial("ws://localhost:9090/socket")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer c.Close()
+
+	c.On("presence", func(members interface{}) {
+		fmt.Printf("online: %v\n", members)
+	})
+	c.Emit("join", name)
+
+	time.Sleep(10 * time.Second)
+}
@@ -0,0 +1,208 @@
+package websocket_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+	"github.com/go-iris2/iris2/adaptors/websocket/client"
+	gorilla "github.com/gorilla/websocket"
+)
+
+// newAckServer starts a websocket server which replies to the "upper" ack requests,
+// the connections are sent to the conns after their "ready" message, when their reader is started.
+func newAckServer(t *testing.T) (string, chan websocket.Connection, func()) {
+	app := iris2.New()
+	ws := websocket.New(websocket.Config{Endpoint: "/socket"})
+	app.Adapt(ws)
+	conns := make(chan websocket.Connection, 1)
+	ws.OnConnection(func(c websocket.Connection) {
+		c.OnAck("upper", func(message interface{}) (interface{}, error) {
+			s, _ := message.(string)
+			if s == "" {
+				return nil, errors.New("empty message")
+			}
+			return strings.ToUpper(s), nil
+		})
+		c.On("ready", func() {
+			conns <- c
+		})
+	})
+	app.Boot()
+
+	srv := httptest.NewServer(app.Router)
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/socket", conns, srv.Close
+}
+
+// readyConnection sends the "ready" message of the client and returns its server's connection.
+func readyConnection(t *testing.T, c *client.Client, conns chan websocket.Connection) websocket.Connection {
+	if err := c.Emit("ready", ""); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case conn := <-conns:
+		return conn
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for the connection")
+	}
+	return nil
+}
+
+func TestAckRoundTrip(t *testing.T) {
+	url, conns, closeServer := newAckServer(t)
+	defer closeServer()
+
+	c, err := client.Dial(url, client.Config{DisableReconnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.OnAck("name", func(message interface{}) (interface{}, error) {
+		return "client " + message.(string), nil
+	})
+
+	// client to server
+	reply, err := c.EmitWithAck("upper", "iris", 3*time.Second)
+	if err != nil || reply != "IRIS" {
+		t.Fatalf("expected the reply 'IRIS' but got %v, %v", reply, err)
+	}
+	if _, err = c.EmitWithAck("upper", "", 3*time.Second); err != websocket.ReplyError("empty message") {
+		t.Fatalf("expected the error of the OnAck but got %v", err)
+	}
+
+	// server to client
+	conn := readyConnection(t, c, conns)
+	reply, err = conn.EmitWithAck("name", "iris", 3*time.Second)
+	if err != nil || reply != "client iris" {
+		t.Fatalf("expected the reply 'client iris' but got %v, %v", reply, err)
+	}
+	if _, err = conn.EmitWithAck("unknown", "iris", 3*time.Second); err == nil {
+		t.Fatal("expected an error for an event without listeners")
+	} else if _, ok := err.(websocket.ReplyError); !ok {
+		t.Fatalf("expected a ReplyError but got %v", err)
+	}
+}
+
+func TestAckTimeout(t *testing.T) {
+	url, conns, closeServer := newAckServer(t)
+	defer closeServer()
+
+	c, err := client.Dial(url, client.Config{DisableReconnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	release := make(chan struct{})
+	c.OnAck("slow", func(message interface{}) (interface{}, error) {
+		<-release
+		return "late", nil
+	})
+	c.OnAck("fast", func(message interface{}) (interface{}, error) {
+		return "fast", nil
+	})
+
+	conn := readyConnection(t, c, conns)
+	start := time.Now()
+	if _, err = conn.EmitWithAck("slow", "", 100*time.Millisecond); err != websocket.ErrAckTimeout {
+		t.Fatalf("expected the ErrAckTimeout but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected the timeout after 100ms but waited %v", elapsed)
+	}
+
+	// the late reply is ignored, the next ack request gets its own reply.
+	close(release)
+	reply, err := conn.EmitWithAck("fast", "", 3*time.Second)
+	if err != nil || reply != "fast" {
+		t.Fatalf("expected the reply 'fast' but got %v, %v", reply, err)
+	}
+}
+
+func TestAckDisconnected(t *testing.T) {
+	url, conns, closeServer := newAckServer(t)
+	defer closeServer()
+
+	c, err := client.Dial(url, client.Config{DisableReconnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	received := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	c.OnAck("never", func(message interface{}) (interface{}, error) {
+		close(received)
+		<-release
+		return nil, nil
+	})
+
+	conn := readyConnection(t, c, conns)
+	result := make(chan error, 1)
+	go func() {
+		_, err := conn.EmitWithAck("never", "", 0)
+		result <- err
+	}()
+	select {
+	case <-received:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for the ack request")
+	}
+
+	// the pending ack requests are cancelled on disconnect.
+	conn.Disconnect()
+	select {
+	case err = <-result:
+		if err != websocket.ErrAckDisconnected {
+			t.Fatalf("expected the ErrAckDisconnected but got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected the pending ack request to be cancelled")
+	}
+	if _, err = conn.EmitWithAck("never", "", 0); err != websocket.ErrAckDisconnected {
+		t.Fatalf("expected the ErrAckDisconnected after the disconnect but got %v", err)
+	}
+}
+
+func TestAckNotSupported(t *testing.T) {
+	url, conns, closeServer := newAckServer(t)
+	defer closeServer()
+
+	// an old client, without the subprotocol.
+	conn, _, err := gorilla.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ready, err := websocket.SerializeMessage("ready", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.WriteMessage(gorilla.TextMessage, ready); err != nil {
+		t.Fatal(err)
+	}
+
+	var c websocket.Connection
+	select {
+	case c = <-conns:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for the connection")
+	}
+	if _, err = c.EmitWithAck("upper", "iris", time.Second); err != websocket.ErrAckNotSupported {
+		t.Fatalf("expected the ErrAckNotSupported but got %v", err)
+	}
+	// the events are still sent to the old clients.
+	if err = c.Emit("event", "iris"); err != nil {
+		t.Fatal(err)
+	}
+	_, message, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected, _ := websocket.SerializeMessage("event", "iris"); string(message) != string(expected) {
+		t.Fatalf("expected the message '%s' but got '%s'", expected, message)
+	}
+}
@@ -0,0 +1,231 @@
+package websocket
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// -------------------------------------------------------------------------------------
+// -------------------------------------------------------------------------------------
+// --------------------------------Backplane implementation-----------------------------
+// -------------------------------------------------------------------------------------
+// -------------------------------------------------------------------------------------
+
+// Backplane is the pub/sub of the websocket servers of a cluster, see Config.Backplane.
+// The messages to the rooms and to all, the joins, the leaves and the disconnections
+// of the connections of the other servers are published to all the servers,
+// each server delivers them to its own connections.
+//
+// It should be safe for use by multiple goroutines.
+type Backplane interface {
+	// Publish sends the payload to the subscribers of all the servers, this one's too.
+	Publish(payload []byte) error
+	// Subscribe registers the callback of the published payloads, the server subscribes once.
+	Subscribe(cb func(payload []byte)) error
+	// SetConnected marks the connection as connected, or not, to one of the servers.
+	SetConnected(connID string, connected bool) error
+	// IsConnected reports whether the connection is connected to one of the servers.
+	IsConnected(connID string) (bool, error)
+}
+
+type backplaneOp uint8
+
+const (
+	backplaneMessage backplaneOp = iota
+	backplaneJoin
+	backplaneLeave
+	backplaneLeaveAll
+	backplaneDisconnect
+)
+
+// backplaneEnvelope is the published payload.
+type backplaneEnvelope struct {
+	// ID is unique per message, the servers ignore the messages which they received already.
+	ID string `json:"id"`
+	// Origin is the publisher server, it ignores its own messages.
+	Origin string      `json:"origin"`
+	Op     backplaneOp `json:"op"`
+	From   string      `json:"from,omitempty"`
+	To     string      `json:"to,omitempty"`
+	Room   string      `json:"room,omitempty"`
+	ConnID string      `json:"conn,omitempty"`
+	Data   []byte      `json:"data,omitempty"`
+}
+
+// backplaneSeenSize is the number of the latest messages which are remembered in order to ignore their duplicates.
+const backplaneSeenSize = 1024
+
+// seenMessages are the IDs of the latest received messages.
+type seenMessages struct {
+	mu   sync.Mutex
+	ids  map[string]struct{}
+	ring []string
+	next int
+}
+
+// add returns false if the id is received already.
+func (s *seenMessages) add(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.ids[id]; ok {
+		return false
+	}
+	if s.ids == nil {
+		s.ids = make(map[string]struct{}, backplaneSeenSize)
+		s.ring = make([]string, backplaneSeenSize)
+	}
+	if old := s.ring[s.next]; old != "" {
+		delete(s.ids, old)
+	}
+	s.ring[s.next] = id
+	s.next = (s.next + 1) % backplaneSeenSize
+	s.ids[id] = struct{}{}
+	return true
+}
+
+// BackplaneRetryDelay is the delay between the retries of a failed Backplane's Subscribe, 1 second.
+const BackplaneRetryDelay = 1 * time.Second
+
+// subscribe subscribes to the backplane, if it fails then it's retried in the background
+// until it's subscribed or the Framework is closed,
+// meanwhile this server doesn't receive the messages of the other servers.
+func (s *server) subscribe() {
+	err := s.config.Backplane.Subscribe(s.backplaneReceived)
+	if err == nil {
+		return
+	}
+	s.log("websocket backplane: %s, retrying to subscribe", err)
+	go func() {
+		ticker := time.NewTicker(BackplaneRetryDelay)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.closed:
+				return
+			case <-ticker.C:
+			}
+			if err := s.config.Backplane.Subscribe(s.backplaneReceived); err == nil {
+				s.log("websocket backplane: subscribed")
+				return
+			}
+		}
+	}()
+}
+
+// close stops the retries of the backplane's Subscribe, it's called when the Framework is closing.
+func (s *server) close() {
+	s.closeOnce.Do(func() {
+		close(s.closed)
+	})
+}
+
+// publish sends the envelope to the other servers, if there is a backplane.
+func (s *server) publish(env backplaneEnvelope) {
+	if s.config.Backplane == nil {
+		return
+	}
+	env.Origin = s.id
+	env.ID = s.id + ":" + strconv.FormatUint(atomic.AddUint64(&s.seq, 1), 10)
+	payload, err := json.Marshal(env)
+	if err == nil {
+		err = s.config.Backplane.Publish(payload)
+	}
+	if err != nil {
+		s.log("websocket backplane: %s", err)
+	}
+}
+
+// backplaneReceived runs the message of another server on this server's connections.
+func (s *server) backplaneReceived(payload []byte) {
+	env := backplaneEnvelope{}
+	if err := json.Unmarshal(payload, &env); err != nil {
+		s.log("websocket backplane: %s", err)
+		return
+	}
+	if env.Origin == s.id || !s.seen.add(env.ID) {
+		return
+	}
+
+	switch env.Op {
+	case backplaneMessage:
+		s.emitLocalMessage(env.From, env.To, env.Data)
+	case backplaneJoin:
+		if s.isLocal(env.ConnID) {
+			s.Join(env.Room, env.ConnID)
+		}
+	case backplaneLeave:
+		if s.isLocal(env.ConnID) {
+			s.Leave(env.Room, env.ConnID)
+		}
+	case backplaneLeaveAll:
+		if s.isLocal(env.ConnID) {
+			s.LeaveAll(env.ConnID)
+		}
+	case backplaneDisconnect:
+		if s.isLocal(env.ConnID) {
+			s.Disconnect(env.ConnID)
+		}
+	}
+}
+
+// setConnected marks the connection on the backplane, if any.
+func (s *server) setConnected(connID string, connected bool) {
+	if s.config.Backplane == nil {
+		return
+	}
+	if err := s.config.Backplane.SetConnected(connID, connected); err != nil {
+		s.log("websocket backplane: %s", err)
+	}
+}
+
+type memoryBackplane struct {
+	mu          sync.RWMutex
+	subscribers []func(payload []byte)
+	connected   map[string]struct{}
+}
+
+var _ Backplane = &memoryBackplane{}
+
+// NewMemoryBackplane returns a new in-process Backplane,
+// share it between the servers of the same process, i.e in the tests.
+func NewMemoryBackplane() Backplane {
+	return &memoryBackplane{connected: make(map[string]struct{})}
+}
+
+func (b *memoryBackplane) Publish(payload []byte) error {
+	b.mu.RLock()
+	subscribers := b.subscribers
+	b.mu.RUnlock()
+	for _, cb := range subscribers {
+		cb(append([]byte(nil), payload...))
+	}
+	return nil
+}
+
+func (b *memoryBackplane) Subscribe(cb func(payload []byte)) error {
+	b.mu.Lock()
+	b.subscribers = append(b.subscribers, cb)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *memoryBackplane) SetConnected(connID string, connected bool) error {
+	b.mu.Lock()
+	if connected {
+		b.connected[connID] = struct{}{}
+	} else {
+		delete(b.connected, connID)
+	}
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *memoryBackplane) IsConnected(connID string) (bool, error) {
+	b.mu.RLock()
+	_, ok := b.connected[connID]
+	b.mu.RUnlock()
+	return ok, nil
+}
@@ -0,0 +1,186 @@
+// Package redis provides a websocket.Backplane on the redis' pub/sub,
+// the servers which share the same redis (and Prefix) share their rooms and broadcasts.
+package redis
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/redis/service"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+)
+
+const (
+	// Channel is the pub/sub channel of the servers, after the Config's Prefix
+	Channel = "websocket"
+	// connectedPrefix is the prefix of the keys of the connected connections
+	connectedPrefix = "websocket:conn:"
+	// DefaultConnectedTTL is the ConnectedTTL, 1 minute
+	DefaultConnectedTTL = 1 * time.Minute
+)
+
+// Backplane is the redis websocket.Backplane, use it as the websocket's Config.Backplane.
+type Backplane struct {
+	// ConnectedTTL is the expiration of the keys of the connected connections,
+	// the keys of this server's connections are refreshed every third of it,
+	// the connections of a server which is stopped without their disconnection are expired after it.
+	// Default is DefaultConnectedTTL
+	ConnectedTTL time.Duration
+
+	redis *service.Service
+
+	mu           sync.Mutex
+	subscription io.Closer
+
+	// connectedMu protects the connected, the connections of this server, and the refresh.
+	connectedMu sync.Mutex
+	connected   map[string]struct{}
+	refresh     chan struct{}
+}
+
+var _ websocket.Backplane = &Backplane{}
+
+// New returns a new redis Backplane,
+// the connected connections are stored for the ConnectedTTL.
+func New(cfg ...service.Config) *Backplane {
+	return &Backplane{
+		ConnectedTTL: DefaultConnectedTTL,
+		redis:        service.New(cfg...),
+		connected:    make(map[string]struct{}),
+	}
+}
+
+// Config returns the configuration for the redis server bridge, you can change them
+func (b *Backplane) Config() *service.Config {
+	return b.redis.Config
+}
+
+func (b *Backplane) connect() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.redis.Connected {
+		b.redis.Connect()
+		if _, err := b.redis.PingPong(); err != nil {
+			b.redis.Connected = false
+			return fmt.Errorf("no connection to redis: %v", err)
+		}
+	}
+	return nil
+}
+
+// Publish sends the payload to the servers
+func (b *Backplane) Publish(payload []byte) error {
+	if err := b.connect(); err != nil {
+		return err
+	}
+	return b.redis.Publish(Channel, payload)
+}
+
+// Subscribe registers the callback of the published payloads,
+// the subscription is renewed when the connection to the redis is lost.
+func (b *Backplane) Subscribe(cb func(payload []byte)) error {
+	if err := b.connect(); err != nil {
+		return err
+	}
+	subscription, err := b.redis.Subscribe(Channel, cb)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.subscription = subscription
+	b.mu.Unlock()
+	return nil
+}
+
+// SetConnected marks the connection as connected, or not, to one of the servers,
+// the connected ones of this server are refreshed until their disconnection or the Close.
+func (b *Backplane) SetConnected(connID string, connected bool) error {
+	if err := b.connect(); err != nil {
+		return err
+	}
+	b.connectedMu.Lock()
+	if connected {
+		b.connected[connID] = struct{}{}
+		if b.refresh == nil {
+			b.refresh = make(chan struct{})
+			go b.refreshConnected(b.refresh)
+		}
+	} else {
+		delete(b.connected, connID)
+	}
+	b.connectedMu.Unlock()
+
+	if connected {
+		return b.redis.SetTTL(connectedPrefix+connID, []byte{1}, b.connectedTTL())
+	}
+	return b.redis.Delete(connectedPrefix + connID)
+}
+
+func (b *Backplane) connectedTTL() time.Duration {
+	if b.ConnectedTTL <= 0 {
+		return DefaultConnectedTTL
+	}
+	return b.ConnectedTTL
+}
+
+// refreshConnected renews the expiration of the connected connections of this server until the done is closed.
+func (b *Backplane) refreshConnected(done chan struct{}) {
+	ticker := time.NewTicker(b.connectedTTL() / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		b.connectedMu.Lock()
+		connIDs := make([]string, 0, len(b.connected))
+		for connID := range b.connected {
+			connIDs = append(connIDs, connID)
+		}
+		b.connectedMu.Unlock()
+
+		for _, connID := range connIDs {
+			// the failed ones are retried on the next tick, before their expiration.
+			b.redis.SetTTL(connectedPrefix+connID, []byte{1}, b.connectedTTL())
+
+			// don't resurrect the key of a connection which was disconnected meanwhile.
+			b.connectedMu.Lock()
+			_, ok := b.connected[connID]
+			b.connectedMu.Unlock()
+			if !ok {
+				b.redis.Delete(connectedPrefix + connID)
+			}
+		}
+	}
+}
+
+// IsConnected reports whether the connection is connected to one of the servers
+func (b *Backplane) IsConnected(connID string) (bool, error) {
+	if err := b.connect(); err != nil {
+		return false, err
+	}
+	return b.redis.Exists(connectedPrefix + connID)
+}
+
+// Close stops the subscription and the refresh of the connected connections,
+// then it closes the connections to the redis
+func (b *Backplane) Close() error {
+	b.mu.Lock()
+	subscription := b.subscription
+	b.subscription = nil
+	b.mu.Unlock()
+	if subscription != nil {
+		subscription.Close()
+	}
+	b.connectedMu.Lock()
+	if b.refresh != nil {
+		close(b.refresh)
+		b.refresh = nil
+	}
+	b.connectedMu.Unlock()
+	return b.redis.CloseConnection()
+}
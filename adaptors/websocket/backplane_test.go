@@ -0,0 +1,241 @@
+package websocket_test
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+	"github.com/go-iris2/iris2/adaptors/websocket/client"
+)
+
+// newBackplaneServer starts a websocket server of the backplane, it returns its url.
+func newBackplaneServer(t *testing.T, backplane websocket.Backplane) (websocket.Server, string, func()) {
+	app := iris2.New()
+	ws := websocket.New(websocket.Config{Endpoint: "/socket", Backplane: backplane})
+	app.Adapt(ws)
+	ws.OnConnection(func(c websocket.Connection) {
+		c.On("id", func() {
+			c.Emit("id", c.ID())
+		})
+		c.On(client.DefaultJoinEvent, func(room string) {
+			c.Join(room)
+			c.Emit("joined", room)
+		})
+		c.On("room", func(room string) {
+			c.To(room).Emit("room", room)
+		})
+	})
+	app.Boot()
+
+	srv := httptest.NewServer(app.Router)
+	return ws, "ws" + strings.TrimPrefix(srv.URL, "http") + "/socket", srv.Close
+}
+
+// backplaneClient is a client of one of the servers, its messages are sent to the messages.
+type backplaneClient struct {
+	*client.Client
+	messages chan string
+}
+
+func dialBackplaneClient(t *testing.T, url string) *backplaneClient {
+	c, err := client.Dial(url, client.Config{DisableReconnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc := &backplaneClient{Client: c, messages: make(chan string, 10)}
+	for _, event := range []string{"id", "joined", "room"} {
+		event := event
+		c.On(event, func(message string) {
+			bc.messages <- event + ":" + message
+		})
+	}
+	c.OnDisconnect(func() {
+		bc.messages <- "disconnected"
+	})
+	return bc
+}
+
+func (c *backplaneClient) expect(t *testing.T, expected string) {
+	select {
+	case got := <-c.messages:
+		if got != expected {
+			t.Fatalf("expected '%s' but got '%s'", expected, got)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout while waiting for '%s'", expected)
+	}
+}
+
+func (c *backplaneClient) expectNothing(t *testing.T) {
+	select {
+	case got := <-c.messages:
+		t.Fatalf("expected nothing but got '%s'", got)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func (c *backplaneClient) id(t *testing.T) string {
+	if err := c.Emit("id", ""); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-c.messages:
+		return strings.TrimPrefix(got, "id:")
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for the id")
+	}
+	return ""
+}
+
+func TestBackplaneRooms(t *testing.T) {
+	backplane := websocket.NewMemoryBackplane()
+	ws1, url1, close1 := newBackplaneServer(t, backplane)
+	defer close1()
+	ws2, url2, close2 := newBackplaneServer(t, backplane)
+	defer close2()
+
+	a := dialBackplaneClient(t, url1)
+	defer a.Close()
+	b := dialBackplaneClient(t, url2)
+	defer b.Close()
+	idB := b.id(t)
+
+	// a connection of the other server is connected.
+	if !ws1.IsConnected(idB) || !ws2.IsConnected(idB) {
+		t.Fatalf("expected the '%s' to be connected to both of the servers", idB)
+	}
+
+	// the rooms are shared by the servers.
+	a.Join("room1")
+	a.expect(t, "joined:room1")
+	b.Join("room1")
+	b.expect(t, "joined:room1")
+	a.Emit("room", "room1")
+	a.expect(t, "room:room1")
+	b.expect(t, "room:room1")
+
+	// the server joins a connection of the other server.
+	ws1.Join("room2", idB)
+	time.Sleep(100 * time.Millisecond)
+	a.Emit("room", "room2")
+	b.expect(t, "room:room2")
+	a.expectNothing(t)
+
+	// the server disconnects a connection of the other server.
+	ws1.Disconnect(idB)
+	b.expect(t, "disconnected")
+	time.Sleep(100 * time.Millisecond)
+	if ws1.IsConnected(idB) || ws2.IsConnected(idB) {
+		t.Fatalf("expected the '%s' to be disconnected from both of the servers", idB)
+	}
+	a.Emit("room", "room1")
+	a.expect(t, "room:room1")
+	a.expectNothing(t)
+}
+
+// duplicateBackplane publishes each payload twice.
+type duplicateBackplane struct {
+	websocket.Backplane
+}
+
+func (b duplicateBackplane) Publish(payload []byte) error {
+	if err := b.Backplane.Publish(payload); err != nil {
+		return err
+	}
+	return b.Backplane.Publish(payload)
+}
+
+func TestBackplaneDuplicates(t *testing.T) {
+	backplane := duplicateBackplane{websocket.NewMemoryBackplane()}
+	_, url1, close1 := newBackplaneServer(t, backplane)
+	defer close1()
+	_, url2, close2 := newBackplaneServer(t, backplane)
+	defer close2()
+
+	a := dialBackplaneClient(t, url1)
+	defer a.Close()
+	b := dialBackplaneClient(t, url2)
+	defer b.Close()
+
+	b.Join("room1")
+	b.expect(t, "joined:room1")
+	a.Emit("room", "room1")
+	b.expect(t, "room:room1")
+	b.expectNothing(t)
+}
+
+// failingBackplane fails the first Subscribe.
+type failingBackplane struct {
+	websocket.Backplane
+	mu     sync.Mutex
+	failed bool
+}
+
+func (b *failingBackplane) Subscribe(cb func(payload []byte)) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.failed {
+		b.failed = true
+		return errors.New("unavailable")
+	}
+	return b.Backplane.Subscribe(cb)
+}
+
+func TestBackplaneSubscribeRetry(t *testing.T) {
+	backplane := websocket.NewMemoryBackplane()
+	_, url1, close1 := newBackplaneServer(t, backplane)
+	defer close1()
+	_, url2, close2 := newBackplaneServer(t, &failingBackplane{Backplane: backplane})
+	defer close2()
+
+	a := dialBackplaneClient(t, url1)
+	defer a.Close()
+	b := dialBackplaneClient(t, url2)
+	defer b.Close()
+
+	b.Join("room1")
+	b.expect(t, "joined:room1")
+	time.Sleep(websocket.BackplaneRetryDelay + 200*time.Millisecond)
+	a.Emit("room", "room1")
+	b.expect(t, "room:room1")
+}
+
+// unavailableBackplane fails all the Subscribes, it counts them.
+type unavailableBackplane struct {
+	websocket.Backplane
+	attempts int32
+}
+
+func (b *unavailableBackplane) Subscribe(cb func(payload []byte)) error {
+	atomic.AddInt32(&b.attempts, 1)
+	return errors.New("unavailable")
+}
+
+func TestBackplaneSubscribeRetryStop(t *testing.T) {
+	backplane := &unavailableBackplane{Backplane: websocket.NewMemoryBackplane()}
+	app := iris2.New()
+	app.Adapt(websocket.New(websocket.Config{Endpoint: "/socket", Backplane: backplane}))
+	app.Boot()
+
+	time.Sleep(websocket.BackplaneRetryDelay + 200*time.Millisecond)
+	if n := atomic.LoadInt32(&backplane.attempts); n < 2 {
+		t.Fatalf("expected the Subscribe to be retried but it was called %d times", n)
+	}
+
+	// the retries stop when the Framework is closed.
+	if err := app.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	n := atomic.LoadInt32(&backplane.attempts)
+	time.Sleep(websocket.BackplaneRetryDelay + 200*time.Millisecond)
+	if after := atomic.LoadInt32(&backplane.attempts); after != n {
+		t.Fatalf("expected no retries after the Close but the Subscribe was called %d more times", after-n)
+	}
+}
@@ -19,6 +19,16 @@ var websocketMessageSeparatorLen = websocketMessageSeparator.length;
 var websocketMessagePrefixAndSepIdx = websocketMessagePrefixLen + websocketMessageSeparatorLen - 1;
 var websocketMessagePrefixIdx = websocketMessagePrefixLen - 1;
 var websocketMessageSeparatorIdx = websocketMessageSeparatorLen - 1;
+// the acks and the batches, the server supports them if it accepts the subprotocol,
+// the v2 is the acks only, of the older servers
+var websocketSubprotocol = "iris-websocket.v3";
+var websocketSubprotocolV2 = "iris-websocket.v2";
+var websocketAckPrefix = "iris-websocket-ack:";
+var websocketReplyPrefix = "iris-websocket-reply:";
+var websocketReplyEvent = "reply";
+var websocketErrorEvent = "error";
+// iris-websocket-batch:length;message length;message, the server batches the queued messages
+var websocketBatchPrefix = "iris-websocket-batch:";
 var Ws = (function () {
     //
     function Ws(endpoint, protocols) {
@@ -28,24 +38,26 @@ var Ws = (function () {
         this.disconnectListeners = [];
         this.nativeMessageListeners = [];
         this.messageListeners = {};
+        this.ackListeners = {};
+        // acks
+        this.ackID = 0;
+        this.pendingAcks = {};
         if (!window["WebSocket"]) {
             return;
         }
         if (endpoint.indexOf("ws") == -1) {
             endpoint = "ws://" + endpoint;
         }
-        if (protocols != null && protocols.length > 0) {
-            this.conn = new WebSocket(endpoint, protocols);
-        }
-        else {
-            this.conn = new WebSocket(endpoint);
-        }
+        // ask for the acks and the batches, the application's subprotocols come first.
+        protocols = (protocols || []).concat([websocketSubprotocol, websocketSubprotocolV2]);
+        this.conn = new WebSocket(endpoint, protocols);
         this.conn.onopen = (function (evt) {
             _this.fireConnect();
             _this.isReady = true;
             return null;
         });
         this.conn.onclose = (function (evt) {
+            _this.cancelAcks();
             _this.fireDisconnect();
             return null;
         });
@@ -142,9 +154,44 @@ var Ws = (function () {
     // else calls the fireMessage
     //
     // remember q gives you the freedom of native websocket messages if you don't want to use this client side at all.
+    // the batches of the server are split to their messages first.
     Ws.prototype.messageReceivedFromConn = function (evt) {
-        //check if qws message
         var message = evt.data;
+        if (message.indexOf(websocketBatchPrefix) == 0) {
+            var messages = this.splitBatch(message);
+            for (var i = 0; i < messages.length; i++) {
+                this.messageReceived(messages[i]);
+            }
+            return;
+        }
+        this.messageReceived(message);
+    };
+    // splitBatch returns the messages of a batch
+    Ws.prototype.splitBatch = function (batch) {
+        var messages = [];
+        var s = batch.substring(websocketBatchPrefix.length, batch.length);
+        while (s.length > 0) {
+            var idx = s.indexOf(websocketMessageSeparator);
+            if (idx < 0) {
+                break;
+            }
+            var length = parseInt(s.substring(0, idx));
+            s = s.substring(idx + websocketMessageSeparatorLen, s.length);
+            messages.push(s.substring(0, length));
+            s = s.substring(length, s.length);
+        }
+        return messages;
+    };
+    Ws.prototype.messageReceived = function (message) {
+        //check if qws message
+        if (message.indexOf(websocketAckPrefix) == 0) {
+            this.ackReceived(message);
+            return;
+        }
+        if (message.indexOf(websocketReplyPrefix) == 0) {
+            this.replyReceived(message);
+            return;
+        }
         if (message.indexOf(websocketMessagePrefix) != -1) {
             var event_1 = this.getWebsocketCustomEvent(message);
             if (event_1 != "") {
@@ -156,6 +203,73 @@ var Ws = (function () {
         // it's a native websocket message
         this.fireNativeMessage(message);
     };
+    // ackReceived replies to the ack request by the OnAck callback or after the On listeners of its event
+    Ws.prototype.ackReceived = function (message) {
+        var _this = this;
+        var s = message.substring(websocketAckPrefix.length, message.length);
+        var idx = s.indexOf(websocketMessageSeparator);
+        var id = s.substring(0, idx);
+        var custom = s.substring(idx + websocketMessageSeparatorLen, s.length);
+        var event = this.getWebsocketCustomEvent(custom);
+        var data = this.getCustomMessage(event, custom);
+        var reply;
+        if (this.ackListeners.hasOwnProperty(event)) {
+            var cb_1 = this.ackListeners[event];
+            reply = new Promise(function (resolve) { resolve(cb_1(data)); });
+        }
+        else if (this.messageListeners.hasOwnProperty(event)) {
+            this.fireMessage(event, data);
+            reply = Promise.resolve(null);
+        }
+        else {
+            reply = Promise.reject(new Error("websocket: no listeners for the event '" + event + "'"));
+        }
+        reply.then(function (result) {
+            var replyMessage;
+            if (result === undefined || result === null) {
+                replyMessage = _this._msg(websocketReplyEvent, websocketJSONMessageType, "null");
+            }
+            else {
+                replyMessage = _this.encodeMessage(websocketReplyEvent, result);
+            }
+            _this.EmitMessage(websocketReplyPrefix + id + websocketMessageSeparator + replyMessage);
+        }, function (err) {
+            var errorMessage = String(err && err.message ? err.message : err);
+            _this.EmitMessage(websocketReplyPrefix + id + websocketMessageSeparator + _this.encodeMessage(websocketErrorEvent, errorMessage));
+        });
+    };
+    // replyReceived resolves the promise of the EmitWithAck, if it's not timed out
+    Ws.prototype.replyReceived = function (message) {
+        var s = message.substring(websocketReplyPrefix.length, message.length);
+        var idx = s.indexOf(websocketMessageSeparator);
+        var id = s.substring(0, idx);
+        var custom = s.substring(idx + websocketMessageSeparatorLen, s.length);
+        var pending = this.pendingAcks[id];
+        if (pending == undefined) {
+            return;
+        }
+        delete this.pendingAcks[id];
+        clearTimeout(pending.timer);
+        var event = this.getWebsocketCustomEvent(custom);
+        var reply = this.decodeMessage(event, custom);
+        if (event == websocketErrorEvent) {
+            pending.reject(new Error(reply));
+        }
+        else {
+            pending.resolve(reply);
+        }
+    };
+    // cancelAcks rejects the pending acks, on disconnect
+    Ws.prototype.cancelAcks = function () {
+        var pending = this.pendingAcks;
+        this.pendingAcks = {};
+        for (var id in pending) {
+            if (pending.hasOwnProperty(id)) {
+                clearTimeout(pending[id].timer);
+                pending[id].reject(new Error("websocket: disconnected before the ack"));
+            }
+        }
+    };
     Ws.prototype.OnConnect = function (fn) {
         if (this.isReady) {
             fn();
@@ -189,6 +303,11 @@ var Ws = (function () {
         }
         this.messageListeners[event].push(cb);
     };
+    // OnAck registers the callback of the ack requests of an event,
+    // its result, or the result of its promise, is the reply to the server.
+    Ws.prototype.OnAck = function (event, cb) {
+        this.ackListeners[event] = cb;
+    };
     Ws.prototype.fireMessage = function (event, message) {
         for (var key in this.messageListeners) {
             if (this.messageListeners.hasOwnProperty(key)) {
@@ -214,6 +333,26 @@ var Ws = (function () {
         var messageStr = this.encodeMessage(event, data);
         this.EmitMessage(messageStr);
     };
+    // EmitWithAck sends an q-custom websocket message and resolves to the reply of the server's OnAck,
+    // it rejects after the timeout (milliseconds), if any, or if the server doesn't support the acks.
+    Ws.prototype.EmitWithAck = function (event, data, timeout) {
+        var _this = this;
+        if (this.conn.protocol != websocketSubprotocol && this.conn.protocol != websocketSubprotocolV2) {
+            return Promise.reject(new Error("websocket: the server doesn't support the acks"));
+        }
+        var id = String(++this.ackID);
+        return new Promise(function (resolve, reject) {
+            var timer = 0;
+            if (timeout > 0) {
+                timer = setTimeout(function () {
+                    delete _this.pendingAcks[id];
+                    reject(new Error("websocket: ack timeout"));
+                }, timeout);
+            }
+            _this.pendingAcks[id] = { resolve: resolve, reject: reject, timer: timer };
+            _this.EmitMessage(websocketAckPrefix + id + websocketMessageSeparator + _this.encodeMessage(event, data));
+        });
+    };
     return Ws;
 }());
 `)
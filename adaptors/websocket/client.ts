@@ -21,10 +21,29 @@ var websocketMessagePrefixAndSepIdx = websocketMessagePrefixLen + websocketMessa
 var websocketMessagePrefixIdx = websocketMessagePrefixLen - 1;
 var websocketMessageSeparatorIdx = websocketMessageSeparatorLen - 1;
 
+// the acks and the batches, the server supports them if it accepts the subprotocol,
+// the v2 is the acks only, of the older servers
+const websocketSubprotocol = "iris-websocket.v3";
+const websocketSubprotocolV2 = "iris-websocket.v2";
+const websocketAckPrefix = "iris-websocket-ack:";
+const websocketReplyPrefix = "iris-websocket-reply:";
+const websocketReplyEvent = "reply";
+const websocketErrorEvent = "error";
+// iris-websocket-batch:length;message length;message, the server batches the queued messages
+const websocketBatchPrefix = "iris-websocket-batch:";
+
 type onConnectFunc = () => void;
 type onWebsocketDisconnectFunc = () => void;
 type onWebsocketNativeMessageFunc = (websocketMessage: string) => void;
 type onMessageFunc = (message: any) => void;
+// the result, or its promise, is the reply
+type onAckFunc = (message: any) => any;
+
+interface pendingAck {
+    resolve: (reply: any) => void;
+    reject: (err: Error) => void;
+    timer: number;
+}
 
 class Ws {
     private conn: WebSocket;
@@ -36,6 +55,11 @@ class Ws {
     private disconnectListeners: onWebsocketDisconnectFunc[] = [];
     private nativeMessageListeners: onWebsocketNativeMessageFunc[] = [];
     private messageListeners: { [event: string]: onMessageFunc[] } = {};
+    private ackListeners: { [event: string]: onAckFunc } = {};
+
+    // acks
+    private ackID: number = 0;
+    private pendingAcks: { [id: string]: pendingAck } = {};
 
     //
 
@@ -47,11 +71,9 @@ class Ws {
         if (endpoint.indexOf("ws") == -1) {
             endpoint = "ws://" + endpoint;
         }
-        if (protocols != null && protocols.length > 0) {
-            this.conn = new WebSocket(endpoint, protocols);
-        } else {
-            this.conn = new WebSocket(endpoint);
-        }
+        // ask for the acks and the batches, the application's subprotocols come first.
+        protocols = (protocols || []).concat([websocketSubprotocol, websocketSubprotocolV2]);
+        this.conn = new WebSocket(endpoint, protocols);
 
         this.conn.onopen = ((evt: Event): any => {
             this.fireConnect();
@@ -60,6 +82,7 @@ class Ws {
         });
 
         this.conn.onclose = ((evt: Event): any => {
+            this.cancelAcks();
             this.fireDisconnect();
             return null;
         });
@@ -166,9 +189,46 @@ class Ws {
     // else calls the fireMessage
     //
     // remember q gives you the freedom of native websocket messages if you don't want to use this client side at all.
+    // the batches of the server are split to their messages first.
     private messageReceivedFromConn(evt: MessageEvent): void {
-        //check if qws message
         let message = <string>evt.data;
+        if (message.indexOf(websocketBatchPrefix) == 0) {
+            let messages = this.splitBatch(message);
+            for (let i = 0; i < messages.length; i++) {
+                this.messageReceived(messages[i]);
+            }
+            return;
+        }
+        this.messageReceived(message);
+    }
+
+    // splitBatch returns the messages of a batch
+    private splitBatch(batch: string): string[] {
+        let messages: string[] = [];
+        let s = batch.substring(websocketBatchPrefix.length, batch.length);
+        while (s.length > 0) {
+            let idx = s.indexOf(websocketMessageSeparator);
+            if (idx < 0) {
+                break;
+            }
+            let length = parseInt(s.substring(0, idx));
+            s = s.substring(idx + websocketMessageSeparatorLen, s.length);
+            messages.push(s.substring(0, length));
+            s = s.substring(length, s.length);
+        }
+        return messages;
+    }
+
+    private messageReceived(message: string): void {
+        //check if qws message
+        if (message.indexOf(websocketAckPrefix) == 0) {
+            this.ackReceived(message);
+            return;
+        }
+        if (message.indexOf(websocketReplyPrefix) == 0) {
+            this.replyReceived(message);
+            return;
+        }
         if (message.indexOf(websocketMessagePrefix) != -1) {
             let event = this.getWebsocketCustomEvent(message);
             if (event != "") {
@@ -182,6 +242,74 @@ class Ws {
         this.fireNativeMessage(message);
     }
 
+    // ackReceived replies to the ack request by the OnAck callback or after the On listeners of its event
+    private ackReceived(message: string): void {
+        let s = message.substring(websocketAckPrefix.length, message.length);
+        let idx = s.indexOf(websocketMessageSeparator);
+        let id = s.substring(0, idx);
+        let custom = s.substring(idx + websocketMessageSeparatorLen, s.length);
+        let event = this.getWebsocketCustomEvent(custom);
+        let data = this.getCustomMessage(event, custom);
+
+        let reply: Promise<any>;
+        if (this.ackListeners.hasOwnProperty(event)) {
+            let cb = this.ackListeners[event];
+            reply = new Promise((resolve) => { resolve(cb(data)); });
+        } else if (this.messageListeners.hasOwnProperty(event)) {
+            this.fireMessage(event, data);
+            reply = Promise.resolve(null);
+        } else {
+            reply = Promise.reject(new Error("websocket: no listeners for the event '" + event + "'"));
+        }
+
+        reply.then((result: any) => {
+            let replyMessage: string;
+            if (result === undefined || result === null) {
+                replyMessage = this._msg(websocketReplyEvent, websocketJSONMessageType, "null");
+            } else {
+                replyMessage = this.encodeMessage(websocketReplyEvent, result);
+            }
+            this.EmitMessage(websocketReplyPrefix + id + websocketMessageSeparator + replyMessage);
+        }, (err: any) => {
+            let errorMessage = String(err && err.message ? err.message : err);
+            this.EmitMessage(websocketReplyPrefix + id + websocketMessageSeparator + this.encodeMessage(websocketErrorEvent, errorMessage));
+        });
+    }
+
+    // replyReceived resolves the promise of the EmitWithAck, if it's not timed out
+    private replyReceived(message: string): void {
+        let s = message.substring(websocketReplyPrefix.length, message.length);
+        let idx = s.indexOf(websocketMessageSeparator);
+        let id = s.substring(0, idx);
+        let custom = s.substring(idx + websocketMessageSeparatorLen, s.length);
+        let pending = this.pendingAcks[id];
+        if (pending == undefined) {
+            return;
+        }
+        delete this.pendingAcks[id];
+        clearTimeout(pending.timer);
+
+        let event = this.getWebsocketCustomEvent(custom);
+        let reply = this.decodeMessage(event, custom);
+        if (event == websocketErrorEvent) {
+            pending.reject(new Error(reply));
+        } else {
+            pending.resolve(reply);
+        }
+    }
+
+    // cancelAcks rejects the pending acks, on disconnect
+    private cancelAcks(): void {
+        let pending = this.pendingAcks;
+        this.pendingAcks = {};
+        for (let id in pending) {
+            if (pending.hasOwnProperty(id)) {
+                clearTimeout(pending[id].timer);
+                pending[id].reject(new Error("websocket: disconnected before the ack"));
+            }
+        }
+    }
+
     OnConnect(fn: onConnectFunc): void {
         if (this.isReady) {
             fn();
@@ -222,6 +350,12 @@ class Ws {
         this.messageListeners[event].push(cb);
     }
 
+    // OnAck registers the callback of the ack requests of an event,
+    // its result, or the result of its promise, is the reply to the server.
+    OnAck(event: string, cb: onAckFunc): void {
+        this.ackListeners[event] = cb;
+    }
+
     fireMessage(event: string, message: any): void {
         for (let key in this.messageListeners) {
             if (this.messageListeners.hasOwnProperty(key)) {
@@ -254,6 +388,27 @@ class Ws {
         this.EmitMessage(messageStr);
     }
 
+    // EmitWithAck sends an q-custom websocket message and resolves to the reply of the server's OnAck,
+    // it rejects after the timeout (milliseconds), if any, or if the server doesn't support the acks.
+    EmitWithAck(event: string, data: any, timeout?: number): Promise<any> {
+        if (this.conn.protocol != websocketSubprotocol && this.conn.protocol != websocketSubprotocolV2) {
+            return Promise.reject(new Error("websocket: the server doesn't support the acks"));
+        }
+
+        let id = String(++this.ackID);
+        return new Promise((resolve, reject) => {
+            let timer = 0;
+            if (timeout > 0) {
+                timer = setTimeout(() => {
+                    delete this.pendingAcks[id];
+                    reject(new Error("websocket: ack timeout"));
+                }, timeout);
+            }
+            this.pendingAcks[id] = { resolve: resolve, reject: reject, timer: timer };
+            this.EmitMessage(websocketAckPrefix + id + websocketMessageSeparator + this.encodeMessage(event, data));
+        });
+    }
+
     //
 
 }
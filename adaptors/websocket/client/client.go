@@ -0,0 +1,568 @@
+// Package client provides a Go client for the Iris websocket server,
+// it speaks the same event protocol as the javascript client-side, see websocket.ClientSource.
+package client
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-iris2/iris2/adaptors/websocket"
+	"github.com/go-iris2/iris2/errors"
+	gorilla "github.com/gorilla/websocket"
+	"github.com/imdario/mergo"
+)
+
+const (
+	// DefaultMinBackoff is the first delay of the reconnection, 500 milliseconds
+	DefaultMinBackoff = 500 * time.Millisecond
+	// DefaultMaxBackoff is the max delay of the reconnection, 30 seconds
+	DefaultMaxBackoff = 30 * time.Second
+	// DefaultJoinEvent is the event which asks the server to join a room, "join"
+	DefaultJoinEvent = "join"
+	// DefaultLeaveEvent is the event which asks the server to leave a room, "leave"
+	DefaultLeaveEvent = "leave"
+)
+
+var (
+	// ErrDisconnected is returned by the Emit when the client is not connected, i.e it's reconnecting.
+	ErrDisconnected = errors.New("websocket client: not connected")
+	// ErrClosed is returned by the Emit after the Close.
+	ErrClosed = errors.New("websocket client: closed")
+	// ErrAckNotSupported is returned by the EmitWithAck when the server doesn't support the acks,
+	// see websocket.Subprotocol.
+	ErrAckNotSupported = errors.New("websocket client: the server doesn't support the acks")
+	// ErrAckTimeout is returned by the EmitWithAck when the server doesn't reply in time.
+	ErrAckTimeout = errors.New("websocket client: ack timeout")
+
+	errAckNoListeners = errors.New("websocket client: no listeners for the event '%s'")
+
+	errDial = errors.New("websocket client: unable to dial %s: %s")
+)
+
+// Config the configs for the websocket client
+// all of these are optional.
+type Config struct {
+	// Header is the header of the handshake request, i.e the Origin or the Authorization.
+	// Default is nil
+	Header http.Header
+	// Dialer dials the server, the websocket.Subprotocol and SubprotocolV2 are added to its Subprotocols.
+	// Default is the gorilla's websocket.DefaultDialer
+	Dialer *gorilla.Dialer
+	// WriteTimeout time allowed to write a message to the connection.
+	// 0 means no timeout.
+	// Default value is 0
+	WriteTimeout time.Duration
+	// BinaryMessages set it to true in order to send binary messages instead of utf-8 text,
+	// same as the server's BinaryMessages.
+	// Default is false
+	BinaryMessages bool
+	// DisableReconnect, if true, the client doesn't reconnect when the connection is lost.
+	// Default is false
+	DisableReconnect bool
+	// MinBackoff is the first delay of the reconnection, it's doubled on each failed attempt.
+	// Default is 500 milliseconds
+	MinBackoff time.Duration
+	// MaxBackoff is the max delay of the reconnection.
+	// Default is 30 seconds
+	MaxBackoff time.Duration
+	// JoinEvent is the event which asks the server to join a room, its message is the room's name.
+	// The server should listen to it, i.e c.On("join", func(room string) { c.Join(room) }).
+	// Default is "join"
+	JoinEvent string
+	// LeaveEvent is the event which asks the server to leave a room, its message is the room's name.
+	// Default is "leave"
+	LeaveEvent string
+}
+
+// DefaultConfig returns the default configs for the websocket client
+func DefaultConfig() Config {
+	return Config{
+		Dialer:     gorilla.DefaultDialer,
+		MinBackoff: DefaultMinBackoff,
+		MaxBackoff: DefaultMaxBackoff,
+		JoinEvent:  DefaultJoinEvent,
+		LeaveEvent: DefaultLeaveEvent,
+	}
+}
+
+// MergeSingle merges the default with the given config and returns the result
+func (c Config) MergeSingle(cfg Config) (config Config) {
+	config = cfg
+	mergo.Merge(&config, c)
+	return
+}
+
+// Client is a connection to the Iris websocket server, it reconnects automatically
+// and it re-joins its rooms, see Join.
+//
+// It's safe for use by multiple goroutines.
+type Client struct {
+	url    string
+	config Config
+
+	mu                       sync.RWMutex
+	conn                     *gorilla.Conn
+	closed                   bool
+	rooms                    map[string]struct{}
+	onEventListeners         map[string][]websocket.MessageFunc
+	onAckListeners           map[string]websocket.AckFunc
+	onNativeMessageListeners []websocket.NativeMessageFunc
+	onDisconnectListeners    []websocket.DisconnectFunc
+	onReconnectListeners     []func()
+	onErrorListeners         []websocket.ErrorFunc
+	// the gorilla's writers are not safe for concurrent use.
+	writerMu sync.Mutex
+	// acks is true if the server of the connection supports the acks.
+	acks bool
+	// ackMu protects the ackID and the pending ack requests.
+	ackMu       sync.Mutex
+	ackID       uint64
+	pendingAcks map[uint64]chan ackResult
+}
+
+type ackResult struct {
+	reply interface{}
+	err   error
+}
+
+// Dial connects to the websocket server's url, i.e "ws://localhost:8080/socket",
+// and starts to read its messages.
+func Dial(url string, cfg ...Config) (*Client, error) {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = c.MergeSingle(cfg[0])
+	}
+
+	client := &Client{
+		url:              url,
+		config:           c,
+		rooms:            make(map[string]struct{}),
+		onEventListeners: make(map[string][]websocket.MessageFunc),
+		onAckListeners:   make(map[string]websocket.AckFunc),
+		pendingAcks:      make(map[uint64]chan ackResult),
+	}
+	conn, err := client.dial()
+	if err != nil {
+		return nil, err
+	}
+	client.conn = conn
+	client.acks = supportsAcks(conn)
+	go client.run(conn)
+	return client, nil
+}
+
+func (c *Client) dial() (*gorilla.Conn, error) {
+	// ask for the acks and the batches, the application's subprotocols come first.
+	dialer := *c.config.Dialer
+	header := c.config.Header
+	if protocols := header.Get("Sec-Websocket-Protocol"); protocols != "" {
+		header = cloneHeader(header)
+		header.Set("Sec-Websocket-Protocol", protocols+", "+websocket.Subprotocol+", "+websocket.SubprotocolV2)
+	} else {
+		dialer.Subprotocols = append(append([]string(nil), dialer.Subprotocols...), websocket.Subprotocol, websocket.SubprotocolV2)
+	}
+
+	conn, _, err := dialer.Dial(c.url, header)
+	if err != nil {
+		return nil, errDial.Format(c.url, err.Error())
+	}
+	return conn, nil
+}
+
+// supportsAcks reports whether the server of the connection supports the acks,
+// the older servers accept the SubprotocolV2 which has no batches.
+func supportsAcks(conn *gorilla.Conn) bool {
+	protocol := conn.Subprotocol()
+	return protocol == websocket.Subprotocol || protocol == websocket.SubprotocolV2
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for k, v := range h {
+		clone[k] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+// run reads the messages of the connection, then it reconnects, until the Close.
+func (c *Client) run(conn *gorilla.Conn) {
+	for {
+		c.read(conn)
+
+		c.mu.Lock()
+		closed := c.closed
+		c.conn = nil
+		c.mu.Unlock()
+		conn.Close()
+		c.cancelAcks()
+		c.fireDisconnect()
+
+		if closed || c.config.DisableReconnect {
+			return
+		}
+		if conn = c.reconnect(); conn == nil {
+			return
+		}
+	}
+}
+
+func (c *Client) read(conn *gorilla.Conn) {
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if gorilla.IsUnexpectedCloseError(err, gorilla.CloseNormalClosure, gorilla.CloseGoingAway) {
+				c.fireError(err.Error())
+			}
+			return
+		}
+		c.messageReceived(data)
+	}
+}
+
+// reconnect dials the server with an exponential backoff, until it's connected or closed,
+// then it re-joins the rooms.
+func (c *Client) reconnect() *gorilla.Conn {
+	backoff := c.config.MinBackoff
+	for {
+		time.Sleep(backoff)
+
+		c.mu.RLock()
+		closed := c.closed
+		c.mu.RUnlock()
+		if closed {
+			return nil
+		}
+
+		conn, err := c.dial()
+		if err != nil {
+			c.fireError(err.Error())
+			if backoff *= 2; backoff > c.config.MaxBackoff {
+				backoff = c.config.MaxBackoff
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return nil
+		}
+		c.conn = conn
+		c.acks = supportsAcks(conn)
+		rooms := make([]string, 0, len(c.rooms))
+		for room := range c.rooms {
+			rooms = append(rooms, room)
+		}
+		c.mu.Unlock()
+
+		for _, room := range rooms {
+			c.Emit(c.config.JoinEvent, room)
+		}
+		c.mu.RLock()
+		listeners := c.onReconnectListeners
+		c.mu.RUnlock()
+		for _, cb := range listeners {
+			cb()
+		}
+		return conn
+	}
+}
+
+// messageReceived fires the event listeners of a custom message or the native message listeners.
+func (c *Client) messageReceived(data []byte) {
+	if messages, ok := websocket.SplitBatch(data); ok {
+		for _, message := range messages {
+			c.messageReceived(message)
+		}
+		return
+	}
+	if id, custom, ok := websocket.ParseAck(data); ok {
+		c.ackReceived(id, custom)
+		return
+	}
+	if id, custom, ok := websocket.ParseReply(data); ok {
+		c.replyReceived(id, custom)
+		return
+	}
+
+	event := websocket.MessageEvent(data)
+	if event == "" {
+		c.mu.RLock()
+		listeners := c.onNativeMessageListeners
+		c.mu.RUnlock()
+		for _, cb := range listeners {
+			cb(data)
+		}
+		return
+	}
+
+	c.mu.RLock()
+	listeners := c.onEventListeners[event]
+	c.mu.RUnlock()
+	if len(listeners) == 0 {
+		return
+	}
+	message, err := websocket.DeserializeMessage(event, data)
+	if message == nil || err != nil {
+		return
+	}
+	for _, cb := range listeners {
+		if err := websocket.CallMessageFunc(cb, message); err != nil {
+			c.fireError(err.Error())
+		}
+	}
+}
+
+// ackReceived replies to the ack request by the OnAck callback or after the On listeners of its event.
+func (c *Client) ackReceived(id uint64, custom []byte) {
+	var reply interface{}
+	event := websocket.MessageEvent(custom)
+	message, err := websocket.DeserializeMessage(event, custom)
+	if err == nil {
+		c.mu.RLock()
+		cb := c.onAckListeners[event]
+		listeners := c.onEventListeners[event]
+		c.mu.RUnlock()
+		if cb != nil {
+			reply, err = cb(message)
+		} else if len(listeners) > 0 {
+			for _, listener := range listeners {
+				if err := websocket.CallMessageFunc(listener, message); err != nil {
+					c.fireError(err.Error())
+				}
+			}
+		} else {
+			err = errAckNoListeners.Format(event)
+		}
+	}
+
+	replyMessage, serr := websocket.SerializeReply(id, reply, err)
+	if serr != nil {
+		replyMessage, _ = websocket.SerializeReply(id, nil, serr)
+	}
+	c.EmitMessage(replyMessage)
+}
+
+// replyReceived sends the reply to the EmitWithAck which waits for it, if any.
+func (c *Client) replyReceived(id uint64, custom []byte) {
+	c.ackMu.Lock()
+	result, ok := c.pendingAcks[id]
+	delete(c.pendingAcks, id)
+	c.ackMu.Unlock()
+	if !ok {
+		// timed out already
+		return
+	}
+	reply, err := websocket.DeserializeReply(custom)
+	result <- ackResult{reply: reply, err: err}
+}
+
+// cancelAcks fails the pending ack requests, called when the connection is lost.
+func (c *Client) cancelAcks() {
+	c.ackMu.Lock()
+	pending := c.pendingAcks
+	c.pendingAcks = make(map[uint64]chan ackResult)
+	c.ackMu.Unlock()
+	for _, result := range pending {
+		result <- ackResult{err: ErrDisconnected}
+	}
+}
+
+// On registers a callback to a particular event which fires when a message to this event received,
+// same callbacks as the server's Connection.On.
+func (c *Client) On(event string, cb websocket.MessageFunc) {
+	c.mu.Lock()
+	c.onEventListeners[event] = append(c.onEventListeners[event], cb)
+	c.mu.Unlock()
+}
+
+// OnAck registers the callback of the ack requests of an event, its result is the reply to the server,
+// same as the server's Connection.OnAck.
+func (c *Client) OnAck(event string, cb websocket.AckFunc) {
+	c.mu.Lock()
+	c.onAckListeners[event] = cb
+	c.mu.Unlock()
+}
+
+// OnMessage registers a callback which fires when native websocket message received
+func (c *Client) OnMessage(cb websocket.NativeMessageFunc) {
+	c.mu.Lock()
+	c.onNativeMessageListeners = append(c.onNativeMessageListeners, cb)
+	c.mu.Unlock()
+}
+
+// OnDisconnect registers a callback which fires when the connection is lost, before the reconnection,
+// or closed.
+func (c *Client) OnDisconnect(cb websocket.DisconnectFunc) {
+	c.mu.Lock()
+	c.onDisconnectListeners = append(c.onDisconnectListeners, cb)
+	c.mu.Unlock()
+}
+
+// OnReconnect registers a callback which fires when the client is reconnected, after the rooms are re-joined.
+func (c *Client) OnReconnect(cb func()) {
+	c.mu.Lock()
+	c.onReconnectListeners = append(c.onReconnectListeners, cb)
+	c.mu.Unlock()
+}
+
+// OnError registers a callback which fires when the connection is closed unexpectedly or a reconnection fails
+func (c *Client) OnError(cb websocket.ErrorFunc) {
+	c.mu.Lock()
+	c.onErrorListeners = append(c.onErrorListeners, cb)
+	c.mu.Unlock()
+}
+
+func (c *Client) fireDisconnect() {
+	c.mu.RLock()
+	listeners := c.onDisconnectListeners
+	c.mu.RUnlock()
+	for _, cb := range listeners {
+		cb()
+	}
+}
+
+func (c *Client) fireError(errorMessage string) {
+	c.mu.RLock()
+	listeners := c.onErrorListeners
+	c.mu.RUnlock()
+	for _, cb := range listeners {
+		cb(errorMessage)
+	}
+}
+
+// EmitMessage sends a native websocket message
+func (c *Client) EmitMessage(nativeMessage []byte) error {
+	c.mu.RLock()
+	conn, closed := c.conn, c.closed
+	c.mu.RUnlock()
+	if closed {
+		return ErrClosed
+	}
+	if conn == nil {
+		return ErrDisconnected
+	}
+
+	messageType := gorilla.TextMessage
+	if c.config.BinaryMessages {
+		messageType = gorilla.BinaryMessage
+	}
+
+	c.writerMu.Lock()
+	defer c.writerMu.Unlock()
+	if c.config.WriteTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(c.config.WriteTimeout))
+	}
+	return conn.WriteMessage(messageType, nativeMessage)
+}
+
+// Emit sends a message on a particular event,
+// supported data types are: string, int, bool, bytes and JSON.
+func (c *Client) Emit(event string, data interface{}) error {
+	message, err := websocket.SerializeMessage(event, data)
+	if err != nil {
+		return err
+	}
+	return c.EmitMessage(message)
+}
+
+// EmitWithAck sends a message on a particular event and waits for the reply of the server,
+// the server's OnAck callback returns the reply. A zero timeout waits until the disconnection.
+//
+// Don't wait for the reply inside the listeners of the client, the reader waits for them,
+// use a goroutine instead.
+func (c *Client) EmitWithAck(event string, data interface{}, timeout time.Duration) (interface{}, error) {
+	c.mu.RLock()
+	acks, connected := c.acks, c.conn != nil
+	c.mu.RUnlock()
+	if connected && !acks {
+		return nil, ErrAckNotSupported
+	}
+
+	c.ackMu.Lock()
+	c.ackID++
+	id := c.ackID
+	// buffered, the reader doesn't wait for the timed out requests.
+	result := make(chan ackResult, 1)
+	c.pendingAcks[id] = result
+	c.ackMu.Unlock()
+
+	message, err := websocket.SerializeAck(id, event, data)
+	if err == nil {
+		err = c.EmitMessage(message)
+	}
+	if err != nil {
+		c.ackMu.Lock()
+		delete(c.pendingAcks, id)
+		c.ackMu.Unlock()
+		return nil, err
+	}
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	select {
+	case r := <-result:
+		return r.reply, r.err
+	case <-timeoutC:
+		c.ackMu.Lock()
+		delete(c.pendingAcks, id)
+		c.ackMu.Unlock()
+		return nil, ErrAckTimeout
+	}
+}
+
+// Join asks the server to join the room, by the JoinEvent,
+// the room is re-joined on each reconnection.
+func (c *Client) Join(room string) error {
+	c.mu.Lock()
+	c.rooms[room] = struct{}{}
+	c.mu.Unlock()
+	return c.Emit(c.config.JoinEvent, room)
+}
+
+// Leave asks the server to leave the room, by the LeaveEvent.
+func (c *Client) Leave(room string) error {
+	c.mu.Lock()
+	delete(c.rooms, room)
+	c.mu.Unlock()
+	return c.Emit(c.config.LeaveEvent, room)
+}
+
+// Connected reports whether the client is connected, it's false while it's reconnecting and after the Close.
+func (c *Client) Connected() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.conn != nil && !c.closed
+}
+
+// Close closes the connection, with a close message, and stops the reconnection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return ErrClosed
+	}
+	c.closed = true
+	conn := c.conn
+	c.mu.Unlock()
+	if conn == nil {
+		return nil
+	}
+
+	c.writerMu.Lock()
+	err := conn.WriteControl(gorilla.CloseMessage,
+		gorilla.FormatCloseMessage(gorilla.CloseNormalClosure, ""), time.Now().Add(websocket.WriteWait))
+	c.writerMu.Unlock()
+	// the reader closes the connection on the server's close message.
+	if err != nil {
+		return conn.Close()
+	}
+	return nil
+}
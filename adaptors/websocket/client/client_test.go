@@ -0,0 +1,185 @@
+package client_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/websocket"
+	"github.com/go-iris2/iris2/adaptors/websocket/client"
+)
+
+func newServer(t *testing.T) (string, func()) {
+	app := iris2.New()
+	ws := websocket.New(websocket.Config{Endpoint: "/socket"})
+	app.Adapt(ws)
+	ws.OnConnection(func(c websocket.Connection) {
+		c.On("echo", func(message string) {
+			c.Emit("echo", message)
+		})
+		c.On("number", func(message string) {
+			c.Emit("number", message)
+		})
+		c.On(client.DefaultJoinEvent, func(room string) {
+			c.Join(room)
+			c.Emit("joined", room)
+		})
+		c.On("room", func(room string) {
+			c.To(room).Emit("room", room)
+		})
+		c.On("kick", func() {
+			c.Disconnect()
+		})
+	})
+	app.Boot()
+
+	srv := httptest.NewServer(app.Router)
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/socket", srv.Close
+}
+
+func receive(t *testing.T, messages chan string) string {
+	select {
+	case message := <-messages:
+		return message
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for a message")
+	}
+	return ""
+}
+
+func TestClientEmit(t *testing.T) {
+	url, closeServer := newServer(t)
+	defer closeServer()
+
+	c, err := client.Dial(url, client.Config{DisableReconnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	messages := make(chan string, 1)
+	c.On("echo", func(message string) {
+		messages <- message
+	})
+
+	if err := c.Emit("echo", "héllo 🌍"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, messages); got != "héllo 🌍" {
+		t.Fatalf("expected the echo 'héllo 🌍' but got '%s'", got)
+	}
+}
+
+func TestClientMessageTypeMismatch(t *testing.T) {
+	url, closeServer := newServer(t)
+	defer closeServer()
+
+	c, err := client.Dial(url, client.Config{DisableReconnect: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	errs := make(chan string, 1)
+	c.OnError(func(errorMessage string) {
+		errs <- errorMessage
+	})
+	c.On("number", func(n int) {
+		t.Errorf("the listener of an int shouldn't be called with a string, got %d", n)
+	})
+	messages := make(chan string, 1)
+	c.On("echo", func(message string) {
+		messages <- message
+	})
+
+	if err := c.Emit("number", "not a number"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, errs); !strings.Contains(got, "func(int)") {
+		t.Fatalf("expected an error about the func(int) listener but got '%s'", got)
+	}
+
+	// the reader is still alive.
+	if err := c.Emit("echo", "alive"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, messages); got != "alive" {
+		t.Fatalf("expected the echo 'alive' but got '%s'", got)
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	url, closeServer := newServer(t)
+	defer closeServer()
+
+	c, err := client.Dial(url, client.Config{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	joined := make(chan string, 2)
+	c.On("joined", func(room string) {
+		joined <- room
+	})
+	rooms := make(chan string, 1)
+	c.On("room", func(room string) {
+		rooms <- room
+	})
+	disconnected := make(chan string, 1)
+	c.OnDisconnect(func() {
+		disconnected <- "disconnected"
+	})
+	reconnected := make(chan string, 1)
+	c.OnReconnect(func() {
+		reconnected <- "reconnected"
+	})
+
+	if err := c.Join("room1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, joined); got != "room1" {
+		t.Fatalf("expected to join the 'room1' but got '%s'", got)
+	}
+
+	if err := c.Emit("kick", ""); err != nil {
+		t.Fatal(err)
+	}
+	receive(t, disconnected)
+	receive(t, reconnected)
+	if !c.Connected() {
+		t.Fatal("expected to be connected after the reconnect")
+	}
+
+	// the rooms are re-joined on the new connection.
+	if got := receive(t, joined); got != "room1" {
+		t.Fatalf("expected to re-join the 'room1' but got '%s'", got)
+	}
+	if err := c.Emit("room", "room1"); err != nil {
+		t.Fatal(err)
+	}
+	if got := receive(t, rooms); got != "room1" {
+		t.Fatalf("expected a message to the 'room1' but got '%s'", got)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	url, closeServer := newServer(t)
+	defer closeServer()
+
+	c, err := client.Dial(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if c.Connected() {
+		t.Fatal("expected to be disconnected after the Close")
+	}
+	if err := c.Emit("echo", "closed"); err != client.ErrClosed {
+		t.Fatalf("expected the ErrClosed but got %v", err)
+	}
+}
@@ -24,6 +24,21 @@ const (
 	DefaultWebsocketWriterBufferSize = 4096
 	// DefaultClientSourcePath "/iris-ws.js"
 	DefaultClientSourcePath = "/iris-ws.js"
+	// DefaultWebsocketSendQueueSize 256
+	DefaultWebsocketSendQueueSize = 256
+)
+
+// SlowConsumerPolicy is what the server does when the send queue of a connection is full,
+// see Config.SlowConsumerPolicy.
+type SlowConsumerPolicy uint8
+
+const (
+	// SlowConsumerDropOldest drops the oldest queued message in favor of the new one, the default policy.
+	SlowConsumerDropOldest SlowConsumerPolicy = iota
+	// SlowConsumerDropNewest drops the new message.
+	SlowConsumerDropNewest
+	// SlowConsumerDisconnect disconnects the connection.
+	SlowConsumerDisconnect
 )
 
 var (
@@ -77,6 +92,22 @@ type Config struct {
 	// The request is an argument which you can use to generate the ID (from headers for example).
 	// If empty then the ID is generated by DefaultIDGenerator: randomString(64)
 	IDGenerator func(ctx *iris2.Context) string
+	// Backplane shares the rooms, the broadcasts and the disconnections
+	// between the websocket servers of a cluster, i.e the redis one, see the backplane/redis package.
+	// The connection IDs should be unique across the servers.
+	// Defaults to nil, the server is standalone
+	Backplane Backplane
+	// SendQueueSize is the max number of the messages which wait to be written to a connection,
+	// each connection has its own queue and writer so a slow client doesn't block the others.
+	// Default value is 256
+	SendQueueSize int
+	// SlowConsumerPolicy is what the server does when the send queue of a connection is full.
+	// Default value is SlowConsumerDropOldest, the connections are kept
+	SlowConsumerPolicy SlowConsumerPolicy
+	// BatchSize, if positive, the queued messages are written as one frame of up to BatchSize bytes,
+	// only to the clients which support it (see Subprotocol) and not with the BinaryMessages.
+	// Default value is 0, one frame per message
+	BatchSize int
 }
 
 // Validate validates the configuration
@@ -132,5 +163,9 @@ func (c Config) Validate() Config {
 		c.IDGenerator = DefaultIDGenerator
 	}
 
+	if c.SendQueueSize <= 0 {
+		c.SendQueueSize = DefaultWebsocketSendQueueSize
+	}
+
 	return c
 }
@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"io"
 	"net"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/errors"
 	"github.com/gorilla/websocket"
 )
 
@@ -72,6 +73,10 @@ type (
 	// MessageFunc is the second argument to the Emitter's Emit functions.
 	// A callback which should receives one parameter of type string, int, bool or any valid JSON/Go struct
 	MessageFunc interface{}
+	// AckFunc is the callback of the ack requests of an event, see Connection.OnAck.
+	// It receives the message (string, int, bool, []byte or the unmarshaled JSON)
+	// and its result is sent back to the client, the error is sent as a ReplyError.
+	AckFunc func(message interface{}) (interface{}, error)
 	// Connection is the front-end API that you will use to communicate with the client side
 	Connection interface {
 		// Emitter implements EmitMessage & Emit
@@ -100,13 +105,37 @@ type (
 		OnMessage(NativeMessageFunc)
 		// On registers a callback to a particular event which fires when a message to this event received
 		On(string, MessageFunc)
-		// Join join a connection to a room, it doesn't check if connection is already there, so care
+		// OnAck registers the callback of the ack requests of an event, its result is the reply to the client.
+		// The ack requests of the events without an OnAck fire the On listeners, the reply is nil.
+		OnAck(string, AckFunc)
+		// EmitWithAck sends a message on a particular event and waits for the reply of the client,
+		// the client's listener returns the reply. A zero timeout waits until the disconnection.
+		//
+		// The clients which don't support the acks, i.e the old ones, return ErrAckNotSupported.
+		// Don't wait for the reply inside the listeners of the same connection, the reader waits for them,
+		// use a goroutine instead.
+		EmitWithAck(event string, data interface{}, timeout time.Duration) (interface{}, error)
+		// Join joins a connection to a room, joining a room twice has no effect
 		Join(string)
 		// Leave removes a connection from a room
 		Leave(string)
+		// Rooms returns the names of the rooms which the connection joined, sorted
+		Rooms() []string
+		// Set sets a value to a key in the connection's values map,
+		// i.e the user's name or status, safe for concurrent use
+		Set(key string, value interface{})
+		// Get returns the connection's value from a key
+		// if doesn't exists returns nil
+		Get(key string) interface{}
+		// GetString same as Get but returns the value as string
+		// if nothing founds returns empty string ""
+		GetString(key string) string
 		// Disconnect disconnects the client, close the underline websocket conn and removes it from the conn list
 		// returns the error, if any, from the underline connection
 		Disconnect() error
+		// QueueLen returns the number of the messages which wait to be written to the client,
+		// see Config.SendQueueSize.
+		QueueLen() int
 	}
 
 	connection struct {
@@ -119,6 +148,28 @@ type (
 		onErrorListeners         []ErrorFunc
 		onNativeMessageListeners []NativeMessageFunc
 		onEventListeners         map[string][]MessageFunc
+		onAckListeners           map[string]AckFunc
+		// acks is true if the client supports the acks, see Subprotocol and SubprotocolV2.
+		acks bool
+		// batches is true if the client supports the batches, see Subprotocol.
+		batches bool
+		// ackMu protects the ackID and the pending ack requests.
+		ackMu       sync.Mutex
+		ackID       uint64
+		pendingAcks map[uint64]chan ackResult
+		// the send queue, the writer goroutine writes its messages, see Config.SendQueueSize.
+		queueMu     sync.Mutex
+		queue       [][]byte
+		queueClosed bool
+		// slow is true when the connection is disconnected by the SlowConsumerDisconnect.
+		slow        bool
+		queueSignal chan struct{}
+		// closing stops the writer, writerDone is closed when it's stopped.
+		closing    chan struct{}
+		writerDone chan struct{}
+		// the user's values, see Set and Get.
+		valuesMu sync.RWMutex
+		values   map[string]interface{}
 		// these were  maden for performance only
 		self      Emitter // pre-defined emitter than sends message to its self client
 		broadcast Emitter // pre-defined emitter that sends message to all except this
@@ -138,6 +189,22 @@ type (
 
 var _ Connection = &connection{}
 
+var (
+	// ErrAckNotSupported is returned by the EmitWithAck when the client doesn't support the acks, see Subprotocol.
+	ErrAckNotSupported = errors.New("websocket: the client doesn't support the acks")
+	// ErrAckTimeout is returned by the EmitWithAck when the client doesn't reply in time.
+	ErrAckTimeout = errors.New("websocket: ack timeout")
+	// ErrAckDisconnected is returned by the EmitWithAck when the connection is closed before the reply.
+	ErrAckDisconnected = errors.New("websocket: disconnected before the ack")
+
+	errAckNoListeners = errors.New("websocket: no listeners for the event '%s'")
+)
+
+type ackResult struct {
+	reply interface{}
+	err   error
+}
+
 func newConnection(s *server, ctx *iris2.Context, underlineConn UnderlineConnection, id string) *connection {
 	c := &connection{
 		underline:                underlineConn,
@@ -147,6 +214,11 @@ func newConnection(s *server, ctx *iris2.Context, underlineConn UnderlineConnect
 		onErrorListeners:         make([]ErrorFunc, 0),
 		onNativeMessageListeners: make([]NativeMessageFunc, 0),
 		onEventListeners:         make(map[string][]MessageFunc, 0),
+		onAckListeners:           make(map[string]AckFunc, 0),
+		pendingAcks:              make(map[uint64]chan ackResult, 0),
+		queueSignal:              make(chan struct{}, 1),
+		closing:                  make(chan struct{}),
+		writerDone:               make(chan struct{}),
 		ctx:                      ctx,
 		server:                   s,
 	}
@@ -155,6 +227,14 @@ func newConnection(s *server, ctx *iris2.Context, underlineConn UnderlineConnect
 		c.messageType = websocket.BinaryMessage
 	}
 
+	if conn, ok := underlineConn.(interface {
+		Subprotocol() string
+	}); ok {
+		protocol := conn.Subprotocol()
+		c.acks = protocol == Subprotocol || protocol == SubprotocolV2
+		c.batches = protocol == Subprotocol
+	}
+
 	c.self = newEmitter(c, c.id)
 	c.broadcast = newEmitter(c, Broadcast)
 	c.all = newEmitter(c, All)
@@ -165,27 +245,154 @@ func newConnection(s *server, ctx *iris2.Context, underlineConn UnderlineConnect
 // write writes a raw websocket message with a specific type to the client
 // used by ping messages and any CloseMessage types.
 func (c *connection) write(websocketMessageType int, data []byte) {
+	if err := c.writeMessage(websocketMessageType, data); err != nil {
+		// if failed then the connection is off, fire the disconnect
+		c.Disconnect()
+	}
+}
+
+func (c *connection) writeMessage(websocketMessageType int, data []byte) error {
 	// for any-case the app tries to write from different goroutines,
 	// we must protect them because they're reporting that as bug...
 	c.writerMu.Lock()
+	defer c.writerMu.Unlock()
 	if writeTimeout := c.server.config.WriteTimeout; writeTimeout > 0 {
 		// set the write deadline based on the configuration
 		c.underline.SetWriteDeadline(time.Now().Add(writeTimeout))
 	}
 
 	// .WriteMessage same as NextWriter and close (flush)
-	err := c.underline.WriteMessage(websocketMessageType, data)
-	c.writerMu.Unlock()
-	if err != nil {
-		// if failed then the connection is off, fire the disconnect
-		c.Disconnect()
-	}
+	return c.underline.WriteMessage(websocketMessageType, data)
 }
 
-// writeDefault is the same as write but the message type is the configured by c.messageType
-// if BinaryMessages is enabled then it's raw []byte as you expected to work with protobufs
+// writeDefault queues the message, the message type is the configured by c.messageType
+// if BinaryMessages is enabled then it's raw []byte as you expected to work with protobufs.
+//
+// The message is written by the writer goroutine, see startWriter,
+// if the queue is full then the Config.SlowConsumerPolicy decides.
 func (c *connection) writeDefault(data []byte) {
-	c.write(c.messageType, data)
+	c.queueMu.Lock()
+	if c.queueClosed {
+		c.queueMu.Unlock()
+		return
+	}
+	if len(c.queue) >= c.server.config.SendQueueSize {
+		switch c.server.config.SlowConsumerPolicy {
+		case SlowConsumerDropNewest:
+			c.queueMu.Unlock()
+			atomic.AddUint64(&c.server.dropped, 1)
+			return
+		case SlowConsumerDisconnect:
+			slow := c.slow
+			c.slow = true
+			c.queueMu.Unlock()
+			if !slow {
+				atomic.AddUint64(&c.server.slowDisconnects, 1)
+				// not from the caller's goroutine, it may hold the server's locks.
+				go c.Disconnect()
+			}
+			return
+		default:
+			c.queue[0] = nil
+			c.queue = c.queue[1:]
+			atomic.AddInt64(&c.server.queued, -1)
+			atomic.AddUint64(&c.server.dropped, 1)
+		}
+	}
+	c.queue = append(c.queue, data)
+	c.queueMu.Unlock()
+	atomic.AddInt64(&c.server.queued, 1)
+
+	select {
+	case c.queueSignal <- struct{}{}:
+	default:
+		// the writer is signaled already
+	}
+}
+
+// startWriter starts the goroutine which writes the queued messages,
+// it disconnects the client on a write error.
+func (c *connection) startWriter() {
+	go func() {
+		err := c.writeQueue()
+		c.dropQueue()
+		close(c.writerDone)
+		if err != nil {
+			c.Disconnect()
+		}
+	}()
+}
+
+// dropQueue drops the messages which are not written, the writer is stopped.
+func (c *connection) dropQueue() {
+	c.queueMu.Lock()
+	c.queueClosed = true
+	dropped := len(c.queue)
+	c.queue = nil
+	c.queueMu.Unlock()
+	atomic.AddInt64(&c.server.queued, -int64(dropped))
+}
+
+func (c *connection) writeQueue() error {
+	for {
+		select {
+		case <-c.queueSignal:
+			if err := c.flushQueue(); err != nil {
+				return err
+			}
+		case <-c.closing:
+			// write the messages which queued before the disconnect
+			return c.flushQueue()
+		}
+	}
+}
+
+// flushQueue writes the queued messages, in batches if the client supports them.
+func (c *connection) flushQueue() error {
+	c.queueMu.Lock()
+	messages := c.queue
+	c.queue = nil
+	c.queueMu.Unlock()
+	atomic.AddInt64(&c.server.queued, -int64(len(messages)))
+
+	if batchSize := c.server.config.BatchSize; batchSize > 0 && len(messages) > 1 &&
+		c.batches && c.messageType == websocket.TextMessage {
+		messages = batchMessages(messages, batchSize)
+	}
+
+	for _, message := range messages {
+		if err := c.writeMessage(c.messageType, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopWriter stops the writer after it writes the queued messages,
+// it waits up to WriteWait for them.
+func (c *connection) stopWriter() {
+	c.queueMu.Lock()
+	if c.queueClosed {
+		c.queueMu.Unlock()
+		return
+	}
+	c.queueClosed = true
+	c.queueMu.Unlock()
+	close(c.closing)
+
+	timer := time.NewTimer(WriteWait)
+	select {
+	case <-c.writerDone:
+	case <-timer.C:
+	}
+	timer.Stop()
+}
+
+func (c *connection) QueueLen() int {
+	c.queueMu.Lock()
+	n := len(c.queue)
+	c.queueMu.Unlock()
+	return n
 }
 
 const (
@@ -278,28 +485,14 @@ func (c *connection) messageReceived(data []byte) {
 		}
 
 		for i := range listeners {
-			if fn, ok := listeners[i].(func()); ok { // its a simple func(){} callback
-				fn()
-			} else if fnString, ok := listeners[i].(func(string)); ok {
-
-				if msgString, is := customMessage.(string); is {
-					fnString(msgString)
-				} else if msgInt, is := customMessage.(int); is {
-					// here if server side waiting for string but client side sent an int, just convert this int to a string
-					fnString(strconv.Itoa(msgInt))
-				}
-
-			} else if fnInt, ok := listeners[i].(func(int)); ok {
-				fnInt(customMessage.(int))
-			} else if fnBool, ok := listeners[i].(func(bool)); ok {
-				fnBool(customMessage.(bool))
-			} else if fnBytes, ok := listeners[i].(func([]byte)); ok {
-				fnBytes(customMessage.([]byte))
-			} else {
-				listeners[i].(func(interface{}))(customMessage)
+			if err := CallMessageFunc(listeners[i], customMessage); err != nil {
+				c.EmitError(err.Error())
 			}
-
 		}
+	} else if id, custom, ok := ParseAck(data); ok && c.acks {
+		c.ackReceived(id, custom)
+	} else if id, custom, ok := ParseReply(data); ok && c.acks {
+		c.replyReceived(id, custom)
 	} else {
 		// it's native websocket message
 		for i := range c.onNativeMessageListeners {
@@ -309,6 +502,58 @@ func (c *connection) messageReceived(data []byte) {
 
 }
 
+// ackReceived replies to the ack request by the OnAck callback or after the On listeners of its event.
+func (c *connection) ackReceived(id uint64, custom []byte) {
+	var reply interface{}
+	event := MessageEvent(custom)
+	message, err := DeserializeMessage(event, custom)
+	if err == nil {
+		if cb := c.onAckListeners[event]; cb != nil {
+			reply, err = cb(message)
+		} else if listeners := c.onEventListeners[event]; len(listeners) > 0 {
+			for i := range listeners {
+				if err := CallMessageFunc(listeners[i], message); err != nil {
+					c.EmitError(err.Error())
+				}
+			}
+		} else {
+			err = errAckNoListeners.Format(event)
+		}
+	}
+
+	replyMessage, serr := SerializeReply(id, reply, err)
+	if serr != nil {
+		replyMessage, _ = SerializeReply(id, nil, serr)
+	}
+	c.writeDefault(replyMessage)
+}
+
+// replyReceived sends the reply to the EmitWithAck which waits for it, if any.
+func (c *connection) replyReceived(id uint64, custom []byte) {
+	c.ackMu.Lock()
+	result, ok := c.pendingAcks[id]
+	delete(c.pendingAcks, id)
+	c.ackMu.Unlock()
+	if !ok {
+		// timed out already
+		return
+	}
+	reply, err := DeserializeReply(custom)
+	result <- ackResult{reply: reply, err: err}
+}
+
+// cancelAcks fails the pending ack requests, called on disconnect.
+func (c *connection) cancelAcks() {
+	c.ackMu.Lock()
+	pending := c.pendingAcks
+	// nil, the next EmitWithAck returns ErrAckDisconnected.
+	c.pendingAcks = nil
+	c.ackMu.Unlock()
+	for _, result := range pending {
+		result <- ackResult{err: ErrAckDisconnected}
+	}
+}
+
 func (c *connection) ID() string {
 	return c.id
 }
@@ -369,6 +614,54 @@ func (c *connection) On(event string, cb MessageFunc) {
 	c.onEventListeners[event] = append(c.onEventListeners[event], cb)
 }
 
+func (c *connection) OnAck(event string, cb AckFunc) {
+	c.onAckListeners[event] = cb
+}
+
+func (c *connection) EmitWithAck(event string, data interface{}, timeout time.Duration) (interface{}, error) {
+	if !c.acks {
+		return nil, ErrAckNotSupported
+	}
+
+	c.ackMu.Lock()
+	if c.pendingAcks == nil {
+		c.ackMu.Unlock()
+		return nil, ErrAckDisconnected
+	}
+	c.ackID++
+	id := c.ackID
+	// buffered, the reader doesn't wait for the timed out requests.
+	result := make(chan ackResult, 1)
+	c.pendingAcks[id] = result
+	c.ackMu.Unlock()
+
+	message, err := SerializeAck(id, event, data)
+	if err != nil {
+		c.ackMu.Lock()
+		delete(c.pendingAcks, id)
+		c.ackMu.Unlock()
+		return nil, err
+	}
+	c.writeDefault(message)
+
+	var timeoutC <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutC = timer.C
+	}
+
+	select {
+	case r := <-result:
+		return r.reply, r.err
+	case <-timeoutC:
+		c.ackMu.Lock()
+		delete(c.pendingAcks, id)
+		c.ackMu.Unlock()
+		return nil, ErrAckTimeout
+	}
+}
+
 func (c *connection) Join(roomName string) {
 	c.server.Join(roomName, c.id)
 }
@@ -377,6 +670,37 @@ func (c *connection) Leave(roomName string) {
 	c.server.Leave(roomName, c.id)
 }
 
+func (c *connection) Rooms() []string {
+	return c.server.ConnectionRooms(c.id)
+}
+
+func (c *connection) Set(key string, value interface{}) {
+	c.valuesMu.Lock()
+	if c.values == nil {
+		c.values = make(map[string]interface{})
+	}
+	c.values[key] = value
+	c.valuesMu.Unlock()
+}
+
+func (c *connection) Get(key string) interface{} {
+	c.valuesMu.RLock()
+	value := c.values[key]
+	c.valuesMu.RUnlock()
+	return value
+}
+
+func (c *connection) GetString(key string) string {
+	if s, ok := c.Get(key).(string); ok {
+		return s
+	}
+	return ""
+}
+
 func (c *connection) Disconnect() error {
+	if !c.server.isLocal(c.id) {
+		// it's removed from the server already, don't publish it to the Backplane.
+		return nil
+	}
 	return c.server.Disconnect(c.ID())
 }
@@ -0,0 +1,184 @@
+package websocket
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingConn is an UnderlineConnection which blocks the writes until the release is closed.
+type blockingConn struct {
+	UnderlineConnection
+	protocol  string
+	writing   chan string
+	release   chan struct{}
+	closeOnce sync.Once
+}
+
+func newBlockingConn(protocol string) *blockingConn {
+	return &blockingConn{protocol: protocol, writing: make(chan string, 10), release: make(chan struct{})}
+}
+
+func (c *blockingConn) Subprotocol() string {
+	return c.protocol
+}
+
+func (c *blockingConn) WriteMessage(messageType int, data []byte) error {
+	c.writing <- string(data)
+	<-c.release
+	return nil
+}
+
+func (c *blockingConn) Close() error {
+	c.closeOnce.Do(func() { close(c.release) })
+	return nil
+}
+
+func (c *blockingConn) expect(t *testing.T, expected string) {
+	select {
+	case got := <-c.writing:
+		if got != expected {
+			t.Fatalf("expected to write '%s' but got '%s'", expected, got)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("timeout while waiting to write '%s'", expected)
+	}
+}
+
+// newQueueConnection returns a connection with a send queue of two messages,
+// it writes the first message and it blocks.
+func newQueueConnection(t *testing.T, cfg Config, protocol string) (*server, *connection, *blockingConn) {
+	cfg.Endpoint = "/socket"
+	cfg.SendQueueSize = 2
+	s := New(cfg).(*server)
+	conn := newBlockingConn(protocol)
+	c := newConnection(s, nil, conn, "conn1")
+	c.pinger = time.NewTicker(time.Hour)
+	s.connections.add(c.id, c)
+	c.startWriter()
+
+	c.writeDefault([]byte("1"))
+	conn.expect(t, "1")
+	return s, c, conn
+}
+
+func TestSlowConsumerDropOldest(t *testing.T) {
+	s, c, conn := newQueueConnection(t, Config{SlowConsumerPolicy: SlowConsumerDropOldest}, "")
+	defer s.Disconnect(c.id)
+
+	for _, message := range []string{"2", "3", "4"} {
+		c.writeDefault([]byte(message))
+	}
+	if n := s.DroppedMessages(); n != 1 {
+		t.Fatalf("expected 1 dropped message but got %d", n)
+	}
+	if !s.IsConnected(c.id) {
+		t.Fatal("expected the slow consumer to be connected")
+	}
+
+	conn.Close()
+	conn.expect(t, "3")
+	conn.expect(t, "4")
+}
+
+func TestSlowConsumerDropNewest(t *testing.T) {
+	s, c, conn := newQueueConnection(t, Config{SlowConsumerPolicy: SlowConsumerDropNewest}, "")
+	defer s.Disconnect(c.id)
+
+	for _, message := range []string{"2", "3", "4"} {
+		c.writeDefault([]byte(message))
+	}
+	if n := s.DroppedMessages(); n != 1 {
+		t.Fatalf("expected 1 dropped message but got %d", n)
+	}
+	if !s.IsConnected(c.id) {
+		t.Fatal("expected the slow consumer to be connected")
+	}
+
+	conn.Close()
+	conn.expect(t, "2")
+	conn.expect(t, "3")
+}
+
+func TestSlowConsumerDisconnect(t *testing.T) {
+	s, c, conn := newQueueConnection(t, Config{SlowConsumerPolicy: SlowConsumerDisconnect}, "")
+	defer conn.Close()
+
+	disconnected := make(chan struct{})
+	c.OnDisconnect(func() {
+		close(disconnected)
+	})
+	for _, message := range []string{"2", "3", "4"} {
+		c.writeDefault([]byte(message))
+	}
+
+	select {
+	case <-disconnected:
+	case <-time.After(3 * time.Second):
+		t.Fatal("expected the slow consumer to be disconnected")
+	}
+	if s.IsConnected(c.id) {
+		t.Fatal("expected the slow consumer to be disconnected")
+	}
+	// the disconnects are not dropped messages, the connection is counted once.
+	if n := s.SlowConsumerDisconnects(); n != 1 {
+		t.Fatalf("expected 1 slow consumer disconnect but got %d", n)
+	}
+	if n := s.DroppedMessages(); n != 0 {
+		t.Fatalf("expected no dropped messages but got %d", n)
+	}
+}
+
+func TestSlowConsumerDefault(t *testing.T) {
+	s, c, conn := newQueueConnection(t, Config{}, "")
+	defer s.Disconnect(c.id)
+
+	for _, message := range []string{"2", "3", "4", "5"} {
+		c.writeDefault([]byte(message))
+	}
+	// the existing apps are not disconnected, the oldest messages are dropped.
+	if !s.IsConnected(c.id) {
+		t.Fatal("expected the slow consumer to be connected")
+	}
+	if n := s.DroppedMessages(); n != 2 {
+		t.Fatalf("expected 2 dropped messages but got %d", n)
+	}
+	if n := s.SlowConsumerDisconnects(); n != 0 {
+		t.Fatalf("expected no slow consumer disconnects but got %d", n)
+	}
+
+	conn.Close()
+	conn.expect(t, "4")
+	conn.expect(t, "5")
+}
+
+func TestBatchSubprotocol(t *testing.T) {
+	tests := []struct {
+		protocol string
+		acks     bool
+		batches  bool
+	}{
+		{"", false, false},
+		{SubprotocolV2, true, false},
+		{Subprotocol, true, true},
+	}
+
+	for _, tt := range tests {
+		s, c, conn := newQueueConnection(t, Config{BatchSize: 1024}, tt.protocol)
+		if c.acks != tt.acks || c.batches != tt.batches {
+			t.Fatalf("%q: expected the acks %v and the batches %v but got %v and %v",
+				tt.protocol, tt.acks, tt.batches, c.acks, c.batches)
+		}
+
+		c.writeDefault([]byte("2"))
+		c.writeDefault([]byte("3"))
+		conn.Close()
+		if tt.batches {
+			conn.expect(t, websocketBatchPrefix+"1;21;3")
+		} else {
+			conn.expect(t, "2")
+			conn.expect(t, "3")
+		}
+		s.Disconnect(c.id)
+	}
+}
@@ -1,13 +1,16 @@
 package websocket
 
 import (
+	"bytes"
 	"encoding/json"
 	"github.com/go-iris2/iris2/errors"
 	"github.com/valyala/bytebufferpool"
 	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 // -------------------------------------------------------------------------------------
@@ -153,6 +156,285 @@ func getWebsocketCustomEvent(websocketMessage string) string {
 	return evt
 }
 
+// SerializeMessage serializes the data of an event to a custom websocket message,
+// the same message which the server's Emit sends.
+// Supported data types are: string, int, bool, bytes and JSON.
+func SerializeMessage(event string, data interface{}) ([]byte, error) {
+	message, err := websocketMessageSerialize(event, data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(message), nil
+}
+
+// MessageEvent returns the event of a custom websocket message,
+// empty string if it's a native message.
+func MessageEvent(message []byte) string {
+	if !bytes.HasPrefix(message, websocketMessagePrefixBytes) ||
+		bytes.IndexByte(message[websocketMessagePrefixAndSepIdx:], websocketMessageSeparatorByte) < 0 {
+		return ""
+	}
+	return getWebsocketCustomEvent(string(message))
+}
+
+// DeserializeMessage returns the data of a custom websocket message of the event, see MessageEvent.
+func DeserializeMessage(event string, message []byte) (interface{}, error) {
+	return websocketMessageDeserialize(event, string(message))
+}
+
+var errMessageFunc = errors.New("websocket: the listener %T can't receive a message of type %T")
+
+// CallMessageFunc calls the callback of an event with the deserialized data,
+// the callback can be a func(), func(string), func(int), func(bool), func([]byte) or func(interface{}).
+// It returns an error, without calling the callback, if the callback can't receive the message's type.
+func CallMessageFunc(cb MessageFunc, message interface{}) error {
+	switch fn := cb.(type) {
+	case func(): // its a simple func(){} callback
+		fn()
+	case func(string):
+		switch msg := message.(type) {
+		case string:
+			fn(msg)
+		case int:
+			// here if server side waiting for string but client side sent an int, just convert this int to a string
+			fn(strconv.Itoa(msg))
+		default:
+			return errMessageFunc.Format(cb, message)
+		}
+	case func(int):
+		msg, ok := message.(int)
+		if !ok {
+			return errMessageFunc.Format(cb, message)
+		}
+		fn(msg)
+	case func(bool):
+		msg, ok := message.(bool)
+		if !ok {
+			return errMessageFunc.Format(cb, message)
+		}
+		fn(msg)
+	case func([]byte):
+		msg, ok := message.([]byte)
+		if !ok {
+			return errMessageFunc.Format(cb, message)
+		}
+		fn(msg)
+	case func(interface{}):
+		fn(message)
+	default:
+		return errMessageFunc.Format(cb, message)
+	}
+	return nil
+}
+
+// -------------------------------------------------------------------------------------
+// ---------------------------ack requests and their replies----------------------------
+// -------------------------------------------------------------------------------------
+
+// Subprotocol is the websocket subprotocol of the clients which support the acks and the batches,
+// see Connection.EmitWithAck and Config.BatchSize. The old clients don't ask for it, they keep the fire-and-forget messages.
+const Subprotocol = "iris-websocket.v3"
+
+// SubprotocolV2 is the websocket subprotocol of the clients which support the acks but not the batches.
+const SubprotocolV2 = "iris-websocket.v2"
+
+const (
+	// iris-websocket-ack:id;iris-websocket-message:event;type;data
+	websocketAckPrefix = "iris-websocket-ack:"
+	// iris-websocket-reply:id;iris-websocket-message:reply;type;data or
+	// iris-websocket-reply:id;iris-websocket-message:error;0;the error
+	websocketReplyPrefix = "iris-websocket-reply:"
+	websocketReplyEvent  = "reply"
+	websocketErrorEvent  = "error"
+)
+
+var (
+	websocketAckPrefixBytes   = []byte(websocketAckPrefix)
+	websocketReplyPrefixBytes = []byte(websocketReplyPrefix)
+)
+
+func serializeWithID(prefix string, id uint64, event string, data interface{}) ([]byte, error) {
+	message, err := websocketMessageSerialize(event, data)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(prefix + strconv.FormatUint(id, 10) + websocketMessageSeparator + message), nil
+}
+
+func parseWithID(prefix []byte, message []byte) (id uint64, custom []byte, ok bool) {
+	if !bytes.HasPrefix(message, prefix) {
+		return 0, nil, false
+	}
+	message = message[len(prefix):]
+	idx := bytes.IndexByte(message, websocketMessageSeparatorByte)
+	if idx < 0 {
+		return 0, nil, false
+	}
+	id, err := strconv.ParseUint(string(message[:idx]), 10, 64)
+	if err != nil {
+		return 0, nil, false
+	}
+	custom = message[idx+websocketMessageSeparatorLen:]
+	if MessageEvent(custom) == "" {
+		return 0, nil, false
+	}
+	return id, custom, true
+}
+
+// SerializeAck serializes an ack request, a custom message of the event which the receiver replies to by its id.
+func SerializeAck(id uint64, event string, data interface{}) ([]byte, error) {
+	return serializeWithID(websocketAckPrefix, id, event, data)
+}
+
+// ParseAck returns the id and the custom message of an ack request, ok is false if it's not an ack request.
+func ParseAck(message []byte) (id uint64, custom []byte, ok bool) {
+	return parseWithID(websocketAckPrefixBytes, message)
+}
+
+// SerializeReply serializes the reply to the ack request of the id, the replyErr is sent instead if it's not nil.
+func SerializeReply(id uint64, reply interface{}, replyErr error) ([]byte, error) {
+	if replyErr != nil {
+		return serializeWithID(websocketReplyPrefix, id, websocketErrorEvent, replyErr.Error())
+	}
+	if reply == nil {
+		reply = []byte{}
+	}
+	return serializeWithID(websocketReplyPrefix, id, websocketReplyEvent, reply)
+}
+
+// ParseReply returns the id of the ack request and the custom message of its reply, see DeserializeReply,
+// ok is false if it's not a reply.
+func ParseReply(message []byte) (id uint64, custom []byte, ok bool) {
+	return parseWithID(websocketReplyPrefixBytes, message)
+}
+
+// ReplyError is the error which the receiver of the ack request replied with.
+type ReplyError string
+
+func (e ReplyError) Error() string {
+	return string(e)
+}
+
+// DeserializeReply returns the data of the reply's custom message, see ParseReply,
+// or the ReplyError of the receiver.
+func DeserializeReply(custom []byte) (interface{}, error) {
+	event := MessageEvent(custom)
+	data, err := DeserializeMessage(event, custom)
+	if err != nil {
+		return nil, err
+	}
+	if event == websocketErrorEvent {
+		s, _ := data.(string)
+		return nil, ReplyError(s)
+	}
+	if b, ok := data.([]byte); ok && len(b) == 0 {
+		// the nil reply
+		return nil, nil
+	}
+	return data, nil
+}
+
+// -------------------------------------------------------------------------------------
+// ---------------------------batches of the queued messages----------------------------
+// -------------------------------------------------------------------------------------
+
+// iris-websocket-batch:length;message length;message, the length is in UTF-16 code units
+// as the javascript strings, see Config.BatchSize.
+const websocketBatchPrefix = "iris-websocket-batch:"
+
+var websocketBatchPrefixBytes = []byte(websocketBatchPrefix)
+
+// utf16Len returns the length of the text in UTF-16 code units.
+func utf16Len(text []byte) int {
+	n := 0
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+		text = text[size:]
+	}
+	return n
+}
+
+// batchMessages joins the messages to batches of up to maxSize bytes,
+// the messages which don't fit are kept as they are.
+func batchMessages(messages [][]byte, maxSize int) [][]byte {
+	var (
+		batches [][]byte
+		batch   []byte
+		count   int
+		first   []byte
+	)
+	flush := func() {
+		if count == 1 {
+			// not worth a batch
+			batches = append(batches, first)
+		} else if count > 1 {
+			batches = append(batches, batch)
+		}
+		batch, count, first = nil, 0, nil
+	}
+
+	for _, message := range messages {
+		length := strconv.Itoa(utf16Len(message))
+		size := len(length) + websocketMessageSeparatorLen + len(message)
+		if count > 0 && len(batch)+size > maxSize {
+			flush()
+		}
+		if count == 0 {
+			batch = append(batch, websocketBatchPrefix...)
+			first = message
+		}
+		batch = append(batch, length...)
+		batch = append(batch, websocketMessageSeparator...)
+		batch = append(batch, message...)
+		count++
+	}
+	flush()
+	return batches
+}
+
+// SplitBatch returns the messages of a batch, ok is false if it's not a batch.
+// The server sends batches only to the clients which ask for the Subprotocol, see Config.BatchSize.
+func SplitBatch(message []byte) (messages [][]byte, ok bool) {
+	if !bytes.HasPrefix(message, websocketBatchPrefixBytes) {
+		return nil, false
+	}
+	message = message[len(websocketBatchPrefixBytes):]
+	for len(message) > 0 {
+		idx := bytes.IndexByte(message, websocketMessageSeparatorByte)
+		if idx < 0 {
+			return nil, false
+		}
+		length, err := strconv.Atoi(string(message[:idx]))
+		if err != nil || length < 0 {
+			return nil, false
+		}
+		message = message[idx+websocketMessageSeparatorLen:]
+
+		// the byte size of length UTF-16 code units
+		size := 0
+		for units := 0; units < length; {
+			if size >= len(message) {
+				return nil, false
+			}
+			r, n := utf8.DecodeRune(message[size:])
+			if r >= 0x10000 {
+				units += 2
+			} else {
+				units++
+			}
+			size += n
+		}
+		messages = append(messages, message[:size])
+		message = message[size:]
+	}
+	return messages, true
+}
+
 const (
 	letterBytes   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	letterIdxBits = 6                    // 6 bits to represent a letter index
@@ -160,12 +442,18 @@ const (
 	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
 )
 
-var src = rand.NewSource(time.Now().UnixNano())
+var (
+	src = rand.NewSource(time.Now().UnixNano())
+	// the source is not safe for concurrent use, the connections' IDs are generated by many goroutines.
+	srcMu sync.Mutex
+)
 
 // random takes a parameter (int) and returns random slice of byte
 // ex: var randomstrbytes []byte; randomstrbytes = utils.Random(32)
 func random(n int) []byte {
 	b := make([]byte, n)
+	srcMu.Lock()
+	defer srcMu.Unlock()
 	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
 	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
 		if remain == 0 {
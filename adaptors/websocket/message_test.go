@@ -0,0 +1,63 @@
+package websocket
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBatchMessages(t *testing.T) {
+	messages := []string{
+		websocketMessagePrefix + "chat;0;héllo",
+		websocketMessagePrefix + "chat;0;🌍 𝄞 and the ;separator;",
+		"a native message",
+		"😀",
+		"",
+	}
+	raw := make([][]byte, len(messages))
+	for i, message := range messages {
+		raw[i] = []byte(message)
+	}
+
+	for _, maxSize := range []int{1, 64, 1024} {
+		var got []string
+		for _, batch := range batchMessages(raw, maxSize) {
+			split, ok := SplitBatch(batch)
+			if !ok {
+				// not worth a batch
+				got = append(got, string(batch))
+				continue
+			}
+			for _, message := range split {
+				got = append(got, string(message))
+			}
+		}
+
+		if strings.Join(got, "|") != strings.Join(messages, "|") {
+			t.Fatalf("max size %d: expected the messages %q but got %q", maxSize, messages, got)
+		}
+	}
+}
+
+func TestBatchLength(t *testing.T) {
+	// the length is in UTF-16 code units, as the javascript's String.length.
+	batches := batchMessages([][]byte{[]byte("😀"), []byte("é")}, 1024)
+	if len(batches) != 1 {
+		t.Fatalf("expected 1 batch but got %d", len(batches))
+	}
+	if expected := websocketBatchPrefix + "2;😀1;é"; string(batches[0]) != expected {
+		t.Fatalf("expected the batch '%s' but got '%s'", expected, batches[0])
+	}
+}
+
+func TestSplitBatchInvalid(t *testing.T) {
+	for _, message := range []string{
+		"not a batch",
+		websocketBatchPrefix + "3;😀",
+		websocketBatchPrefix + "x;a",
+		websocketBatchPrefix + "1a",
+	} {
+		if _, ok := SplitBatch([]byte(message)); ok {
+			t.Fatalf("expected the '%s' to be an invalid batch", message)
+		}
+	}
+}
@@ -2,7 +2,9 @@ package websocket
 
 import (
 	"net/http"
+	"sort"
 	"sync"
+	"sync/atomic"
 
 	"github.com/go-iris2/iris2"
 	"github.com/gorilla/websocket"
@@ -36,6 +38,9 @@ type Server interface {
 	// and you want to check if that connection is already connected (on multiple tabs)
 	IsConnected(connID string) bool
 
+	// GetConnection returns the connection of this server with that ID, nil if it's not connected to this server
+	GetConnection(connID string) Connection
+
 	// Join joins a websocket client to a room,
 	// first parameter is the room name and the second the connection.ID()
 	//
@@ -51,6 +56,28 @@ type Server interface {
 	// You can use connection.Leave("room name") instead.
 	Leave(roomName string, connID string)
 
+	/*
+	   rooms of the connections of this server,
+	    the rooms which are named after a connection ID (each connection joins its own) are excluded.
+	    With a Config.Backplane they see only the local connections,
+	    the presence of the other servers' connections is not shared.
+	*/
+
+	// Rooms returns the names of the rooms which have at least one connection, sorted
+	Rooms() []string
+	// RoomMembers returns the IDs of the connections which joined the room, in the order they joined
+	RoomMembers(roomName string) []string
+	// ConnectionRooms returns the names of the rooms which the connection joined, sorted
+	ConnectionRooms(connID string) []string
+	// OnJoin registers a callback which fires when a connection joins a room,
+	// after the join, useful for presence indicators.
+	// With a Config.Backplane it fires for the connections of this server only
+	OnJoin(cb RoomFunc)
+	// OnLeave registers a callback which fires when a connection leaves a room,
+	// on Leave, LeaveAll and on disconnect, before the OnDisconnect callbacks.
+	// With a Config.Backplane it fires for the connections of this server only
+	OnLeave(cb RoomFunc)
+
 	// Disconnect force-disconnects a websocket connection
 	// based on its connection.ID()
 	// What it does?
@@ -61,6 +88,16 @@ type Server interface {
 	//
 	// You can use the connection.Disconnect() instead.
 	Disconnect(connID string) error
+
+	// QueueDepth returns the number of the messages which wait to be written to the clients,
+	// see Config.SendQueueSize.
+	QueueDepth() int64
+	// DroppedMessages returns the number of the messages which dropped because of the full send queues,
+	// see Config.SlowConsumerPolicy.
+	DroppedMessages() uint64
+	// SlowConsumerDisconnects returns the number of the connections which disconnected because of their full send queue,
+	// see SlowConsumerDisconnect.
+	SlowConsumerDisconnects() uint64
 }
 
 // -------------------------------------------------------------------------------------
@@ -149,6 +186,10 @@ type (
 	// Receives one parameter which is the Connection
 	ConnectionFunc func(Connection)
 
+	// RoomFunc is the callback which fires when a connection joins or leaves a room,
+	// see OnJoin and OnLeave. Receives the room's name and the connection's ID
+	RoomFunc func(roomName string, connID string)
+
 	// websocketRoomPayload is used as payload from the connection to the server
 	websocketRoomPayload struct {
 		roomName     string
@@ -163,11 +204,28 @@ type (
 	}
 
 	server struct {
+		// seq is the sequence of the published messages, see Backplane.
+		seq uint64
+		// queued is the number of the messages in the send queues, dropped the dropped ones
+		// and slowDisconnects the connections which disconnected because of their full send queue.
+		queued                int64
+		dropped               uint64
+		slowDisconnects       uint64
+		id                    string
 		config                Config
 		connections           connections
+		connectionsMu         sync.RWMutex        // for connections
 		rooms                 map[string][]string // by default a connection is joined to a room which has the connection id as its name
 		mu                    sync.Mutex          // for rooms
 		onConnectionListeners []ConnectionFunc
+		onJoinListeners       []RoomFunc
+		onLeaveListeners      []RoomFunc
+		// seen are the latest messages of the backplane.
+		seen seenMessages
+		// closed is closed when the Framework is closing, it stops the retries of the backplane's Subscribe.
+		closed    chan struct{}
+		closeOnce sync.Once
+		log       func(format string, a ...interface{})
 		//connectionPool        *sync.Pool // sadly I can't make this because the websocket connection is live until is closed.
 	}
 )
@@ -190,6 +248,18 @@ func (s *server) Handler() iris2.HandlerFunc {
 		//
 		// If the upgrade fails, then Upgrade replies to the client with an HTTP error
 		// response.
+		//
+		// The clients which ask for the Subprotocol support the acks and the batches,
+		// the ones which ask for the SubprotocolV2 support the acks,
+		// unless the application negotiated its own subprotocol.
+		if ctx.ResponseWriter.Header().Get("Sec-Websocket-Protocol") == "" {
+			for _, protocol := range websocket.Subprotocols(ctx.Request) {
+				if protocol == Subprotocol || protocol == SubprotocolV2 {
+					ctx.ResponseWriter.Header().Set("Sec-Websocket-Protocol", protocol)
+					break
+				}
+			}
+		}
 		conn, err := upgrader.Upgrade(ctx.ResponseWriter, ctx.Request, ctx.ResponseWriter.Header())
 		if err != nil {
 			ctx.Log("websocket error: %v", err)
@@ -206,8 +276,13 @@ func (s *server) handleConnection(ctx *iris2.Context, websocketConn UnderlineCon
 	cid := s.config.IDGenerator(ctx)
 	// create the new connection
 	c := newConnection(s, ctx, websocketConn, cid)
+	// start the writer of the queued messages, the listeners may send messages
+	c.startWriter()
 	// add the connection to the server's list
+	s.connectionsMu.Lock()
 	s.connections.add(cid, c)
+	s.connectionsMu.Unlock()
+	s.setConnected(cid, true)
 
 	// join to itself
 	s.Join(c.ID(), c.ID())
@@ -251,11 +326,40 @@ func (s *server) OnConnection(cb ConnectionFunc) {
 	s.onConnectionListeners = append(s.onConnectionListeners, cb)
 }
 
-// IsConnected returns true if the connection with that ID is connected to the server
+// IsConnected returns true if the connection with that ID is connected to the server,
+// or to one of the servers of the Backplane,
 // useful when you have defined a custom connection id generator (based on a database)
 // and you want to check if that connection is already connected (on multiple tabs)
 func (s *server) IsConnected(connID string) bool {
+	if s.isLocal(connID) {
+		return true
+	}
+	if s.config.Backplane != nil {
+		connected, err := s.config.Backplane.IsConnected(connID)
+		if err != nil {
+			s.log("websocket backplane: %s", err)
+		}
+		return connected
+	}
+	return false
+}
+
+// GetConnection returns the connection of this server with that ID, nil if it's not connected to this server
+func (s *server) GetConnection(connID string) Connection {
+	s.connectionsMu.RLock()
+	c := s.connections.get(connID)
+	s.connectionsMu.RUnlock()
+	if c == nil {
+		return nil
+	}
+	return c
+}
+
+// isLocal reports whether the connection is connected to this server.
+func (s *server) isLocal(connID string) bool {
+	s.connectionsMu.RLock()
 	c := s.connections.get(connID)
+	s.connectionsMu.RUnlock()
 	return c != nil
 }
 
@@ -264,32 +368,61 @@ func (s *server) IsConnected(connID string) bool {
 //
 // You can use connection.Join("room name") instead.
 func (s *server) Join(roomName string, connID string) {
+	if s.config.Backplane != nil && !s.isLocal(connID) {
+		s.publish(backplaneEnvelope{Op: backplaneJoin, Room: roomName, ConnID: connID})
+		return
+	}
 	s.mu.Lock()
-	s.join(roomName, connID)
+	joined := s.join(roomName, connID)
 	s.mu.Unlock()
+	if joined {
+		s.fireRoom(s.onJoinListeners, roomName, connID)
+	}
 }
 
-// join used internally, no locks used.
-func (s *server) join(roomName string, connID string) {
-	if s.rooms[roomName] == nil {
-		s.rooms[roomName] = make([]string, 0)
+// join used internally, no locks used,
+// returns false if the connection is inside the room already.
+func (s *server) join(roomName string, connID string) bool {
+	for _, id := range s.rooms[roomName] {
+		if id == connID {
+			return false
+		}
 	}
 	s.rooms[roomName] = append(s.rooms[roomName], connID)
+	return true
+}
+
+// fireRoom fires the OnJoin or OnLeave callbacks, not for the connection's own room.
+func (s *server) fireRoom(listeners []RoomFunc, roomName string, connID string) {
+	if roomName == connID {
+		return
+	}
+	for i := range listeners {
+		listeners[i](roomName, connID)
+	}
 }
 
 // LeaveAll kicks out a connection from ALL of its joined rooms
 func (s *server) LeaveAll(connID string) {
+	if s.config.Backplane != nil && !s.isLocal(connID) {
+		s.publish(backplaneEnvelope{Op: backplaneLeaveAll, ConnID: connID})
+		return
+	}
+	s.leaveAll(connID)
+}
+
+// leaveAll kicks out a connection of this server from ALL of its joined rooms
+func (s *server) leaveAll(connID string) {
 	s.mu.Lock()
-	for name, connectionIDs := range s.rooms {
-		for i := range connectionIDs {
-			if connectionIDs[i] == connID {
-				// the connection is inside this room, lets remove it
-				s.rooms[name][i] = s.rooms[name][len(s.rooms[name])-1]
-				s.rooms[name] = s.rooms[name][:len(s.rooms[name])-1]
-			}
-		}
+	left := s.connectionRooms(connID)
+	for _, name := range left {
+		s.leave(name, connID)
 	}
 	s.mu.Unlock()
+
+	for _, name := range left {
+		s.fireRoom(s.onLeaveListeners, name, connID)
+	}
 }
 
 // Leave leaves a websocket client from a room,
@@ -297,20 +430,29 @@ func (s *server) LeaveAll(connID string) {
 //
 // You can use connection.Leave("room name") instead.
 func (s *server) Leave(roomName string, connID string) {
+	if s.config.Backplane != nil && !s.isLocal(connID) {
+		s.publish(backplaneEnvelope{Op: backplaneLeave, Room: roomName, ConnID: connID})
+		return
+	}
 	s.mu.Lock()
-	s.leave(roomName, connID)
+	left := s.leave(roomName, connID)
 	s.mu.Unlock()
+	if left {
+		s.fireRoom(s.onLeaveListeners, roomName, connID)
+	}
 }
 
-// leave used internally, no locks used.
-func (s *server) leave(roomName string, connID string) {
+// leave used internally, no locks used,
+// returns false if the connection is not inside the room.
+func (s *server) leave(roomName string, connID string) (left bool) {
 	///THINK: we could add locks to its room but we still use the lock for the whole rooms or we can just do what we do with connections
 	// I will think about it on the next revision, so far we use the locks only for rooms so we are ok...
 	if s.rooms[roomName] != nil {
 		for i := range s.rooms[roomName] {
 			if s.rooms[roomName][i] == connID {
-				s.rooms[roomName][i] = s.rooms[roomName][len(s.rooms[roomName])-1]
-				s.rooms[roomName] = s.rooms[roomName][:len(s.rooms[roomName])-1]
+				// keep the order of the members
+				s.rooms[roomName] = append(s.rooms[roomName][:i], s.rooms[roomName][i+1:]...)
+				left = true
 				break
 			}
 		}
@@ -318,6 +460,78 @@ func (s *server) leave(roomName string, connID string) {
 			delete(s.rooms, roomName)
 		}
 	}
+	return
+}
+
+// Rooms returns the names of the rooms which have at least one connection, sorted
+func (s *server) Rooms() []string {
+	s.mu.Lock()
+	rooms := make([]string, 0, len(s.rooms))
+	for name, connectionIDs := range s.rooms {
+		if !isOwnRoom(name, connectionIDs) {
+			rooms = append(rooms, name)
+		}
+	}
+	s.mu.Unlock()
+	sort.Strings(rooms)
+	return rooms
+}
+
+// isOwnRoom reports whether the room is named after one of its connections.
+func isOwnRoom(roomName string, connectionIDs []string) bool {
+	for _, id := range connectionIDs {
+		if id == roomName {
+			return true
+		}
+	}
+	return false
+}
+
+// RoomMembers returns the IDs of the connections which joined the room, in the order they joined
+func (s *server) RoomMembers(roomName string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	connectionIDs := s.rooms[roomName]
+	if isOwnRoom(roomName, connectionIDs) {
+		return nil
+	}
+	return append([]string(nil), connectionIDs...)
+}
+
+// ConnectionRooms returns the names of the rooms which the connection joined, sorted
+func (s *server) ConnectionRooms(connID string) []string {
+	s.mu.Lock()
+	rooms := s.connectionRooms(connID)
+	s.mu.Unlock()
+	return rooms
+}
+
+// connectionRooms used internally, no locks used.
+func (s *server) connectionRooms(connID string) []string {
+	var rooms []string
+	for name, connectionIDs := range s.rooms {
+		if name == connID {
+			continue
+		}
+		for _, id := range connectionIDs {
+			if id == connID {
+				rooms = append(rooms, name)
+				break
+			}
+		}
+	}
+	sort.Strings(rooms)
+	return rooms
+}
+
+// OnJoin registers a callback which fires when a connection joins a room
+func (s *server) OnJoin(cb RoomFunc) {
+	s.onJoinListeners = append(s.onJoinListeners, cb)
+}
+
+// OnLeave registers a callback which fires when a connection leaves a room
+func (s *server) OnLeave(cb RoomFunc) {
+	s.onLeaveListeners = append(s.onLeaveListeners, cb)
 }
 
 // emitMessage is the main 'router' of the messages coming from the connection
@@ -329,36 +543,48 @@ func (s *server) leave(roomName string, connID string) {
 //
 // You SHOULD use connection.EmitMessage/Emit/To().Emit/EmitMessage instead.
 // let's keep it unexported for the best.
+//
+// The messages which are not sent to a connection of this server are published to the Backplane, if any.
 func (s *server) emitMessage(from, to string, data []byte) {
+	s.emitLocalMessage(from, to, data)
+	if s.config.Backplane != nil && !s.isLocal(to) {
+		s.publish(backplaneEnvelope{Op: backplaneMessage, From: from, To: to, Data: data})
+	}
+}
+
+// emitLocalMessage sends the message to the connections of this server.
+//
+// The writeDefault only queues the message, a slow client doesn't block the loop.
+func (s *server) emitLocalMessage(from, to string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connectionsMu.RLock()
+	defer s.connectionsMu.RUnlock()
+
 	if to != All && to != Broadcast && s.rooms[to] != nil {
+		var disconnected []string
 		// it suppose to send the message to a specific room/or a user inside its own room
 		for _, connectionIDInsideRoom := range s.rooms[to] {
 			if c := s.connections.get(connectionIDInsideRoom); c != nil {
 				c.writeDefault(data) //send the message to the client(s)
 			} else {
 				// the connection is not connected but it's inside the room, we remove it on disconnect but for ANY CASE:
-				cid := connectionIDInsideRoom
-				if c != nil {
-					cid = c.id
-				}
-				s.Leave(cid, to)
+				disconnected = append(disconnected, connectionIDInsideRoom)
 			}
 		}
-	} else {
+		for _, cid := range disconnected {
+			s.leave(to, cid)
+		}
+	} else if to == All || to == Broadcast {
 		// it suppose to send the message to all opened connections or to all except the sender
 		for _, cKV := range s.connections {
-			connID := cKV.key
-			if to != All && to != connID { // if it's not suppose to send to all connections (including itself)
-				if to == Broadcast && from == connID { // if broadcast to other connections except this
-					continue //here we do the opossite of previous block,
-					// just skip this connection when it's suppose to send the message to all connections except the sender
-				}
-
+			if to == Broadcast && from == cKV.key { // if broadcast to other connections except this
+				continue // just skip this connection when it's suppose to send the message to all connections except the sender
 			}
-			// send to the client(s) when the top validators passed
 			cKV.value.writeDefault(data)
 		}
 	}
+	// else the room has no connections on this server, i.e a room of the other servers of the Backplane.
 }
 
 // Disconnect force-disconnects a websocket connection based on its connection.ID()
@@ -369,17 +595,34 @@ func (s *server) emitMessage(from, to string, data []byte) {
 // 4. close the underline connection and return its error, if any.
 //
 // You can use the connection.Disconnect() instead.
+//
+// The connections of the other servers of the Backplane are disconnected by their server.
 func (s *server) Disconnect(connID string) (err error) {
+	if s.config.Backplane != nil && !s.isLocal(connID) {
+		s.publish(backplaneEnvelope{Op: backplaneDisconnect, ConnID: connID})
+		return
+	}
 	// remove the connection from the list
-	if c, ok := s.connections.remove(connID); ok {
+	s.connectionsMu.Lock()
+	c, ok := s.connections.remove(connID)
+	s.connectionsMu.Unlock()
+	if ok {
 		if !c.disconnected {
 			c.disconnected = true
+			s.setConnected(connID, false)
 			// stop the ping timer
 			c.pinger.Stop()
-			// leave from all joined rooms
-			s.LeaveAll(connID)
+			// fail the ack requests which wait for a reply
+			c.cancelAcks()
+			// leave from all joined rooms, then from its own room, without the OnLeave
+			s.leaveAll(connID)
+			s.mu.Lock()
+			s.leave(connID, connID)
+			s.mu.Unlock()
 			// fire the disconnect callbacks, if any
 			c.fireDisconnect()
+			// write the queued messages, then
+			c.stopWriter()
 			// close the underline connection and return its error, if any.
 			err = c.underline.Close()
 		}
@@ -387,3 +630,18 @@ func (s *server) Disconnect(connID string) (err error) {
 
 	return
 }
+
+// QueueDepth returns the number of the messages which wait to be written to the clients
+func (s *server) QueueDepth() int64 {
+	return atomic.LoadInt64(&s.queued)
+}
+
+// DroppedMessages returns the number of the messages which dropped because of the full send queues
+func (s *server) DroppedMessages() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// SlowConsumerDisconnects returns the number of the connections which disconnected because of their full send queue
+func (s *server) SlowConsumerDisconnects() uint64 {
+	return atomic.LoadUint64(&s.slowDisconnects)
+}
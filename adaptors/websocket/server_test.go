@@ -0,0 +1,163 @@
+package websocket
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/gorilla/websocket"
+)
+
+func TestServerDisconnectRooms(t *testing.T) {
+	app := iris2.New()
+	ws := New(Config{Endpoint: "/socket"})
+	app.Adapt(ws)
+	s := ws.(*server)
+
+	joined := make(chan string, 1)
+	disconnected := make(chan string, 1)
+	var left []string
+	ws.OnConnection(func(c Connection) {
+		c.Join("room1")
+		// the reader is started after the OnConnection.
+		c.On("ready", func() {
+			joined <- c.ID()
+		})
+		c.OnDisconnect(func() {
+			disconnected <- c.ID()
+		})
+	})
+	ws.OnLeave(func(roomName string, connID string) {
+		left = append(left, roomName)
+	})
+	app.Boot()
+	srv := httptest.NewServer(app.Router)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/socket", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ready, err := SerializeMessage("ready", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, ready); err != nil {
+		t.Fatal(err)
+	}
+
+	var connID string
+	select {
+	case connID = <-joined:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for the connection")
+	}
+
+	s.mu.Lock()
+	n := len(s.rooms)
+	s.mu.Unlock()
+	if n != 2 {
+		t.Fatalf("expected the room1 and the own room of the connection but got %d rooms", n)
+	}
+
+	ws.Disconnect(connID)
+	select {
+	case <-disconnected:
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout while waiting for the disconnect")
+	}
+
+	s.mu.Lock()
+	n = len(s.rooms)
+	s.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("expected no rooms after the disconnect but got %d", n)
+	}
+	// the own room of the connection is not a room of the OnLeave.
+	if strings.Join(left, ",") != "room1" {
+		t.Fatalf("expected to leave only the room1 but left %v", left)
+	}
+}
+
+func TestServerPresence(t *testing.T) {
+	s := New(Config{Endpoint: "/socket"}).(*server)
+	var joins []string
+	s.OnJoin(func(roomName string, connID string) {
+		joins = append(joins, roomName+":"+connID)
+	})
+
+	// the own rooms of the connections.
+	s.Join("conn2", "conn2")
+	s.Join("conn1", "conn1")
+
+	s.Join("room1", "conn2")
+	s.Join("room1", "conn1")
+	s.Join("room1", "conn2")
+	s.Join("b", "conn1")
+	s.Join("a", "conn1")
+
+	if got := strings.Join(s.RoomMembers("room1"), ","); got != "conn2,conn1" {
+		t.Fatalf("expected the members in the order they joined but got %s", got)
+	}
+	if got := strings.Join(s.ConnectionRooms("conn1"), ","); got != "a,b,room1" {
+		t.Fatalf("expected the sorted rooms of the connection, without its own, but got %s", got)
+	}
+	if got := strings.Join(s.Rooms(), ","); got != "a,b,room1" {
+		t.Fatalf("expected the sorted rooms, without the own rooms, but got %s", got)
+	}
+	if members := s.RoomMembers("conn1"); members != nil {
+		t.Fatalf("expected no members of an own room but got %v", members)
+	}
+	// once per join, not for the own rooms.
+	if got := strings.Join(joins, ","); got != "room1:conn2,room1:conn1,b:conn1,a:conn1" {
+		t.Fatalf("expected one OnJoin per join but got %s", got)
+	}
+
+	s.Leave("room1", "conn2")
+	if got := strings.Join(s.RoomMembers("room1"), ","); got != "conn1" {
+		t.Fatalf("expected the members after the leave but got %s", got)
+	}
+	s.Leave("room1", "conn1")
+	if got := strings.Join(s.Rooms(), ","); got != "a,b" {
+		t.Fatalf("expected the empty room to be removed but got %s", got)
+	}
+}
+
+func TestConnectionValues(t *testing.T) {
+	s := New(Config{Endpoint: "/socket"}).(*server)
+	c := newConnection(s, nil, newBlockingConn(""), "conn1")
+
+	if c.Get("name") != nil || c.GetString("name") != "" {
+		t.Fatal("expected no value before the Set")
+	}
+	c.Set("count", 1)
+	if c.GetString("count") != "" {
+		t.Fatal("expected an empty string for a value which is not a string")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key%d", i)
+			for j := 0; j < 100; j++ {
+				c.Set(key, fmt.Sprintf("value%d", j))
+				c.Get("count")
+				c.GetString(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 8; i++ {
+		if got := c.GetString(fmt.Sprintf("key%d", i)); got != "value99" {
+			t.Fatalf("expected the last value of the key%d but got '%s'", i, got)
+		}
+	}
+}
@@ -3,6 +3,7 @@
 package websocket
 
 import (
+	"context"
 	"strings"
 
 	"github.com/go-iris2/iris2"
@@ -11,9 +12,12 @@ import (
 // New returns a new websocket server policy adaptor.
 func New(cfg Config) Server {
 	return &server{
-		config: cfg.Validate(),
-		rooms:  make(map[string][]string, 0),
+		config:                cfg.Validate(),
+		rooms:                 make(map[string][]string, 0),
 		onConnectionListeners: make([]ConnectionFunc, 0),
+		id:                    randomString(16),
+		closed:                make(chan struct{}),
+		log:                   func(string, ...interface{}) {},
 	}
 }
 
@@ -47,6 +51,15 @@ func (s *server) Adapt(frame *iris2.Policies) {
 				return
 			}
 
+			s.log = f.Log
+			if s.config.Backplane != nil {
+				s.subscribe()
+				f.OnShutdown("websocket backplane", 0, func(context.Context) error {
+					s.close()
+					return nil
+				})
+			}
+
 			// set the routing for client-side source (javascript) (optional)
 			clientSideLookupName := "iris-websocket-client-side"
 			wsHandler := s.Handler()
@@ -2,6 +2,7 @@ package iris2
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"net/http"
 	"os"
@@ -34,6 +35,75 @@ func TCPKeepAlive(addr string) (net.Listener, error) {
 	return TCPKeepAliveListener{ln.(*net.TCPListener)}, err
 }
 
+// UNIX returns a new unix(file) Listener
+func UNIX(socketFile string, mode os.FileMode) (net.Listener, error) {
+	if errOs := os.Remove(socketFile); errOs != nil && !os.IsNotExist(errOs) {
+		return nil, errRemoveUnix.Format(socketFile, errOs.Error())
+	}
+
+	ln, err := net.Listen("unix", socketFile)
+	if err != nil {
+		return nil, errPortAlreadyUsed.AppendErr(err)
+	}
+
+	if err = os.Chmod(socketFile, mode); err != nil {
+		return nil, errChmod.Format(mode, socketFile, err.Error())
+	}
+
+	return ln, nil
+}
+
+// TLS returns a new TLS Listener
+func TLS(addr, certFile, keyFile string) (net.Listener, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, errCertKeyMissing
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errParseTLS.Format(certFile, keyFile, err)
+	}
+
+	return CERT(addr, cert)
+}
+
+// CERT returns a listener which contans tls.Config with the provided certificate, use for ssl
+func CERT(addr string, cert tls.Certificate) (net.Listener, error) {
+	return tlsListener(addr, &tls.Config{Certificates: []tls.Certificate{cert}})
+}
+
+// CertManager is the interface which an ACME (i.e Let's Encrypt) certificate manager should implement
+// in order to be used by the AutoTLS listener and the Framework.ListenAutoTLS,
+// the golang.org/x/crypto/acme/autocert.Manager is a valid CertManager,
+// its Cache field (i.e autocert.DirCache) is where the certificates are stored.
+type CertManager interface {
+	// GetCertificate returns the certificate for the client hello's server name,
+	// it obtains or renews it, if needed.
+	GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error)
+	// HTTPHandler returns a handler which answers the ACME "http-01" challenges
+	// and passes the rest of the requests to the fallback,
+	// a nil fallback should redirect the requests to https.
+	HTTPHandler(fallback http.Handler) http.Handler
+}
+
+// AutoTLS returns a new TLS Listener which its certificates are
+// obtained and renewed automatically by the ACME certificate manager.
+func AutoTLS(addr string, manager CertManager) (net.Listener, error) {
+	return tlsListener(addr, &tls.Config{GetCertificate: manager.GetCertificate})
+}
+
+// tlsListener returns a new tcp4 keep alive listener, wrapped with the tls config,
+// which prefers HTTP/2.
+func tlsListener(addr string, tlsConfig *tls.Config) (net.Listener, error) {
+	ln, err := TCPKeepAlive(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	return tls.NewListener(ln, tlsConfig), nil
+}
+
 // TCPKeepAliveListener sets TCP keep-alive timeouts on accepted
 // connections.
 // Dead TCP connections (e.g. closing laptop mid-download) eventually
@@ -2,7 +2,22 @@
 package iris2_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-iris2/iris2"
 )
@@ -84,3 +99,195 @@ func TestParseAddr(t *testing.T) {
 		t.Fatalf("Expecting server 4's hostname to be %s but we got %s", expectedScheme4, scheme4)
 	}
 }
+
+// testCertificate generates a self-signed certificate for the 127.0.0.1 and localhost.
+func testCertificate(t *testing.T) (certPEM []byte, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"iris2"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+func testInsecureClient() *http.Client {
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+}
+
+func testGet(t *testing.T, client *http.Client, url string, expectedBody string) {
+	var (
+		resp *http.Response
+		err  error
+	)
+	// wait for the server to start
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get(url); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if string(body) != expectedBody {
+		t.Fatalf("expected body of %s to be %q but got %q", url, expectedBody, string(body))
+	}
+}
+
+func testFreeAddr(t *testing.T) string {
+	ln, err := iris2.TCP4("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestServeMultipleListeners(t *testing.T) {
+	dir, err := ioutil.TempDir("", "iris2-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	certPEM, keyPEM := testCertificate(t)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err = ioutil.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	app := iris2.New(iris2.OptionDisableInterruptHandler(true))
+	app.Get("/", func(ctx *iris2.Context) {
+		scheme := "http"
+		if ctx.Request.TLS != nil {
+			scheme = "https"
+		}
+		ctx.WriteString(scheme)
+	})
+
+	httpLn, err := iris2.TCP4("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpsLn, err := iris2.TLS("127.0.0.1:0", certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	socketFile := filepath.Join(dir, "iris2.sock")
+	unixLn, err := iris2.UNIX(socketFile, 0666)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serveErrs := make(chan error, 3)
+	for _, ln := range []net.Listener{httpLn, httpsLn, unixLn} {
+		go func(ln net.Listener) { serveErrs <- app.Serve(ln) }(ln)
+	}
+
+	testGet(t, http.DefaultClient, "http://"+httpLn.Addr().String()+"/", "http")
+	testGet(t, testInsecureClient(), "https://"+httpsLn.Addr().String()+"/", "https")
+	unixClient := &http.Client{Transport: &http.Transport{
+		Dial: func(string, string) (net.Conn, error) {
+			return net.Dial("unix", socketFile)
+		},
+	}}
+	testGet(t, unixClient, "http://unix/", "http")
+
+	if err = app.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-serveErrs; err != http.ErrServerClosed {
+			t.Fatalf("expected all servers to be closed but got: %v", err)
+		}
+	}
+
+	if _, err = iris2.TLS("127.0.0.1:0", "", ""); err == nil {
+		t.Fatalf("expected an error for missing certificate and key files")
+	}
+}
+
+// testCertManager is a local ACME stand-in.
+type testCertManager struct {
+	cert  tls.Certificate
+	hosts chan string
+}
+
+func (m *testCertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.hosts <- hello.ServerName
+	return &m.cert, nil
+}
+
+func (m *testCertManager) HTTPHandler(fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/") {
+			w.Write([]byte("token.thumbprint"))
+			return
+		}
+		if fallback != nil {
+			fallback.ServeHTTP(w, r)
+			return
+		}
+		http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), http.StatusFound)
+	})
+}
+
+func TestListenAutoTLS(t *testing.T) {
+	certPEM, keyPEM := testCertificate(t)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager := &testCertManager{cert: cert, hosts: make(chan string, 10)}
+
+	app := iris2.New(iris2.OptionDisableInterruptHandler(true))
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.WriteString("secure")
+	})
+
+	addr, challengeAddr := testFreeAddr(t), testFreeAddr(t)
+	go app.ListenAutoTLS(addr, manager, challengeAddr)
+
+	port := addr[strings.LastIndexByte(addr, ':'):]
+	testGet(t, testInsecureClient(), "https://localhost"+port+"/", "secure")
+	if host := <-manager.hosts; host != "localhost" {
+		t.Fatalf("expected the certificate to be requested for localhost but got %q", host)
+	}
+	testGet(t, http.DefaultClient, "http://"+challengeAddr+"/.well-known/acme-challenge/token", "token.thumbprint")
+
+	if app.Config.VScheme != iris2.SchemeHTTPS {
+		t.Fatalf("expected scheme %s but got %s", iris2.SchemeHTTPS, app.Config.VScheme)
+	}
+
+	if err = app.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,429 @@
+package iris2
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+)
+
+// EncoderWriter is the compressor of an Encoder, it's reused by its Reset.
+type EncoderWriter interface {
+	io.WriteCloser
+	// Flush writes the pending compressed data to the underline writer.
+	Flush() error
+	// Reset discards the state of the compressor and makes it write to the w.
+	Reset(w io.Writer)
+}
+
+// Encoder is a content-coding of the responses, i.e "gzip", see RegisterEncoder.
+type Encoder struct {
+	// Name is the content-coding of the Accept-Encoding and Content-Encoding headers, i.e "br"
+	Name      string
+	newWriter func(w io.Writer, level int) (EncoderWriter, error)
+	// pools are the reusable compressors, by level.
+	pools sync.Map
+}
+
+// EncodingPreference is the order of the encodings, the server's preference,
+// when the client accepts more than one of them with the same quality value.
+// The registered encodings which are not listed are the least preferred.
+var EncodingPreference = []string{"br", "zstd", "gzip", "deflate"}
+
+var encoders = struct {
+	mu     sync.RWMutex
+	byName map[string]*Encoder
+}{byName: make(map[string]*Encoder)}
+
+func init() {
+	RegisterEncoder("gzip", func(w io.Writer, level int) (EncoderWriter, error) {
+		return gzip.NewWriterLevel(w, level)
+	})
+	// the http's "deflate" is the zlib format, see RFC 7230 section 4.2.2.
+	RegisterEncoder("deflate", func(w io.Writer, level int) (EncoderWriter, error) {
+		return zlib.NewWriterLevel(w, level)
+	})
+}
+
+// RegisterEncoder registers, or replaces, the encoder of the content-coding name, i.e "br",
+// which is negotiated by the renders, see Configuration.Gzip, and by the Context.Compress.
+// The "gzip" and the "deflate" are registered by default.
+//
+// The newWriter returns a new compressor of the w with the level,
+// DefaultCompression means the encoder's default level.
+func RegisterEncoder(name string, newWriter func(w io.Writer, level int) (EncoderWriter, error)) {
+	encoders.mu.Lock()
+	encoders.byName[name] = &Encoder{Name: name, newWriter: newWriter}
+	encoders.mu.Unlock()
+}
+
+// GetEncoder returns the registered encoder of the content-coding name, nil if it's not registered.
+func GetEncoder(name string) *Encoder {
+	encoders.mu.RLock()
+	enc := encoders.byName[name]
+	encoders.mu.RUnlock()
+	return enc
+}
+
+// registeredEncoders returns the encoders by the EncodingPreference.
+func registeredEncoders() []*Encoder {
+	encoders.mu.RLock()
+	defer encoders.mu.RUnlock()
+
+	list := make([]*Encoder, 0, len(encoders.byName))
+	for _, name := range EncodingPreference {
+		if enc, ok := encoders.byName[name]; ok {
+			list = append(list, enc)
+		}
+	}
+	var others []string
+	for name := range encoders.byName {
+		preferred := false
+		for _, p := range EncodingPreference {
+			if p == name {
+				preferred = true
+				break
+			}
+		}
+		if !preferred {
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	for _, name := range others {
+		list = append(list, encoders.byName[name])
+	}
+	return list
+}
+
+// acquire returns a compressor of the w, from the pool of the level.
+func (e *Encoder) acquire(w io.Writer, level int) (EncoderWriter, error) {
+	if pool, ok := e.pools.Load(level); ok {
+		if ew, ok := pool.(*sync.Pool).Get().(EncoderWriter); ok {
+			ew.Reset(w)
+			return ew, nil
+		}
+	}
+	return e.newWriter(w, level)
+}
+
+// release puts back the closed compressor to the pool of the level.
+func (e *Encoder) release(ew EncoderWriter, level int) {
+	pool, _ := e.pools.LoadOrStore(level, &sync.Pool{})
+	pool.(*sync.Pool).Put(ew)
+}
+
+// parseAcceptEncoding returns the quality values of an Accept-Encoding header, by encoding.
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, q := part, 1.0
+		if idx := strings.IndexByte(part, ';'); idx >= 0 {
+			name = part[:idx]
+			if param := strings.TrimSpace(part[idx+1:]); strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			accepted[name] = q
+		}
+	}
+	return accepted
+}
+
+// NegotiateEncoder returns the registered encoder with the highest quality value
+// of the client's Accept-Encoding header, the EncodingPreference breaks the ties.
+// Returns nil if the client accepts none of them.
+func (ctx *Context) NegotiateEncoder() *Encoder {
+	accepted := parseAcceptEncoding(ctx.RequestHeader(acceptEncodingHeader))
+	if len(accepted) == 0 {
+		return nil
+	}
+	star, hasStar := accepted["*"]
+
+	var best *Encoder
+	bestQ := 0.0
+	for _, enc := range registeredEncoders() {
+		q, ok := accepted[enc.Name]
+		if !ok {
+			if !hasStar {
+				continue
+			}
+			q = star
+		}
+		if q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// renderWriter returns the writer of a render, compressed by the client's preferred encoder if enabled,
+// unless the response is compressed by the Context.Compress already.
+// The returned func ends the compression.
+func (ctx *Context) renderWriter(compress bool) (io.Writer, func()) {
+	if _, compressing := ctx.ResponseWriter.(*compressResponseWriter); compress && !compressing {
+		if enc := ctx.NegotiateEncoder(); enc != nil {
+			if ew, err := enc.acquire(ctx.ResponseWriter, DefaultCompression); err == nil {
+				ctx.ResponseWriter.Header().Add(varyHeader, acceptEncodingHeader)
+				ctx.SetHeader(contentEncodingHeader, enc.Name)
+				return ew, func() {
+					ew.Close()
+					enc.release(ew, DefaultCompression)
+				}
+			}
+		}
+	}
+	return ctx.ResponseWriter, func() {}
+}
+
+// DefaultCompressMinSize is the default min size of the compressed bodies, 1KB
+const DefaultCompressMinSize = 1024
+
+// DefaultCompressContentTypes are the default compressed media types,
+// the text ones and the common text-based application formats.
+var DefaultCompressContentTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/xhtml+xml",
+	"application/rss+xml",
+	"application/atom+xml",
+	"image/svg+xml",
+}
+
+// CompressOptions are the options of the Context.Compress, see DefaultCompressOptions.
+type CompressOptions struct {
+	// Level is the compression level, DefaultCompression is the encoder's default level.
+	Level int
+	// MinSize is the min size of the compressed bodies, the smaller ones are sent as they are.
+	// The streamed responses are compressed regardless, see StreamWriter.
+	MinSize int
+	// ContentTypes are the compressed media types, a "text/*" matches all the text types,
+	// empty means all of them.
+	ContentTypes []string
+}
+
+// DefaultCompressOptions returns the default options of the Context.Compress
+func DefaultCompressOptions() CompressOptions {
+	return CompressOptions{
+		Level:        DefaultCompression,
+		MinSize:      DefaultCompressMinSize,
+		ContentTypes: DefaultCompressContentTypes,
+	}
+}
+
+// Compress compresses the next writes of the response by the client's preferred encoder,
+// see NegotiateEncoder, until the EndCompress or the end of the request.
+// The body is buffered until its MinSize, then it's compressed
+// unless its content type is not one of the ContentTypes or it's encoded already, i.e by a render.
+// The streamed responses are compressed on their first Flush, see StreamWriter.
+//
+// Returns false if the client accepts none of the registered encoders or the response is compressed already.
+func (ctx *Context) Compress(opts CompressOptions) bool {
+	if _, compressing := ctx.ResponseWriter.(*compressResponseWriter); compressing {
+		return false
+	}
+	enc := ctx.NegotiateEncoder()
+	if enc == nil {
+		return false
+	}
+	ctx.ResponseWriter = acquireCompressResponseWriter(ctx.ResponseWriter, enc, opts)
+	return true
+}
+
+// EndCompress ends the compression of the Compress, it writes the rest of the compressed body,
+// the next writes are not compressed.
+func (ctx *Context) EndCompress() error {
+	w, ok := ctx.ResponseWriter.(*compressResponseWriter)
+	if !ok {
+		return nil
+	}
+	err := w.finish()
+	ctx.ResponseWriter = w.ResponseWriter
+	w.ResponseWriter = nil
+	cwpool.Put(w)
+	return err
+}
+
+// compressResponseWriter compresses the body, written to the underline response writer.
+type compressResponseWriter struct {
+	ResponseWriter
+	encoder *Encoder
+	opts    CompressOptions
+	// buf is the body before the decision, see begin.
+	buf []byte
+	// writer is the compressor, nil if the body is not compressed.
+	writer  EncoderWriter
+	decided bool
+}
+
+var _ ResponseWriter = &compressResponseWriter{}
+
+var cwpool = sync.Pool{New: func() interface{} { return &compressResponseWriter{} }}
+
+func acquireCompressResponseWriter(underline ResponseWriter, enc *Encoder, opts CompressOptions) *compressResponseWriter {
+	w := cwpool.Get().(*compressResponseWriter)
+	w.ResponseWriter = underline
+	w.encoder = enc
+	w.opts = opts
+	w.buf = w.buf[0:0]
+	w.writer = nil
+	w.decided = false
+	return w
+}
+
+// compressible reports whether the response can be compressed, it detects the missing content type.
+func (w *compressResponseWriter) compressible() bool {
+	status := w.StatusCode()
+	if status < http.StatusOK || status == http.StatusNoContent ||
+		status == http.StatusPartialContent || status == http.StatusNotModified {
+		return false
+	}
+
+	header := w.Header()
+	if header.Get(contentEncodingHeader) != "" {
+		return false
+	}
+	cType := header.Get(contentType)
+	if cType == "" {
+		cType = http.DetectContentType(w.buf)
+		header.Set(contentType, cType)
+	}
+	if len(w.opts.ContentTypes) == 0 {
+		return true
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(cType, ";")[0]))
+	for _, t := range w.opts.ContentTypes {
+		if t == mediaType || (strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1])) {
+			return true
+		}
+	}
+	return false
+}
+
+// begin decides whether the body is compressed and writes the buffered body.
+func (w *compressResponseWriter) begin(compress bool) error {
+	w.decided = true
+	if compress && w.compressible() {
+		ew, err := w.encoder.acquire(w.ResponseWriter, w.opts.Level)
+		if err != nil {
+			return err
+		}
+		w.writer = ew
+
+		header := w.Header()
+		header.Add(varyHeader, acceptEncodingHeader)
+		header.Set(contentEncodingHeader, w.encoder.Name)
+		header.Del(contentLength)
+		// the compressed body is a different representation.
+		if etag := header.Get(etagHeader); strings.HasPrefix(etag, `"`) {
+			header.Set(etagHeader, "W/"+etag)
+		}
+	}
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	_, err := w.write(w.buf)
+	w.buf = w.buf[0:0]
+	return err
+}
+
+func (w *compressResponseWriter) write(contents []byte) (int, error) {
+	if w.writer != nil {
+		return w.writer.Write(contents)
+	}
+	return w.ResponseWriter.Write(contents)
+}
+
+// finish writes the buffered body and closes the compressor.
+func (w *compressResponseWriter) finish() (err error) {
+	if !w.decided {
+		err = w.begin(len(w.buf) > 0 && len(w.buf) >= w.opts.MinSize)
+	}
+	if w.writer != nil {
+		if cerr := w.writer.Close(); err == nil {
+			err = cerr
+		}
+		w.encoder.release(w.writer, w.opts.Level)
+		w.writer = nil
+	}
+	return
+}
+
+// Write compresses the contents, the body is buffered until its MinSize.
+func (w *compressResponseWriter) Write(contents []byte) (int, error) {
+	if w.decided {
+		return w.write(contents)
+	}
+	w.buf = append(w.buf, contents...)
+	if len(w.buf) >= w.opts.MinSize {
+		if err := w.begin(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(contents), nil
+}
+
+// Writef formats according to a format specifier and writes to the response.
+//
+// Returns the number of bytes written and any write error encountered
+func (w *compressResponseWriter) Writef(format string, a ...interface{}) (n int, err error) {
+	return fmt.Fprintf(w, format, a...)
+}
+
+// WriteString writes a simple string to the response.
+//
+// Returns the number of bytes written and any write error encountered
+func (w *compressResponseWriter) WriteString(s string) (n int, err error) {
+	return w.Write([]byte(s))
+}
+
+// Written returns the number of the body's bytes written so far,
+// the compressed size plus the buffered body.
+func (w *compressResponseWriter) Written() int {
+	return w.ResponseWriter.Written() + len(w.buf)
+}
+
+// Flush sends any buffered data to the client,
+// the body is compressed regardless its size.
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		w.begin(len(w.buf) > 0)
+	}
+	if w.writer != nil {
+		w.writer.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *compressResponseWriter) flushResponse() {
+	w.finish()
+	w.ResponseWriter.flushResponse()
+}
+
+// clone returns a clone of the underline response writer,
+// the transactions write their body to the compressed one, see ResponseRecorder.writeTo.
+func (w *compressResponseWriter) clone() ResponseWriter {
+	return w.ResponseWriter.clone()
+}
+
+func (w *compressResponseWriter) writeTo(to ResponseWriter) {
+	w.ResponseWriter.writeTo(to)
+}
+
+func (w *compressResponseWriter) releaseMe() {
+	w.ResponseWriter.releaseMe()
+	w.ResponseWriter = nil
+	cwpool.Put(w)
+}
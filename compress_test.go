@@ -0,0 +1,140 @@
+package iris2_test
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/httptest"
+)
+
+func decompress(t *testing.T, encoding string, body string) string {
+	var (
+		r   io.Reader
+		err error
+	)
+	switch encoding {
+	case "gzip":
+		r, err = gzip.NewReader(strings.NewReader(body))
+	case "deflate":
+		r, err = zlib.NewReader(strings.NewReader(body))
+	default:
+		return body
+	}
+	if err != nil {
+		t.Fatalf("%s: %v", encoding, err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("%s: %v", encoding, err)
+	}
+	return string(b)
+}
+
+func TestContextCompress(t *testing.T) {
+	app := iris2.New(iris2.Configuration{Gzip: true})
+	app.Adapt(newTestNativeRouter())
+	app.Adapt(iris2.RenderPolicy(func(out io.Writer, name string, binding interface{}, options ...map[string]interface{}) (error, bool) {
+		_, err := fmt.Fprintf(out, "%s:%v", name, binding)
+		return err, true
+	}))
+
+	big := strings.Repeat("compressed body ", 128)
+	compressed := app.Party("/c", func(ctx *iris2.Context) {
+		if ctx.Compress(iris2.DefaultCompressOptions()) {
+			defer ctx.EndCompress()
+		}
+		ctx.Next()
+	})
+	compressed.Get("/big", func(ctx *iris2.Context) {
+		ctx.SetHeader("ETag", `"big"`)
+		ctx.WriteString(big)
+	})
+	compressed.Get("/small", func(ctx *iris2.Context) {
+		ctx.WriteString("small body")
+	})
+	compressed.Get("/image", func(ctx *iris2.Context) {
+		ctx.SetContentType("image/png")
+		ctx.Write([]byte(big))
+	})
+	compressed.Get("/stream", func(ctx *iris2.Context) {
+		ctx.SetContentType("text/plain")
+		i := 0
+		ctx.StreamWriter(func(w io.Writer) bool {
+			fmt.Fprintf(w, "chunk%d;", i)
+			i++
+			return i < 3
+		})
+	})
+	compressed.Get("/transaction", func(ctx *iris2.Context) {
+		ctx.WriteString(big)
+		ctx.BeginTransaction(func(t *iris2.Transaction) {
+			t.Context.WriteString("transaction")
+		})
+	})
+	app.Get("/render", func(ctx *iris2.Context) {
+		ctx.MustRender("index.html", "binding")
+	})
+
+	e := httptest.New(app, t)
+
+	for _, tt := range []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"gzip, deflate", "gzip"},
+		{"deflate;q=1.0, gzip;q=0.5", "deflate"},
+		{"identity, *;q=0.1", "gzip"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"gzip;q=0", ""},
+		{"", ""},
+	} {
+		r := e.GET("/c/big").WithHeader("Accept-Encoding", tt.acceptEncoding).Expect().Status(http.StatusOK)
+		r.Header("Content-Encoding").Equal(tt.encoding)
+		if body := decompress(t, tt.encoding, r.Body().Raw()); body != big {
+			t.Fatalf("Accept-Encoding %q: expected the big body but got %q", tt.acceptEncoding, body)
+		}
+		if tt.encoding != "" {
+			r.Header("Vary").Equal("Accept-Encoding")
+			r.Header("Content-Type").Contains("text/plain")
+			r.Header("ETag").Equal(`W/"big"`)
+		}
+	}
+
+	e.GET("/c/small").WithHeader("Accept-Encoding", "gzip").Expect().Status(http.StatusOK).
+		Header("Content-Encoding").Empty()
+	r := e.GET("/c/image").WithHeader("Accept-Encoding", "gzip").Expect().Status(http.StatusOK)
+	r.Header("Content-Encoding").Empty()
+	r.Body().Equal(big)
+
+	// the streamed responses are compressed regardless their size.
+	r = e.GET("/c/stream").WithHeader("Accept-Encoding", "gzip").Expect().Status(http.StatusOK)
+	r.Header("Content-Encoding").Equal("gzip")
+	if body := decompress(t, "gzip", r.Body().Raw()); body != "chunk0;chunk1;chunk2;" {
+		t.Fatalf("expected the streamed chunks but got %q", body)
+	}
+
+	r = e.GET("/c/transaction").WithHeader("Accept-Encoding", "deflate").Expect().Status(http.StatusOK)
+	r.Header("Content-Encoding").Equal("deflate")
+	if body := decompress(t, "deflate", r.Body().Raw()); body != big+"transaction" {
+		t.Fatalf("expected the body of the transaction but got %q", body)
+	}
+
+	// the renders negotiate the registered encoders too.
+	r = e.GET("/render").WithHeader("Accept-Encoding", "deflate").Expect().Status(http.StatusOK)
+	r.Header("Content-Encoding").Equal("deflate")
+	if body := decompress(t, "deflate", r.Body().Raw()); body != "index.html:binding" {
+		t.Fatalf("expected the rendered body but got %q", body)
+	}
+	e.GET("/render").Expect().Status(http.StatusOK).Body().Equal("index.html:binding")
+
+	if iris2.GetEncoder("gzip") == nil || iris2.GetEncoder("unknown") != nil {
+		t.Fatalf("expected the gzip encoder only")
+	}
+}
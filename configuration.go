@@ -80,6 +80,34 @@ type Configuration struct {
 	// If zero, DefaultMaxHeaderBytes is used.
 	MaxHeaderBytes int
 
+	// ShutdownTimeout is the maximum duration which the Close waits
+	// for the active connections to be drained.
+	// Zero means no timeout.
+	//
+	// Defaults to 10 seconds.
+	ShutdownTimeout time.Duration
+
+	// ShutdownDelay is the duration between the readiness flipping (see Framework.IsReady)
+	// and the servers' shutdown on Close, gives the time to the load balancers
+	// to stop sending new requests.
+	//
+	// Defaults to 0.
+	ShutdownDelay time.Duration
+
+	// DisableInterruptHandler if it's true then the Framework doesn't
+	// listen for the interrupt (Ctrl/Cmd+C) and SIGTERM signals,
+	// use it when the app is embedded in a larger process
+	// which manages the lifecycle by itself via Framework.Close/Wait.
+	//
+	// Defaults to false.
+	DisableInterruptHandler bool
+
+	// DisableExitOnInterrupt if it's true then the Framework
+	// closes gracefully on interrupt (or SIGTERM) signal but it doesn't exit the process.
+	//
+	// Defaults to false.
+	DisableExitOnInterrupt bool
+
 	// DisablePathCorrection corrects and redirects the requested path to the registered path
 	// for example, if /home/ path is requested but no handler for this Route found,
 	// then the Router checks if /home handler exists, if yes,
@@ -106,6 +134,24 @@ type Configuration struct {
 	// Defaults to false.
 	FireMethodNotAllowed bool
 
+	// DisableAutoHead if it's true then the router doesn't serve the HEAD requests
+	// with the GET route of the same path when no HEAD route registered.
+	// Defaults to false.
+	DisableAutoHead bool
+
+	// DisableAutoOptions if it's true then the router doesn't answer
+	// the OPTIONS requests, which have no OPTIONS (or cors) route registered,
+	// with the "Allow" header of the path's registered methods.
+	// Defaults to false.
+	DisableAutoOptions bool
+
+	// EnableMethodOverride if it's true then the router serves a POST request
+	// with the route of the method given by the "X-HTTP-Method-Override" header
+	// or by the "_method" form field, useful for the html forms which can only GET and POST.
+	// Only the PUT, PATCH and DELETE methods can override a POST request.
+	// Defaults to false.
+	EnableMethodOverride bool
+
 	// DisableBodyConsumptionOnUnmarshal manages the reading behavior of the context's body readers/binders.
 	// If setted to true then it
 	// disables the body consumption by the `context.UnmarshalBody/ReadJSON/ReadXML`.
@@ -126,7 +172,8 @@ type Configuration struct {
 	Charset string
 
 	// Gzip enables gzip compression on your Render actions, this includes any type of render,
-	// templates and pure/raw content
+	// templates and pure/raw content,
+	// the client's preferred registered encoder is used, i.e "br" or "gzip", see RegisterEncoder.
 	// If you don't want to enable it globally, you could just use the third parameter
 	// on context.Render("myfileOrResponse", structBinding{}, iris2.RenderOptions{"gzip": true})
 	// Defaults to false.
@@ -214,6 +261,48 @@ var (
 		}
 	}
 
+	// OptionShutdownTimeout sets the maximum duration which the Close waits
+	// for the active connections to be drained.
+	// Zero means no timeout.
+	//
+	// Defaults to 10 seconds.
+	OptionShutdownTimeout = func(val time.Duration) OptionSet {
+		return func(c *Configuration) {
+			c.ShutdownTimeout = val
+		}
+	}
+
+	// OptionShutdownDelay sets the duration between the readiness flipping (see Framework.IsReady)
+	// and the servers' shutdown on Close, gives the time to the load balancers
+	// to stop sending new requests.
+	//
+	// Defaults to 0.
+	OptionShutdownDelay = func(val time.Duration) OptionSet {
+		return func(c *Configuration) {
+			c.ShutdownDelay = val
+		}
+	}
+
+	// OptionDisableInterruptHandler if it's true then the Framework doesn't
+	// listen for the interrupt (Ctrl/Cmd+C) and SIGTERM signals.
+	//
+	// Defaults to false.
+	OptionDisableInterruptHandler = func(val bool) OptionSet {
+		return func(c *Configuration) {
+			c.DisableInterruptHandler = val
+		}
+	}
+
+	// OptionDisableExitOnInterrupt if it's true then the Framework
+	// closes gracefully on interrupt (or SIGTERM) signal but it doesn't exit the process.
+	//
+	// Defaults to false.
+	OptionDisableExitOnInterrupt = func(val bool) OptionSet {
+		return func(c *Configuration) {
+			c.DisableExitOnInterrupt = val
+		}
+	}
+
 	// OptionDisablePathCorrection corrects and redirects the requested path to the registered path
 	// for example, if /home/ path is requested but no handler for this Route found,
 	// then the Router checks if /home handler exists, if yes,
@@ -243,6 +332,36 @@ var (
 		}
 	}
 
+	// OptionDisableAutoHead if it's true then the router doesn't serve the HEAD requests
+	// with the GET route of the same path when no HEAD route registered.
+	// Defaults to false.
+	OptionDisableAutoHead = func(val bool) OptionSet {
+		return func(c *Configuration) {
+			c.DisableAutoHead = val
+		}
+	}
+
+	// OptionDisableAutoOptions if it's true then the router doesn't answer
+	// the OPTIONS requests, which have no OPTIONS (or cors) route registered,
+	// with the "Allow" header of the path's registered methods.
+	// Defaults to false.
+	OptionDisableAutoOptions = func(val bool) OptionSet {
+		return func(c *Configuration) {
+			c.DisableAutoOptions = val
+		}
+	}
+
+	// OptionEnableMethodOverride if it's true then the router serves a POST request
+	// with the route of the method given by the "X-HTTP-Method-Override" header
+	// or by the "_method" form field, useful for the html forms which can only GET and POST.
+	// Only the PUT, PATCH and DELETE methods can override a POST request.
+	// Defaults to false.
+	OptionEnableMethodOverride = func(val bool) OptionSet {
+		return func(c *Configuration) {
+			c.EnableMethodOverride = val
+		}
+	}
+
 	// OptionDisableBodyConsumptionOnUnmarshal manages the reading behavior of the context's body readers/binders.
 	// If setted to true then it
 	// disables the body consumption by the `context.UnmarshalBody/ReadJSON/ReadXML`.
@@ -327,6 +446,8 @@ const (
 	DefaultReadTimeout = 0
 	// DefaultWriteTimeout no serve client timeout
 	DefaultWriteTimeout = 0
+	// DefaultShutdownTimeout is the maximum duration which the Close waits for the active connections
+	DefaultShutdownTimeout = 10 * time.Second
 )
 
 // DefaultConfiguration returns the default configuration for an Iris station, fills the main Configuration
@@ -337,9 +458,16 @@ func DefaultConfiguration() Configuration {
 		ReadTimeout:                       DefaultReadTimeout,
 		WriteTimeout:                      DefaultWriteTimeout,
 		MaxHeaderBytes:                    DefaultMaxHeaderBytes,
+		ShutdownTimeout:                   DefaultShutdownTimeout,
+		ShutdownDelay:                     0,
+		DisableInterruptHandler:           false,
+		DisableExitOnInterrupt:            false,
 		DisablePathCorrection:             DefaultDisablePathCorrection,
 		EnablePathEscape:                  DefaultEnablePathEscape,
 		FireMethodNotAllowed:              false,
+		DisableAutoHead:                   false,
+		DisableAutoOptions:                false,
+		EnableMethodOverride:              false,
 		DisableBodyConsumptionOnUnmarshal: false,
 		TimeFormat:                        DefaultTimeFormat,
 		Charset:                           DefaultCharset,
@@ -54,6 +54,12 @@ const (
 	lastModified = "Last-Modified"
 	// IfModifiedSince "If-Modified-Since"
 	ifModifiedSince = "If-Modified-Since"
+	// ETag "ETag"
+	etagHeader = "ETag"
+	// IfNoneMatch "If-None-Match"
+	ifNoneMatch = "If-None-Match"
+	// Range "Range"
+	rangeHeader = "Range"
 	// ContentDisposition "Content-Disposition"
 	contentDisposition = "Content-Disposition"
 	// CacheControl "Cache-Control"
@@ -175,7 +181,14 @@ func (c *contextPool) Release(ctx *Context) {
 	// when all finished
 	ctx.ResponseWriter.flushResponse()
 
+	if ctx.session != nil {
+		if end := ctx.framework.policies.SessionsPolicy.End; end != nil {
+			end(ctx.session)
+		}
+	}
+
 	ctx.Middleware = nil
+	ctx.route = nil
 	ctx.session = nil
 	ctx.Request = nil
 	///TODO:
@@ -213,6 +226,7 @@ type (
 		framework      *Framework
 		//keep track all registered middleware (handlers)
 		Middleware  Middleware //  exported because is useful for debugging
+		route       RouteInfo
 		session     Session
 		formDecoder *schema.Decoder
 		// Pos is the position number of the Context, look .Next to understand
@@ -272,6 +286,13 @@ func (ctx *Context) GetHandlerName() string {
 	return runtime.FuncForPC(reflect.ValueOf(ctx.Middleware[len(ctx.Middleware)-1]).Pointer()).Name()
 }
 
+// GetCurrentRoute returns the route which is being served,
+// it's nil when the request didn't match any route of the default router
+// or when a custom router, which doesn't set it, is used.
+func (ctx *Context) GetCurrentRoute() RouteInfo {
+	return ctx.route
+}
+
 // ExecRoute calls any route (mostly  "offline" route) like it was requested by the user, but it is not.
 // Offline means that the route is registered to the iris and have all features that a normal route has
 // BUT it isn't available by browsing, its handlers executed only when other handler's context call them
@@ -793,6 +814,17 @@ func (ctx *Context) clientAllowsGzip() bool {
 	return false
 }
 
+// clientAcceptsEncoding reports whether the Accept-Encoding header of the client
+// accepts the encoding, i.e "br", with a non-zero quality value,
+// the "*" applies to the encodings which are not listed.
+func (ctx *Context) clientAcceptsEncoding(encoding string) bool {
+	accepted := parseAcceptEncoding(ctx.RequestHeader(acceptEncodingHeader))
+	if q, ok := accepted[encoding]; ok {
+		return q > 0
+	}
+	return accepted["*"] > 0
+}
+
 // WriteGzip accepts bytes, which are compressed to gzip format and sent to the client.
 // returns the number of bytes written and an error ( if the client doesn' supports gzip compression)
 func (ctx *Context) WriteGzip(b []byte) (int, error) {
@@ -836,8 +868,28 @@ const (
 	NoLayout = template.NoLayout
 	// TemplateLayoutContextKey is the name of the user values which can be used to set a template layout from a middleware and override the parent's
 	TemplateLayoutContextKey = "templateLayout"
+	// ViewDataContextKey is the name of the user values which keeps the ViewData
+	ViewDataContextKey = "viewData"
 )
 
+// ViewData sets a value which is passed to the templates, from a middleware too,
+// the values are added to the Map (or nil) bindings of the next renders,
+// the binding's own keys are not overridden.
+func (ctx *Context) ViewData(key string, value interface{}) {
+	m, ok := ctx.Get(ViewDataContextKey).(Map)
+	if !ok {
+		m = make(Map)
+		ctx.Set(ViewDataContextKey, m)
+	}
+	m[key] = value
+}
+
+// GetViewData returns the values which are set by the ViewData, nil if none.
+func (ctx *Context) GetViewData() Map {
+	m, _ := ctx.Get(ViewDataContextKey).(Map)
+	return m
+}
+
 // getGzipOption receives a default value and the render options map and returns if gzip is enabled for this render action
 func getGzipOption(defaultValue bool, options map[string]interface{}) bool {
 	gzipOpt := options["gzip"] // we only need that, so don't create new map to keep the options.
@@ -857,7 +909,7 @@ func getCharsetOption(defaultValue string, options map[string]interface{}) strin
 }
 
 func (ctx *Context) fastRenderWithStatus(status int, cType string, data []byte) (err error) {
-	if _, shouldFirstStatusCode := ctx.ResponseWriter.(*responseWriter); shouldFirstStatusCode {
+	if _, recording := ctx.IsRecording(); !recording {
 		ctx.SetStatusCode(status)
 	}
 	gzipEnabled := ctx.framework.Config.Gzip
@@ -870,17 +922,8 @@ func (ctx *Context) fastRenderWithStatus(status int, cType string, data []byte)
 	// add the content type to the response
 	ctx.SetContentType(cType)
 
-	var out io.Writer
-	if gzipEnabled && ctx.clientAllowsGzip() {
-		ctx.ResponseWriter.Header().Add(varyHeader, acceptEncodingHeader)
-		ctx.SetHeader(contentEncodingHeader, "gzip")
-
-		gzipWriter := AcquireGzipWriter(ctx.ResponseWriter)
-		defer ReleaseGzipWriter(gzipWriter)
-		out = gzipWriter
-	} else {
-		out = ctx.ResponseWriter
-	}
+	out, release := ctx.renderWriter(gzipEnabled)
+	defer release()
 
 	// no need to loop through the RenderPolicy, these types must be fast as possible
 	// with the features like gzip and custom charset too.
@@ -899,7 +942,7 @@ func (ctx *Context) fastRenderWithStatus(status int, cType string, data []byte)
 // Note: the options: "gzip" and "charset" are built'n support by Iris, so you can pass these on any template engine or serialize engines
 func (ctx *Context) RenderWithStatus(status int, name string, binding interface{}, options ...map[string]interface{}) (err error) {
 
-	if _, shouldFirstStatusCode := ctx.ResponseWriter.(*responseWriter); shouldFirstStatusCode {
+	if _, recording := ctx.IsRecording(); !recording {
 		ctx.SetStatusCode(status)
 	}
 
@@ -941,17 +984,8 @@ func (ctx *Context) RenderWithStatus(status int, name string, binding interface{
 	// add the content type to the response
 	ctx.SetContentType(cType)
 
-	var out io.Writer
-	if gzipEnabled && ctx.clientAllowsGzip() {
-		ctx.ResponseWriter.Header().Add(varyHeader, acceptEncodingHeader)
-		ctx.SetHeader(contentEncodingHeader, "gzip")
-
-		gzipWriter := AcquireGzipWriter(ctx.ResponseWriter)
-		defer ReleaseGzipWriter(gzipWriter)
-		out = gzipWriter
-	} else {
-		out = ctx.ResponseWriter
-	}
+	out, release := ctx.renderWriter(gzipEnabled)
+	defer release()
 
 	var m Map
 	var ok bool
@@ -962,6 +996,11 @@ func (ctx *Context) RenderWithStatus(status int, name string, binding interface{
 		m, ok = binding.(Map)
 	}
 	if ok {
+		for k, v := range ctx.GetViewData() {
+			if _, exists := m[k]; !exists {
+				m[k] = v
+			}
+		}
 		if ctx.framework.Config.AutoFlashMessage && ctx.framework.policies.SessionsPolicy.Start != nil {
 			m["FlashMessage"] = ctx.Session().GetFlashString("msg")
 		}
@@ -1123,34 +1162,87 @@ func (ctx *Context) SetClientCachedBody(status int, bodyContent []byte, cType st
 // receives three parameters, it's low-level function, instead you can use .ServeFile(string,bool)/SendFile(string,string)
 //
 // You can define your own "Content-Type" header also, after this function call
-// Doesn't implements resuming (by range), use ctx.SendFile instead
+// It sends an ETag, unless it's already set, and checks the If-None-Match and If-Modified-Since headers.
+// It implements resuming, the Range requests with single and multiple ranges and the If-Range header,
+// the ranges are served uncompressed even if gzipCompression is true.
 func (ctx *Context) ServeContent(content io.ReadSeeker, filename string, modtime time.Time, gzipCompression bool) error {
-	if t, err := time.Parse(ctx.framework.Config.TimeFormat, ctx.RequestHeader(ifModifiedSince)); err == nil &&
-		modtime.Before(t.Add(1*time.Second)) {
-		ctx.ResponseWriter.Header().Del(contentType)
-		ctx.ResponseWriter.Header().Del(contentLength)
-		ctx.SetStatusCode(http.StatusNotModified)
-		return nil
+	size, err := content.Seek(0, io.SeekEnd)
+	if err == nil {
+		_, err = content.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		return errServeContent.With(err)
 	}
 
+	header := ctx.ResponseWriter.Header()
 	if ext := TypeByExtension(filename); ext != "" {
-		ctx.ResponseWriter.Header().Set(contentType, ext)
+		header.Set(contentType, ext)
 	}
-	ctx.ResponseWriter.Header().Set(lastModified, modtime.UTC().Format(ctx.framework.Config.TimeFormat))
-	ctx.SetStatusCode(http.StatusOK)
-	var out io.Writer
-	if gzipCompression && ctx.clientAllowsGzip() {
-		ctx.ResponseWriter.Header().Add(varyHeader, acceptEncodingHeader)
-		ctx.SetHeader(contentEncodingHeader, "gzip")
+	etag := header.Get(etagHeader)
+	if etag == "" {
+		etag = fileETag(modtime, size)
+	}
+
+	if gzipCompression {
+		header.Add(varyHeader, acceptEncodingHeader)
+	}
+	if gzipCompression && ctx.clientAllowsGzip() && ctx.RequestHeader(rangeHeader) == "" {
+		etag = gzipETag(etag)
+		header.Set(etagHeader, etag)
+		header.Set(lastModified, modtime.UTC().Format(ctx.framework.Config.TimeFormat))
+		if ctx.clientCacheFresh(etag, modtime) {
+			header.Del(contentType)
+			header.Del(contentLength)
+			ctx.SetStatusCode(http.StatusNotModified)
+			return nil
+		}
 
+		ctx.SetHeader(contentEncodingHeader, "gzip")
+		ctx.SetStatusCode(http.StatusOK)
 		gzipWriter := AcquireGzipWriter(ctx.ResponseWriter)
 		defer ReleaseGzipWriter(gzipWriter)
-		out = gzipWriter
-	} else {
-		out = ctx.ResponseWriter
+		_, err = io.Copy(gzipWriter, content)
+		return errServeContent.With(err)
 	}
-	_, err := io.Copy(out, content)
-	return errServeContent.With(err)
+
+	header.Set(etagHeader, etag)
+	// the net/http checks the conditional headers and serves the ranges.
+	http.ServeContent(ctx.ResponseWriter, ctx.Request, filename, modtime, content)
+	return nil
+}
+
+// fileETag returns the ETag of a file by its modification time and its size.
+func fileETag(modtime time.Time, size int64) string {
+	return `"` + strconv.FormatInt(modtime.UnixNano(), 36) + "-" + strconv.FormatInt(size, 36) + `"`
+}
+
+// gzipETag returns the ETag of the gzip representation,
+// it's different than the uncompressed one, the If-Range of a compressed response doesn't match the file's bytes.
+func gzipETag(etag string) string {
+	return strings.TrimSuffix(etag, `"`) + `-gzip"`
+}
+
+// clientCacheFresh reports whether the client's copy, with this etag and modtime, is fresh,
+// by the If-None-Match or, if the client doesn't send it, by the If-Modified-Since.
+func (ctx *Context) clientCacheFresh(etag string, modtime time.Time) bool {
+	if inm := ctx.RequestHeader(ifNoneMatch); inm != "" {
+		if etag == "" {
+			return false
+		}
+		etag = strings.TrimPrefix(etag, "W/")
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if modtime.IsZero() {
+		return false
+	}
+	t, err := time.Parse(ctx.framework.Config.TimeFormat, ctx.RequestHeader(ifModifiedSince))
+	return err == nil && !modtime.Truncate(time.Second).After(t)
 }
 
 // ServeFile serves a view file, to send a file ( zip for example) to the client you should use the SendFile(serverfilename,clientfilename)
@@ -1159,7 +1251,7 @@ func (ctx *Context) ServeContent(content io.ReadSeeker, filename string, modtime
 // gzipCompression (bool)
 //
 // You can define your own "Content-Type" header also, after this function call
-// This function doesn't implement resuming (by range), use ctx.SendFile instead
+// This function implements resuming (by range), see ServeContent
 //
 // Use it when you want to serve css/js/... files to the client, for bigger files and 'force-download' use the SendFile
 func (ctx *Context) ServeFile(filename string, gzipCompression bool) error {
@@ -1496,6 +1588,25 @@ func (ctx *Context) SessionDestroy() {
 	}
 }
 
+// SessionRegenerate gives a new id to the session, keeping its values, and returns it.
+// Call it after a login or a privilege change in order to protect against session fixation.
+//
+// If the SessionsPolicy doesn't support it, the session is returned as it's.
+func (ctx *Context) SessionRegenerate() Session {
+	sess := ctx.Session()
+	if sess == nil {
+		return nil
+	}
+
+	if regenerate := ctx.framework.policies.SessionsPolicy.Regenerate; regenerate != nil {
+		ctx.session = regenerate(ctx.ResponseWriter, ctx.Request, sess)
+	} else {
+		ctx.Log("SessionRegenerate: the SessionsPolicy doesn't support the session's regeneration")
+	}
+
+	return ctx.session
+}
+
 var maxAgeExp = regexp.MustCompile(`maxage=(\d+)`)
 
 // MaxAge returns the "cache-control" request header's value
@@ -1527,6 +1638,11 @@ func (ctx *Context) MaxAge() int64 {
 func (ctx *Context) Record() {
 	if w, ok := ctx.ResponseWriter.(*responseWriter); ok {
 		ctx.ResponseWriter = acquireResponseRecorder(w)
+	} else if c, ok := ctx.ResponseWriter.(*compressResponseWriter); ok {
+		// records the compressed body.
+		if w, ok := c.ResponseWriter.(*responseWriter); ok {
+			c.ResponseWriter = acquireResponseRecorder(w)
+		}
 	}
 }
 
@@ -1534,7 +1650,8 @@ func (ctx *Context) Record() {
 // if not recording then it starts recording and returns the new context's ResponseRecorder
 func (ctx *Context) Recorder() *ResponseRecorder {
 	ctx.Record()
-	return ctx.ResponseWriter.(*ResponseRecorder)
+	rr, _ := ctx.IsRecording()
+	return rr
 }
 
 // IsRecording returns the response recorder and a true value
@@ -1545,7 +1662,11 @@ func (ctx *Context) IsRecording() (*ResponseRecorder, bool) {
 	// two return values in order to minimize the if statement:
 	// if (Recording) then writer = Recorder()
 	// instead we do: recorder,ok = Recording()
-	rr, ok := ctx.ResponseWriter.(*ResponseRecorder)
+	w := ctx.ResponseWriter
+	if c, ok := w.(*compressResponseWriter); ok {
+		w = c.ResponseWriter
+	}
+	rr, ok := w.(*ResponseRecorder)
 	return rr, ok
 }
 
@@ -1567,6 +1688,49 @@ func (ctx *Context) TransactionsSkipped() bool {
 	return false
 }
 
+// transactionsContextKey is the context-key of the request's transactionLog.
+const transactionsContextKey = "__IRIS_TRANSACTIONS___"
+
+// transactionLog returns the request's transactions, create it if not exists,
+// the transactions' contexts share it with the request's context.
+func (ctx *Context) transactionLog(create bool) *transactionLog {
+	if log, ok := ctx.Get(transactionsContextKey).(*transactionLog); ok {
+		return log
+	}
+	if !create {
+		return nil
+	}
+	log := &transactionLog{}
+	ctx.Set(transactionsContextKey, log)
+	return log
+}
+
+// TransactionResults returns the outcome of the request's transactions, nested ones too,
+// in the order they began. It's useful for the after (Done) handlers.
+func (ctx *Context) TransactionResults() []TransactionResult {
+	log := ctx.transactionLog(false)
+	if log == nil {
+		return nil
+	}
+	results := make([]TransactionResult, len(log.results))
+	copy(results, log.results)
+	return results
+}
+
+// RollbackTransactions rolls back the completed transactions of the request, in the reverse order,
+// their rollback callbacks, and their nested transactions' ones, are called.
+// It's called by the AbortTransactionScope when a transaction fails.
+func (ctx *Context) RollbackTransactions() {
+	log := ctx.transactionLog(false)
+	if log == nil {
+		return
+	}
+	for i := len(log.committed) - 1; i >= 0; i-- {
+		log.committed[i].compensate()
+	}
+	log.committed = nil
+}
+
 // non-detailed error log for transacton unexpected panic
 var errTransactionInterrupted = errors.New("Transaction Interrupted, recovery from panic:\n%s")
 
@@ -1583,13 +1747,33 @@ var errTransactionInterrupted = errors.New("Transaction Interrupted, recovery fr
 // Transactions have their own middleware ecosystem also, look iris2.go:UseTransaction.
 //
 // See https://github.com/iris-contrib/examples/tree/master/transactions for more
+//
+// The transaction's rollback callbacks are called when it fails or panics, see Transaction.OnRollback,
+// and the outcome of the request's transactions is available by the ctx.TransactionResults.
 func (ctx *Context) BeginTransaction(pipe func(transaction *Transaction)) {
+	ctx.beginTransaction(pipe, nil)
+}
+
+// beginTransaction starts a transaction, outer is nil if it's not a nested transaction.
+func (ctx *Context) beginTransaction(pipe func(transaction *Transaction), outer *Transaction) {
 	// SILLY NOTE: use of manual pipe type in order of TransactionFunc
 	// in order to help editors complete the sentence here...
 
+	var log *transactionLog
+	if outer != nil {
+		log = outer.log
+	} else {
+		log = ctx.transactionLog(true)
+	}
+
 	// do NOT begin a transaction when the previous transaction has been failed
 	// and it was requested scoped or SkipTransactions called manually.
 	if ctx.TransactionsSkipped() {
+		depth := 0
+		if outer != nil {
+			depth = outer.depth() + 1
+		}
+		log.add(depth, TransactionSkipped)
 		return
 	}
 
@@ -1597,13 +1781,21 @@ func (ctx *Context) BeginTransaction(pipe func(transaction *Transaction)) {
 	ctx.Record()
 
 	// get a transaction scope from the pool by passing the temp context/
-	t := newTransaction(ctx)
+	t := newTransaction(ctx, outer, log)
 	defer func() {
 		if err := recover(); err != nil {
-			ctx.Log(errTransactionInterrupted.Format(err).Error())
+			reason := errTransactionInterrupted.Format(err).Error()
+			ctx.Log(reason)
+			if !t.completed {
+				// the panic is a failure, the rollback callbacks are called.
+				t.end(TransactionErrResult{StatusCode: http.StatusInternalServerError, Reason: reason})
+			}
 			// complete (again or not , doesn't matters) the scope without loud
 			t.Complete(nil)
 			// we continue as normal, no need to return here*
+		} else if !t.completed {
+			// no Complete, no error.
+			t.end(TransactionErrResult{})
 		}
 
 		// write the temp contents to the original writer
@@ -1618,8 +1810,40 @@ func (ctx *Context) BeginTransaction(pipe func(transaction *Transaction)) {
 	pipe(t)
 }
 
-// Log logs to the iris defined logger
+// RequestIDContextKey, TraceIDContextKey & SpanIDContextKey are used by the requestid middleware
+// to store the request's correlation id and its W3C trace context,
+// the ctx.Log prefixes the messages with them.
+var (
+	RequestIDContextKey = "requestID"
+	TraceIDContextKey   = "traceID"
+	SpanIDContextKey    = "spanID"
+)
+
+// RequestID returns the request's correlation id, if any,
+// it's the ctx.GetString(iris2.RequestIDContextKey), see the requestid middleware.
+func (ctx *Context) RequestID() string {
+	return ctx.GetString(RequestIDContextKey)
+}
+
+// TraceID returns the request's W3C trace id, if any,
+// it's the ctx.GetString(iris2.TraceIDContextKey), see the requestid middleware.
+func (ctx *Context) TraceID() string {
+	return ctx.GetString(TraceIDContextKey)
+}
+
+// Log logs to the iris defined logger,
+// the message is prefixed with the request id and the trace id, if any.
 func (ctx *Context) Log(format string, a ...interface{}) {
+	var prefix string
+	if id := ctx.RequestID(); id != "" {
+		prefix = "request_id=" + id + " "
+	}
+	if id := ctx.TraceID(); id != "" {
+		prefix += "trace_id=" + id + " "
+	}
+	if prefix != "" {
+		format = strings.Replace(prefix, "%", "%%", -1) + format
+	}
 	ctx.framework.Log(format, a...)
 }
 
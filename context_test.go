@@ -1,6 +1,8 @@
 package iris2_test
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"testing"
@@ -232,3 +234,109 @@ func TestLimitRequestBodySizeMiddleware(t *testing.T) {
 	e.POST("/").WithBytes(largerBSent).Expect().Status(http.StatusBadRequest).Body().Equal("http: request body too large")
 
 }
+
+func TestContextRequestIDTraceID(t *testing.T) {
+	app := iris2.New()
+	app.Adapt(newTestNativeRouter())
+
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.Writef("[%s][%s]", ctx.RequestID(), ctx.TraceID())
+	})
+	app.Get("/ids", func(ctx *iris2.Context) {
+		ctx.Set(iris2.RequestIDContextKey, "my-request")
+		ctx.Set(iris2.TraceIDContextKey, "4bf92f3577b34da6a3ce929d0e0e4736")
+		ctx.Writef("[%s][%s]", ctx.RequestID(), ctx.TraceID())
+	})
+
+	e := httptest.New(app, t)
+	e.GET("/").Expect().Status(http.StatusOK).Body().Equal("[][]")
+	e.GET("/ids").Expect().Status(http.StatusOK).Body().Equal("[my-request][4bf92f3577b34da6a3ce929d0e0e4736]")
+}
+
+func TestContextViewData(t *testing.T) {
+	app := iris2.New()
+	app.Adapt(newTestNativeRouter())
+	app.Adapt(iris2.RenderPolicy(func(out io.Writer, name string, binding interface{}, options ...map[string]interface{}) (error, bool) {
+		m := binding.(iris2.Map)
+		_, err := fmt.Fprintf(out, "%s:%v:%v", name, m["title"], m["user"])
+		return err, true
+	}))
+
+	app.UseFunc(func(ctx *iris2.Context) {
+		ctx.ViewData("title", "from middleware")
+		ctx.ViewData("user", "kataras")
+		ctx.Next()
+	})
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.MustRender("index.html", nil)
+	})
+	app.Get("/override", func(ctx *iris2.Context) {
+		ctx.MustRender("index.html", iris2.Map{"title": "from handler"})
+	})
+
+	e := httptest.New(app, t)
+	e.GET("/").Expect().Status(http.StatusOK).Body().Equal("index.html:from middleware:kataras")
+	e.GET("/override").Expect().Status(http.StatusOK).Body().Equal("index.html:from handler:kataras")
+}
+
+type testSession struct {
+	iris2.Session
+	id string
+}
+
+func (s *testSession) ID() string { return s.id }
+
+func TestContextSessionRegenerate(t *testing.T) {
+	app := iris2.New()
+	app.Adapt(newTestNativeRouter())
+	app.Adapt(iris2.SessionsPolicy{
+		Start: func(http.ResponseWriter, *http.Request) iris2.Session {
+			return &testSession{id: "old"}
+		},
+		Regenerate: func(_ http.ResponseWriter, _ *http.Request, sess iris2.Session) iris2.Session {
+			return &testSession{id: sess.ID() + "-new"}
+		},
+	})
+
+	app.Get("/", func(ctx *iris2.Context) {
+		before := ctx.Session().ID()
+		ctx.SessionRegenerate()
+		ctx.Writef("%s:%s", before, ctx.Session().ID())
+	})
+
+	e := httptest.New(app, t)
+	e.GET("/").Expect().Status(http.StatusOK).Body().Equal("old:old-new")
+}
+
+func TestContextSessionEnd(t *testing.T) {
+	ended := make(chan string, 1)
+	app := iris2.New()
+	app.Adapt(newTestNativeRouter())
+	app.Adapt(iris2.SessionsPolicy{
+		Start: func(http.ResponseWriter, *http.Request) iris2.Session {
+			return &testSession{id: "sid"}
+		},
+		End: func(sess iris2.Session) {
+			ended <- sess.ID()
+		},
+	})
+
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.Writef("%s", ctx.Session().ID())
+	})
+	app.Get("/nosession", func(ctx *iris2.Context) {
+		ctx.Writef("none")
+	})
+
+	e := httptest.New(app, t)
+	e.GET("/").Expect().Status(http.StatusOK).Body().Equal("sid")
+	if got := <-ended; got != "sid" {
+		t.Fatalf("expected the End of the session 'sid' but got '%s'", got)
+	}
+	e.GET("/nosession").Expect().Status(http.StatusOK).Body().Equal("none")
+	select {
+	case got := <-ended:
+		t.Fatalf("expected no End without a session but got '%s'", got)
+	default:
+	}
+}
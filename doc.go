@@ -157,6 +157,21 @@ This is the only difference between the routers, the registered path form, the A
           ctx.Writef("The path after /anything is: %s",s)
         })
 
+        // typed parameters, a failed constraint falls through
+        // to the next route of the same shape or to the 404 error handler.
+        app.Get("/users/:id:int{min(1)}", func(ctx *iris2.Context){
+          id, _ := ctx.ParamInt("id")
+          ctx.Writef("User with id: %d", id)
+        })
+
+        app.Get("/users/:username:string{min(3)}", func(ctx *iris2.Context){
+          ctx.Writef("User with username: %s", ctx.Param("username"))
+        })
+
+        app.Get("/posts/:slug:regexp(^[a-z-]+$)", func(ctx *iris2.Context){
+          ctx.Writef("Post: %s", ctx.Param("slug"))
+        })
+
         mysubdomain:= app.Party("mysubdomain.")
         // http://mysubdomain.myhost.com/
         mysudomain.Get("/", h)
@@ -258,6 +273,12 @@ Static Files
       // Forth parameter is the AssetNames function
       StaticEmbedded(reqPath string, vdir string, assetFn func(name string) ([]byte, error), namesFn func() []string) RouteInfo
 
+      // StaticFS serves the files of an fs.FS, i.e an embed.FS, with ETags by their contents' hashes,
+      // the precompressed .br and .gz siblings and an optional single page application's index fallback.
+      //
+      //     iris2.StaticFS("/static", assets, iris2.StaticFSOptions{SPA: true})
+      StaticFS(reqPath string, fsys fs.FS, options ...StaticFSOptions) RouteInfo
+
 Example code:
 
 
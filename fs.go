@@ -3,9 +3,11 @@ package iris2
 import (
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"time"
 )
 
 // StaticHandlerBuilder is the web file system's Handler builder
@@ -153,8 +155,26 @@ func (w *fsHandler) Build() HandlerFunc {
 		h := func(ctx *Context) {
 			writer := ctx.ResponseWriter
 
-			if w.gzip && ctx.clientAllowsGzip() {
+			// the ranges are served uncompressed, they are ranges of the file's bytes.
+			gzip := w.gzip && ctx.clientAllowsGzip() && ctx.RequestHeader(rangeHeader) == ""
+			if w.gzip {
 				ctx.ResponseWriter.Header().Add(varyHeader, acceptEncodingHeader)
+			}
+
+			// the http.FileServer checks the If-None-Match, and the If-Range, against the ETag header.
+			if etag, modtime, ok := w.validators(ctx.Request.URL.Path); ok {
+				if gzip {
+					etag = gzipETag(etag)
+				}
+				ctx.SetHeader(etagHeader, etag)
+				if ctx.clientCacheFresh(etag, modtime) {
+					ctx.SetHeader(lastModified, modtime.UTC().Format(ctx.framework.Config.TimeFormat))
+					ctx.SetStatusCode(http.StatusNotModified)
+					return
+				}
+			}
+
+			if gzip {
 				ctx.SetHeader(contentEncodingHeader, "gzip")
 				gzipResWriter := acquireGzipResponseWriter(ctx.ResponseWriter) //.ResponseWriter)
 				writer = gzipResWriter
@@ -183,6 +203,44 @@ func (w *fsHandler) Build() HandlerFunc {
 	return w.handler
 }
 
+// validators returns the ETag and the modification time of the file of the request's path,
+// false if it's not a file or a directory with an index.html, the http.FileServer serves them.
+func (w *fsHandler) validators(reqPath string) (etag string, modtime time.Time, ok bool) {
+	name := reqPath
+	if w.stripPath {
+		name = strings.TrimPrefix(name, w.requestPath)
+	}
+	if !strings.HasPrefix(name, slash) {
+		name = slash + name
+	}
+	name = path.Clean(name)
+
+	f, err := w.filesystem.Open(name)
+	if err != nil {
+		return
+	}
+	info, err := f.Stat()
+	f.Close()
+	if err != nil {
+		return
+	}
+	if info.IsDir() {
+		// the http.FileServer redirects the directories without the trailing slash.
+		if !strings.HasSuffix(reqPath, slash) {
+			return
+		}
+		if f, err = w.filesystem.Open(path.Join(name, "index.html")); err != nil {
+			return
+		}
+		info, err = f.Stat()
+		f.Close()
+		if err != nil || info.IsDir() {
+			return
+		}
+	}
+	return fileETag(info.ModTime(), info.Size()), info.ModTime(), true
+}
+
 // StripPrefix returns a handler that serves HTTP requests
 // by removing the given prefix from the request URL's Path
 // and invoking the handler h. StripPrefix handles a
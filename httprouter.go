@@ -55,6 +55,7 @@ type (
 		tokens      string
 		nodes       []*muxEntry
 		middleware  Middleware
+		route       RouteInfo // the route of the middleware, nil on macro nodes
 		precedence  uint64
 		paramsLen   uint8
 	}
@@ -112,7 +113,7 @@ func findLower(a, b int) int {
 }
 
 // add adds a muxEntry to the existing muxEntry or to the tree if no muxEntry has the prefix of
-func (e *muxEntry) add(path string, middleware Middleware) error {
+func (e *muxEntry) add(path string, route RouteInfo, middleware Middleware) error {
 	fullPath := path
 	e.precedence++
 	numParams := getParamsLen(path)
@@ -137,6 +138,7 @@ func (e *muxEntry) add(path string, middleware Middleware) error {
 					tokens:      e.tokens,
 					nodes:       e.nodes,
 					middleware:  e.middleware,
+					route:       e.route,
 					precedence:  e.precedence - 1,
 				}
 
@@ -150,6 +152,7 @@ func (e *muxEntry) add(path string, middleware Middleware) error {
 				e.tokens = string([]byte{e.part[i]})
 				e.part = path[:i]
 				e.middleware = nil
+				e.route = nil
 				e.hasWildNode = false
 			}
 
@@ -204,18 +207,19 @@ func (e *muxEntry) add(path string, middleware Middleware) error {
 					e.precedenceTo(len(e.tokens) - 1)
 					e = node
 				}
-				return e.addNode(numParams, path, fullPath, middleware)
+				return e.addNode(numParams, path, fullPath, route, middleware)
 
 			} else if i == len(path) {
 				if e.middleware != nil {
 					return errMuxEntryMiddlewareAlreadyExists.Format(fullPath)
 				}
 				e.middleware = middleware
+				e.route = route
 			}
 			return nil
 		}
 	} else {
-		if err := e.addNode(numParams, path, fullPath, middleware); err != nil {
+		if err := e.addNode(numParams, path, fullPath, route, middleware); err != nil {
 			return err
 		}
 		e.entryCase = isRoot
@@ -224,7 +228,7 @@ func (e *muxEntry) add(path string, middleware Middleware) error {
 }
 
 // addNode adds a muxEntry as children to other muxEntry
-func (e *muxEntry) addNode(numParams uint8, path string, fullPath string, middleware Middleware) error {
+func (e *muxEntry) addNode(numParams uint8, path string, fullPath string, route RouteInfo, middleware Middleware) error {
 	var offset int
 
 	for i, max := 0, len(path); numParams > 0; i++ {
@@ -311,6 +315,7 @@ func (e *muxEntry) addNode(numParams uint8, path string, fullPath string, middle
 				entryCase:  matchEverything,
 				paramsLen:  1,
 				middleware: middleware,
+				route:      route,
 				precedence: 1,
 			}
 			e.nodes = []*muxEntry{child}
@@ -321,6 +326,7 @@ func (e *muxEntry) addNode(numParams uint8, path string, fullPath string, middle
 
 	e.part = path[offset:]
 	e.middleware = middleware
+	e.route = route
 
 	return nil
 }
@@ -368,6 +374,7 @@ loop:
 						return
 					}
 					if ctx.Middleware = e.middleware; ctx.Middleware != nil {
+						ctx.route = e.route
 						return
 					} else if len(e.nodes) == 1 {
 						e = e.nodes[0]
@@ -380,6 +387,7 @@ loop:
 
 					ctx.Set(e.part[2:], path)
 					ctx.Middleware = e.middleware
+					ctx.route = e.route
 					return
 
 				default:
@@ -388,6 +396,7 @@ loop:
 			}
 		} else if path == e.part {
 			if ctx.Middleware = e.middleware; ctx.Middleware != nil {
+				ctx.route = e.route
 				return
 			}
 
@@ -452,6 +461,8 @@ type (
 		maxParameters uint8
 		methodEqual   func(string, string) bool
 		hosts         bool
+		// macros keeps the compiled route paths for the reverse routing.
+		macros macroCache
 	}
 )
 
@@ -536,8 +547,24 @@ func newRouter() Policies {
 			//
 			// This policy is used for reverse routing,
 			// see Path/URL and ~/adaptors/view/ {{ url }} {{ urlpath }}
+			//
+			// path = "/api/users/:id:int", args = ["kataras"]
+			// return "", the argument doesn't pass the parameter's type
 			URLPath: func(r RouteInfo, args ...string) string {
-				rpath := r.Path()
+				m, err := mux.macros.get(r.Path())
+				if err != nil {
+					return ""
+				}
+				// validate the arguments against the typed parameters, if any.
+				if m.typed {
+					for i, p := range m.params {
+						if i < len(args) && !p.validate(args[i]) {
+							return ""
+						}
+					}
+				}
+
+				rpath := m.path
 				formattedPath := formatPath(rpath)
 
 				if rpath == formattedPath {
@@ -558,36 +585,21 @@ func newRouter() Policies {
 			},
 		},
 		RouterBuilderPolicy: func(repo RouteRepository, context ContextPool) http.Handler {
-			fatalErr := false
 			mux.garden = mux.garden[0:0] // re-set the nodes
 			mux.hosts = false
+			// collect the routes of each tree, in order of registration, first
+			// because the typed routes of the same shape are sharing the same node.
+			treeRoutes := make(map[*muxTree][]RouteInfo)
 			repo.Visit(func(r RouteInfo) {
-				if fatalErr {
-					return
-				}
-				// add to the registry tree
 				method := r.Method()
 				subdomain := r.Subdomain()
-				path := r.Path()
-				middleware := r.Middleware()
 				tree := mux.getTree(method, subdomain)
 				if tree == nil {
 					//first time we register a route to this method with this domain
 					tree = &muxTree{method: method, subdomain: subdomain, entry: &muxEntry{}}
 					mux.garden = append(mux.garden, tree)
 				}
-				// I decide that it's better to explicit give subdomain and a path to it than registeredPath(mysubdomain./something) now its: subdomain: mysubdomain., path: /something
-				// we have different tree for each of subdomains, now you can use everything you can use with the normal paths ( before you couldn't set /any/*path)
-				if err := tree.entry.add(path, middleware); err != nil {
-					// by-default it panics on these errors, but to make sure let's introduce the fatalErr to stop visiting
-					fatalErr = true
-					logger.Println(err.Error())
-					return
-				}
-
-				if mp := tree.entry.paramsLen; mp > mux.maxParameters {
-					mux.maxParameters = mp
-				}
+				treeRoutes[tree] = append(treeRoutes[tree], r)
 
 				// check for method equality if at least one route has cors
 				if r.HasCors() {
@@ -601,11 +613,30 @@ func newRouter() Policies {
 					mux.hosts = true
 				}
 			})
-			if !fatalErr {
-				return mux.buildHandler(context)
+
+			for _, tree := range mux.garden {
+				routes, err := groupMacroRoutes(treeRoutes[tree])
+				if err != nil {
+					logger.Println(err.Error())
+					return nil
+				}
+				// add to the registry tree
+				// I decide that it's better to explicit give subdomain and a path to it than registeredPath(mysubdomain./something) now its: subdomain: mysubdomain., path: /something
+				// we have different tree for each of subdomains, now you can use everything you can use with the normal paths ( before you couldn't set /any/*path)
+				for _, r := range routes {
+					if err := tree.entry.add(r.path, r.route, r.middleware); err != nil {
+						// by-default it panics on these errors, stop the building here
+						logger.Println(err.Error())
+						return nil
+					}
+				}
+
+				if mp := tree.entry.paramsLen; mp > mux.maxParameters {
+					mux.maxParameters = mp
+				}
 			}
-			return nil
 
+			return mux.buildHandler(context)
 		},
 	}
 }
@@ -620,88 +651,201 @@ func (mux *serveMux) getTree(method string, subdomain string) *muxTree {
 	return nil
 }
 
-func (mux *serveMux) buildHandler(pool ContextPool) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		pool.Run(w, r, func(context *Context) {
-			routePath := context.Path()
-			for i := range mux.garden {
-				tree := mux.garden[i]
-				if !mux.methodEqual(context.Request.Method, tree.method) {
-					continue
+// matchesHost returns true if the tree can serve the request's host,
+// trees without subdomain are always matching.
+func (mux *serveMux) matchesHost(context *Context, tree *muxTree) bool {
+	if !mux.hosts || tree.subdomain == "" {
+		return true
+	}
+
+	requestHost := context.Host()
+	hostname := context.Framework().Config.VHost
+	// println("mux are true and tree.subdomain= " + tree.subdomain + "and hostname = " + hostname + " host = " + requestHost)
+	if requestHost != hostname {
+		// we have a subdomain
+		if strings.Contains(tree.subdomain, DynamicSubdomainIndicator) {
+			return true
+		}
+		// go to the next tree, we have a subdomain but it is not the correct
+		return tree.subdomain+hostname == requestHost
+	}
+	//("it's subdomain but the request is not the same as the vhost)
+	return false
+}
+
+// serve finds and executes the route of the method's tree,
+// it returns false if no route found for this method.
+func (mux *serveMux) serve(context *Context, method string) bool {
+	routePath := context.Path()
+	for i := range mux.garden {
+		tree := mux.garden[i]
+		if !mux.methodEqual(method, tree.method) {
+			continue
+		}
+
+		if !mux.matchesHost(context, tree) {
+			continue
+		}
+
+		mustRedirect := tree.entry.get(routePath, context) // pass the parameters here for 0 allocation
+		if context.Middleware != nil {
+			// ok we found the correct route, serve it and exit entirely from here
+			//ctx.Request.Header.SetUserAgentBytes(DefaultUserAgent)
+			context.Do()
+			return true
+		} else if mustRedirect && !context.Framework().Config.DisablePathCorrection { // && context.Method() == MethodConnect {
+			reqPath := routePath
+			pathLen := len(reqPath)
+
+			if pathLen > 1 {
+				if reqPath[pathLen-1] == '/' {
+					reqPath = reqPath[:pathLen-1] //remove the last /
+				} else {
+					//it has path prefix, it doesn't ends with / and it hasn't be found, then just add the slash
+					reqPath = reqPath + "/"
 				}
 
-				if mux.hosts && tree.subdomain != "" {
-
-					requestHost := context.Host()
-					hostname := context.Framework().Config.VHost
-					// println("mux are true and tree.subdomain= " + tree.subdomain + "and hostname = " + hostname + " host = " + requestHost)
-					if requestHost != hostname {
-						// we have a subdomain
-						if strings.Contains(tree.subdomain, DynamicSubdomainIndicator) {
-						} else {
-							if tree.subdomain+hostname != requestHost {
-								// go to the next tree, we have a subdomain but it is not the correct
-								continue
-							}
-						}
-					} else {
-						//("it's subdomain but the request is not the same as the vhost)
-						continue
-					}
+				urlToRedirect := reqPath
+
+				statusForRedirect := http.StatusMovedPermanently //	StatusMovedPermanently, this document is obselte, clients caches this.
+				if tree.method == MethodPost ||
+					tree.method == MethodPut ||
+					tree.method == MethodDelete {
+					statusForRedirect = http.StatusTemporaryRedirect //	To maintain POST data
 				}
 
-				mustRedirect := tree.entry.get(routePath, context) // pass the parameters here for 0 allocation
-				if context.Middleware != nil {
-					// ok we found the correct route, serve it and exit entirely from here
-					//ctx.Request.Header.SetUserAgentBytes(DefaultUserAgent)
-					context.Do()
-					return
-				} else if mustRedirect && !context.Framework().Config.DisablePathCorrection { // && context.Method() == MethodConnect {
-					reqPath := routePath
-					pathLen := len(reqPath)
-
-					if pathLen > 1 {
-						if reqPath[pathLen-1] == '/' {
-							reqPath = reqPath[:pathLen-1] //remove the last /
-						} else {
-							//it has path prefix, it doesn't ends with / and it hasn't be found, then just add the slash
-							reqPath = reqPath + "/"
-						}
+				context.Redirect(urlToRedirect, statusForRedirect)
+				// RFC2616 recommends that a short note "SHOULD" be included in the
+				// response because older user agents may not understand 301/307.
+				// Shouldn't send the response for POST or HEAD; that leaves GET.
+				if tree.method == MethodGet {
+					note := "<a href=\"" + HTMLEscape(urlToRedirect) + "\">Moved Permanently</a>.\n"
+					// ignore error
+					context.WriteString(note)
+				}
+				return true
+			}
+		}
+		// not found
+		break
+	}
+	return false
+}
 
-						urlToRedirect := reqPath
+// allowedMethods returns the methods which have a route for the request's path,
+// the result is sorted as the AllMethods are.
+func (mux *serveMux) allowedMethods(context *Context) []string {
+	cfg := context.Framework().Config
+	routePath := context.Path()
+	found := make(map[string]bool)
 
-						statusForRedirect := http.StatusMovedPermanently //	StatusMovedPermanently, this document is obselte, clients caches this.
-						if tree.method == MethodPost ||
-							tree.method == MethodPut ||
-							tree.method == MethodDelete {
-							statusForRedirect = http.StatusTemporaryRedirect //	To maintain POST data
-						}
+	for i := range mux.garden {
+		tree := mux.garden[i]
+		if tree.method == MethodNone || found[tree.method] || !mux.matchesHost(context, tree) {
+			continue
+		}
 
-						context.Redirect(urlToRedirect, statusForRedirect)
-						// RFC2616 recommends that a short note "SHOULD" be included in the
-						// response because older user agents may not understand 301/307.
-						// Shouldn't send the response for POST or HEAD; that leaves GET.
-						if tree.method == MethodGet {
-							note := "<a href=\"" + HTMLEscape(urlToRedirect) + "\">Moved Permanently</a>.\n"
-							// ignore error
-							context.WriteString(note)
-						}
-						return
-					}
+		tree.entry.get(routePath, context)
+		if context.Middleware != nil && macroMatches(context) {
+			found[tree.method] = true
+			if tree.method == MethodGet && !cfg.DisableAutoHead {
+				found[MethodHead] = true
+			}
+		}
+		// we're only looking, reset the parameters and the route's handlers.
+		context.Middleware = nil
+		context.route = nil
+		context.values.Reset()
+	}
+
+	if len(found) > 0 && !cfg.DisableAutoOptions {
+		found[MethodOptions] = true
+	}
+
+	var allowed []string
+	for _, m := range AllMethods {
+		if found[m] {
+			allowed = append(allowed, m)
+		}
+	}
+	return allowed
+}
+
+const (
+	// allowHeader is the response header which lists the methods of a resource, "Allow"
+	allowHeader = "Allow"
+	// methodOverrideHeader is the request header which overrides the method of a POST request,
+	// see Configuration.EnableMethodOverride
+	methodOverrideHeader = "X-HTTP-Method-Override"
+	// methodOverrideFormField is the form field which overrides the method of a POST request,
+	// used by the legacy html forms, see Configuration.EnableMethodOverride
+	methodOverrideFormField = "_method"
+)
+
+// overridableMethods are the methods which a POST request can be overridden to,
+// a method override can't turn a POST to a safe method like GET.
+var overridableMethods = []string{MethodPut, MethodPatch, MethodDelete}
+
+// overrideMethod changes the method of a POST request to the one
+// given by the X-HTTP-Method-Override header or by the _method form field,
+// if it's one of the PUT, PATCH or DELETE.
+func overrideMethod(context *Context) {
+	if context.Request.Method != MethodPost {
+		return
+	}
+
+	method := context.RequestHeader(methodOverrideHeader)
+	if method == "" {
+		method = context.PostValue(methodOverrideFormField)
+	}
+	if method == "" {
+		return
+	}
+
+	method = strings.ToUpper(method)
+	for _, m := range overridableMethods {
+		if m == method {
+			context.Request.Method = method
+			return
+		}
+	}
+}
+
+func (mux *serveMux) buildHandler(pool ContextPool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pool.Run(w, r, func(context *Context) {
+			cfg := context.Framework().Config
+			if cfg.EnableMethodOverride {
+				overrideMethod(context)
+			}
+
+			method := context.Request.Method
+			if mux.serve(context, method) {
+				return
+			}
+
+			// HEAD is served by the GET route, if no HEAD route found,
+			// the net/http server doesn't send the body of a HEAD request.
+			if method == MethodHead && !cfg.DisableAutoHead && mux.serve(context, MethodGet) {
+				return
+			}
+
+			if method == MethodOptions && !cfg.DisableAutoOptions {
+				if allowed := mux.allowedMethods(context); len(allowed) > 0 {
+					context.ResponseWriter.Header().Set(allowHeader, strings.Join(allowed, ", "))
+					context.ResponseWriter.Header().Set(contentLength, "0")
+					context.SetStatusCode(http.StatusOK)
+					return
 				}
-				// not found
-				break
 			}
+
 			// https://github.com/kataras/iris/issues/469
-			if context.Framework().Config.FireMethodNotAllowed {
-				for i := range mux.garden {
-					tree := mux.garden[i]
-					if !mux.methodEqual(context.Method(), tree.method) {
-						continue
-					}
+			if cfg.FireMethodNotAllowed {
+				if allowed := mux.allowedMethods(context); len(allowed) > 0 {
+					context.ResponseWriter.Header().Set(allowHeader, strings.Join(allowed, ", "))
+					context.EmitError(http.StatusMethodNotAllowed)
+					return
 				}
-				context.EmitError(http.StatusMethodNotAllowed)
-				return
 			}
 			context.EmitError(http.StatusNotFound)
 		})
@@ -0,0 +1,531 @@
+package iris2
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-iris2/iris2/errors"
+)
+
+//  +------------------------------------------------------------+
+//  | Typed route parameters                                     |
+//  +------------------------------------------------------------+
+//
+// The default router accepts an optional type (and optional validator funcs)
+// right after a named parameter:
+//
+// app.Get("/users/:id:int", ...)                   // -> /users/42
+// app.Get("/users/:id:int{min(1),max(1000)}", ...) // -> /users/1 ... /users/1000
+// app.Get("/files/:name:string{min(3)}", ...)      // -> /files/abc
+// app.Get("/posts/:slug:regexp(^[a-z-]+$)", ...)   // -> /posts/hello-world
+//
+// Available types:
+// string, int, long(int64), uint, bool, alphabetical and regexp(expr).
+//
+// Available validator funcs, inside the {}:
+// min(n), max(n), range(min,max) for the numeric types (by value)
+// and min(n), max(n), range(min,max), prefix(s), suffix(s), contains(s), regexp(expr)
+// for the string types (by length, where applicable).
+//
+// Routes with the same shape, i.e /users/:id:int and /users/:username:string,
+// are tried in the order of registration,
+// if none of their constraints passes then the 404 custom error handler is fired.
+
+const (
+	// macroStartByte is the byte which separates the parameter's name from its type, ':'
+	macroStartByte = byte(':')
+	// macroFuncsStartByte is the byte which starts the validator funcs of a parameter's type, '{'
+	macroFuncsStartByte = byte('{')
+	// macroFuncsEndByte is the byte which ends the validator funcs of a parameter's type, '}'
+	macroFuncsEndByte = byte('}')
+)
+
+var (
+	errMacroUnknownType = errors.New(`
+		httprouter: Unknown parameter type '%s' in route path: '%s'`)
+
+	errMacroUnknownFunc = errors.New(`
+		httprouter: Unknown validator func '%s' for parameter type '%s' in route path: '%s'`)
+
+	errMacroInvalidFunc = errors.New(`
+		httprouter: Invalid validator func '%s' in route path: '%s'. Trace: %s`)
+
+	errMacroUnbalanced = errors.New(`
+		httprouter: Unbalanced parenthesis or braces found in the parameter '%s' of the route path: '%s'`)
+)
+
+type (
+	// macroEvaluator reports whether a path parameter's value passes a type or a validator func.
+	macroEvaluator func(paramValue string) bool
+
+	// paramMacro is a compiled route path parameter,
+	// the evaluators are empty for the untyped ones: ':name' and '*name'.
+	paramMacro struct {
+		name       string
+		typ        string
+		evaluators []macroEvaluator
+	}
+
+	// routeMacros is the result of a route path's compilation.
+	routeMacros struct {
+		// path is the route path without the macros, as the muxEntry understands it.
+		path string
+		// shape is the path without the parameters' names, i.e /users/:/files/*,
+		// routes with the same shape share the same node.
+		shape  string
+		params []*paramMacro
+		typed  bool
+	}
+)
+
+// validate returns true if the value passes all of the parameter's evaluators.
+func (p *paramMacro) validate(paramValue string) bool {
+	for _, evaluator := range p.evaluators {
+		if !evaluator(paramValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// isParamStart returns true if the byte at 'i' starts a named (':') or a wildcard ('*') parameter.
+func isParamStart(path string, i int) bool {
+	c := path[i]
+	return (c == parameterStartByte || c == matchEverythingByte) && (i == 0 || path[i-1] == slashByte)
+}
+
+// compileRouteMacros parses a route path, i.e /users/:id:int{min(1)}/*file,
+// and returns its macro-free path (/users/:id/*file) and its compiled parameters.
+func compileRouteMacros(fullPath string) (*routeMacros, error) {
+	m := &routeMacros{}
+	var path, shape []byte
+
+	for i := 0; i < len(fullPath); i++ {
+		if !isParamStart(fullPath, i) {
+			path = append(path, fullPath[i])
+			shape = append(shape, fullPath[i])
+			continue
+		}
+
+		c := fullPath[i]
+		end := i + 1
+		for end < len(fullPath) && fullPath[end] != slashByte && fullPath[end] != macroStartByte {
+			end++
+		}
+
+		p := &paramMacro{name: fullPath[i+1 : end]}
+		path = append(path, fullPath[i:end]...)
+		shape = append(shape, c)
+
+		if c == parameterStartByte && end < len(fullPath) && fullPath[end] == macroStartByte {
+			// we have a type, find its end, a slash outside of parenthesis and braces.
+			macroEnd, depth := end+1, 0
+			for ; macroEnd < len(fullPath); macroEnd++ {
+				b := fullPath[macroEnd]
+				if b == '(' || b == macroFuncsStartByte {
+					depth++
+				} else if b == ')' || b == macroFuncsEndByte {
+					depth--
+				} else if b == slashByte && depth == 0 {
+					break
+				}
+			}
+			if depth != 0 {
+				return nil, errMacroUnbalanced.Format(p.name, fullPath)
+			}
+
+			if err := p.parse(fullPath[end+1:macroEnd], fullPath); err != nil {
+				return nil, err
+			}
+			m.typed = true
+			end = macroEnd
+		}
+
+		m.params = append(m.params, p)
+		i = end - 1
+	}
+
+	m.path = string(path)
+	m.shape = string(shape)
+	return m, nil
+}
+
+// parse parses the macro, the part after the parameter's name,
+// i.e int{min(1),max(10)} or regexp(^[a-z]+$), and fills the parameter's type and evaluators.
+func (p *paramMacro) parse(macro string, fullPath string) error {
+	typ, rest := macro, ""
+	if idx := strings.IndexAny(macro, "({"); idx > 0 {
+		typ, rest = macro[0:idx], macro[idx:]
+	}
+	p.typ = typ
+
+	evaluator, ok := macroTypes[typ]
+	if !ok {
+		if typ != "regexp" {
+			return errMacroUnknownType.Format(typ, fullPath)
+		}
+		// regexp(expr) is the only type which accepts an argument.
+		if len(rest) == 0 || rest[0] != '(' {
+			return errMacroInvalidFunc.Format(macro, fullPath, "regexp type requires an expression")
+		}
+		expr, after := splitMacroArgs(rest)
+		ev, err := regexpEvaluator(expr)
+		if err != nil {
+			return errMacroInvalidFunc.Format(macro, fullPath, err.Error())
+		}
+		evaluator, rest = ev, after
+	}
+	p.evaluators = append(p.evaluators, evaluator)
+
+	if len(rest) == 0 {
+		return nil
+	}
+	if rest[0] != macroFuncsStartByte || rest[len(rest)-1] != macroFuncsEndByte {
+		return errMacroInvalidFunc.Format(rest, fullPath, "expected {func(args),...}")
+	}
+
+	for _, fn := range splitMacroFuncs(rest[1 : len(rest)-1]) {
+		idx := strings.IndexByte(fn, '(')
+		if idx <= 0 || fn[len(fn)-1] != ')' {
+			return errMacroInvalidFunc.Format(fn, fullPath, "expected func(args)")
+		}
+		name := fn[0:idx]
+		args, _ := splitMacroArgs(fn[idx:])
+
+		builder, ok := macroFuncs[p.kind()][name]
+		if !ok {
+			return errMacroUnknownFunc.Format(name, typ, fullPath)
+		}
+		ev, err := builder(args)
+		if err != nil {
+			return errMacroInvalidFunc.Format(fn, fullPath, err.Error())
+		}
+		p.evaluators = append(p.evaluators, ev)
+	}
+
+	return nil
+}
+
+// kind returns the family of the parameter's type, "number", "bool" or "string",
+// used to find the available validator funcs.
+func (p *paramMacro) kind() string {
+	switch p.typ {
+	case "int", "long", "uint":
+		return "number"
+	case "bool":
+		return "bool"
+	}
+	return "string"
+}
+
+// splitMacroArgs receives "(args)rest" and returns "args" and "rest".
+func splitMacroArgs(s string) (args string, rest string) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], s[i+1:]
+			}
+		}
+	}
+	return s[1:], ""
+}
+
+// splitMacroFuncs splits the validator funcs by comma, ignoring the commas inside the funcs' arguments.
+func splitMacroFuncs(s string) []string {
+	var funcs []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				funcs = append(funcs, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" {
+		funcs = append(funcs, last)
+	}
+	return funcs
+}
+
+func regexpEvaluator(expr string) (macroEvaluator, error) {
+	rx, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return rx.MatchString, nil
+}
+
+// macroTypes are the available parameter types, except the "regexp" which accepts an argument.
+var macroTypes = map[string]macroEvaluator{
+	"string": func(paramValue string) bool {
+		return paramValue != ""
+	},
+	"int": func(paramValue string) bool {
+		_, err := strconv.Atoi(paramValue)
+		return err == nil
+	},
+	"long": func(paramValue string) bool {
+		_, err := strconv.ParseInt(paramValue, 10, 64)
+		return err == nil
+	},
+	"uint": func(paramValue string) bool {
+		_, err := strconv.ParseUint(paramValue, 10, 64)
+		return err == nil
+	},
+	"bool": func(paramValue string) bool {
+		_, err := strconv.ParseBool(paramValue)
+		return err == nil
+	},
+	"alphabetical": func(paramValue string) bool {
+		if paramValue == "" {
+			return false
+		}
+		for _, r := range paramValue {
+			if !unicode.IsLetter(r) {
+				return false
+			}
+		}
+		return true
+	},
+}
+
+// macroFuncBuilder receives the raw arguments of a validator func and returns its evaluator.
+type macroFuncBuilder func(args string) (macroEvaluator, error)
+
+func numberFunc(cmp func(v, a, b float64) bool, nargs int) macroFuncBuilder {
+	return func(args string) (macroEvaluator, error) {
+		parts := strings.Split(args, ",")
+		if len(parts) != nargs {
+			return nil, errors.New("expected " + strconv.Itoa(nargs) + " numeric argument(s)")
+		}
+		nums := make([]float64, 2)
+		for i, part := range parts {
+			n, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return nil, err
+			}
+			nums[i] = n
+		}
+		return func(paramValue string) bool {
+			v, err := strconv.ParseFloat(paramValue, 64)
+			return err == nil && cmp(v, nums[0], nums[1])
+		}, nil
+	}
+}
+
+func lengthFunc(cmp func(v, a, b float64) bool, nargs int) macroFuncBuilder {
+	number := numberFunc(cmp, nargs)
+	return func(args string) (macroEvaluator, error) {
+		ev, err := number(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(paramValue string) bool {
+			return ev(strconv.Itoa(len([]rune(paramValue))))
+		}, nil
+	}
+}
+
+func stringFunc(cmp func(paramValue, arg string) bool) macroFuncBuilder {
+	return func(args string) (macroEvaluator, error) {
+		return func(paramValue string) bool {
+			return cmp(paramValue, args)
+		}, nil
+	}
+}
+
+var (
+	minCmp   = func(v, a, _ float64) bool { return v >= a }
+	maxCmp   = func(v, a, _ float64) bool { return v <= a }
+	rangeCmp = func(v, a, b float64) bool { return v >= a && v <= b }
+)
+
+// macroFuncs are the available validator funcs per type's family.
+var macroFuncs = map[string]map[string]macroFuncBuilder{
+	"number": {
+		"min":   numberFunc(minCmp, 1),
+		"max":   numberFunc(maxCmp, 1),
+		"range": numberFunc(rangeCmp, 2),
+	},
+	"string": {
+		"min":      lengthFunc(minCmp, 1),
+		"max":      lengthFunc(maxCmp, 1),
+		"range":    lengthFunc(rangeCmp, 2),
+		"prefix":   stringFunc(strings.HasPrefix),
+		"suffix":   stringFunc(strings.HasSuffix),
+		"contains": stringFunc(strings.Contains),
+		"regexp":   regexpEvaluator,
+	},
+	"bool": {},
+}
+
+// macroCache keeps the compiled route paths, used on reverse routing.
+type macroCache struct {
+	mu    sync.RWMutex
+	paths map[string]*routeMacros
+}
+
+func (c *macroCache) get(path string) (*routeMacros, error) {
+	c.mu.RLock()
+	m, ok := c.paths[path]
+	c.mu.RUnlock()
+	if ok {
+		return m, nil
+	}
+
+	m, err := compileRouteMacros(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	if c.paths == nil {
+		c.paths = make(map[string]*routeMacros)
+	}
+	c.paths[path] = m
+	c.mu.Unlock()
+	return m, nil
+}
+
+type (
+	// macroCandidate is a route which shares its node with other routes of the same shape.
+	macroCandidate struct {
+		macros     *routeMacros
+		route      RouteInfo
+		middleware Middleware
+	}
+
+	// macroNode is the Handler which is registered to the muxEntry
+	// when one or more typed routes share the same node,
+	// it selects the first route which its parameters pass the constraints.
+	macroNode struct {
+		// params are the parameters as they're named on the muxEntry (first registered route).
+		params     []*paramMacro
+		candidates []*macroCandidate
+	}
+)
+
+var _ Handler = &macroNode{}
+
+// Serve implements the Handler, it executes the first candidate route
+// which passes the constraints, otherwise it fires the 404 error handler.
+func (n *macroNode) Serve(ctx *Context) {
+	for _, c := range n.candidates {
+		if !n.accepts(c, ctx) {
+			continue
+		}
+		// the candidate may name its parameters differently, set them as well.
+		for i, p := range c.macros.params {
+			if name := n.params[i].name; p.name != name {
+				ctx.Set(p.name, ctx.Get(name))
+			}
+		}
+		ctx.Middleware = c.middleware
+		ctx.route = c.route
+		ctx.Do()
+		return
+	}
+
+	ctx.EmitError(http.StatusNotFound)
+}
+
+// match returns true if at least one of the candidate routes passes the constraints.
+func (n *macroNode) match(ctx *Context) bool {
+	for _, c := range n.candidates {
+		if n.accepts(c, ctx) {
+			return true
+		}
+	}
+	return false
+}
+
+// macroMatches returns true if the ctx's route, found by the muxEntry,
+// passes the constraints of its typed parameters, if any.
+func macroMatches(ctx *Context) bool {
+	if len(ctx.Middleware) == 1 {
+		if n, ok := ctx.Middleware[0].(*macroNode); ok {
+			return n.match(ctx)
+		}
+	}
+	return true
+}
+
+func (n *macroNode) accepts(c *macroCandidate, ctx *Context) bool {
+	for i, p := range c.macros.params {
+		if !p.validate(ctx.GetString(n.params[i].name)) {
+			return false
+		}
+	}
+	return true
+}
+
+// macroRoute is a route prepared for the muxTree.
+type macroRoute struct {
+	path       string
+	route      RouteInfo
+	middleware Middleware
+	candidate  *macroCandidate
+	node       *macroNode
+}
+
+// toNode moves the route's middleware to a macroNode, if not already.
+func (mr *macroRoute) toNode() *macroNode {
+	if mr.node == nil {
+		mr.node = &macroNode{
+			params:     mr.candidate.macros.params,
+			candidates: []*macroCandidate{mr.candidate},
+		}
+		mr.middleware = Middleware{mr.node}
+		mr.route = nil
+	}
+	return mr.node
+}
+
+// groupMacroRoutes receives the routes of a tree, in order of registration,
+// and returns the routes which should be added to the tree:
+// routes that share the same shape with at least one typed route are grouped to one macroNode.
+func groupMacroRoutes(routes []RouteInfo) ([]*macroRoute, error) {
+	var result []*macroRoute
+	shapes := make(map[string]*macroRoute)
+
+	for _, r := range routes {
+		m, err := compileRouteMacros(r.Path())
+		if err != nil {
+			return nil, err
+		}
+
+		candidate := &macroCandidate{macros: m, route: r, middleware: r.Middleware()}
+		if prev, ok := shapes[m.shape]; ok && (prev.node != nil || m.typed) {
+			node := prev.toNode()
+			node.candidates = append(node.candidates, candidate)
+			continue
+		}
+
+		mr := &macroRoute{path: m.path, route: r, middleware: candidate.middleware, candidate: candidate}
+		if m.typed {
+			mr.toNode()
+		}
+
+		if _, ok := shapes[m.shape]; !ok {
+			shapes[m.shape] = mr
+		}
+		result = append(result, mr)
+	}
+
+	return result, nil
+}
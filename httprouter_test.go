@@ -1,11 +1,16 @@
 package iris2_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"time"
 	"math/rand"
 	"strconv"
 	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/go-iris2/iris2"
 	"github.com/go-iris2/iris2/httptest"
@@ -296,3 +301,284 @@ func TestRouteURLPath(t *testing.T) {
 		t.Fatalf("iris2's reverse routing 'URLPath' error:  expected %s but got %s", expected, got)
 	}
 }
+
+func TestMuxTypedParameters(t *testing.T) {
+	app := newApp()
+
+	app.Get("/users/:id:int{min(1)}", func(ctx *iris2.Context) {
+		id, _ := ctx.ParamInt("id")
+		ctx.Writef("user id=%d", id)
+	}).ChangeName("user")
+	app.Get("/users/:username:alphabetical", func(ctx *iris2.Context) {
+		ctx.Writef("username=%s", ctx.Param("username"))
+	})
+	app.Get("/files/:name:string{min(3)}", func(ctx *iris2.Context) {
+		ctx.Writef("file=%s", ctx.Param("name"))
+	})
+	app.Get("/posts/:slug:regexp(^[a-z-]+$)", func(ctx *iris2.Context) {
+		ctx.Writef("slug=%s", ctx.Param("slug"))
+	}).ChangeName("post")
+
+	app.OnError(http.StatusNotFound, func(ctx *iris2.Context) {
+		ctx.Writef("custom not found")
+	})
+
+	e := httptest.New(app, t)
+
+	e.GET("/users/42").Expect().Status(http.StatusOK).Body().Equal("user id=42")
+	// falls through to the next route of the same shape
+	e.GET("/users/kataras").Expect().Status(http.StatusOK).Body().Equal("username=kataras")
+	// fails both constraints
+	e.GET("/users/0").Expect().Status(http.StatusNotFound).Body().Equal("custom not found")
+	e.GET("/users/k4t").Expect().Status(http.StatusNotFound).Body().Equal("custom not found")
+
+	e.GET("/files/abc").Expect().Status(http.StatusOK).Body().Equal("file=abc")
+	e.GET("/files/ab").Expect().Status(http.StatusNotFound)
+
+	e.GET("/posts/hello-world").Expect().Status(http.StatusOK).Body().Equal("slug=hello-world")
+	e.GET("/posts/Hello_World").Expect().Status(http.StatusNotFound)
+
+	// reverse routing validates the arguments too
+	if expected, got := "/users/42", app.Path("user", 42); got != expected {
+		t.Fatalf("expected reverse routing path %s but got %s", expected, got)
+	}
+	if got := app.Path("user", "kataras"); got != "" {
+		t.Fatalf("expected an empty reverse routing path for an invalid argument but got %s", got)
+	}
+	if expected, got := "/posts/hello-world", app.Path("post", "hello-world"); got != expected {
+		t.Fatalf("expected reverse routing path %s but got %s", expected, got)
+	}
+}
+
+func TestMuxAutoHeadOptionsAndMethodNotAllowed(t *testing.T) {
+	app := iris2.New(iris2.OptionFireMethodNotAllowed(true))
+
+	app.Get("/users/:id", func(ctx *iris2.Context) {
+		ctx.SetHeader("X-User", ctx.Param("id"))
+		ctx.WriteString("user " + ctx.Param("id"))
+	})
+	app.Delete("/users/:id", func(ctx *iris2.Context) {
+		ctx.WriteString("deleted " + ctx.Param("id"))
+	})
+
+	e := httptest.New(app, t)
+
+	// HEAD is served by the GET route
+	e.HEAD("/users/42").Expect().Status(http.StatusOK).Header("X-User").Equal("42")
+	// OPTIONS answers with the registered methods
+	e.OPTIONS("/users/42").Expect().Status(http.StatusOK).
+		Header("Allow").Equal("GET, DELETE, HEAD, OPTIONS")
+	e.OPTIONS("/nothere").Expect().Status(http.StatusNotFound)
+	// 405 with the Allow header
+	e.PUT("/users/42").Expect().Status(http.StatusMethodNotAllowed).
+		Header("Allow").Equal("GET, DELETE, HEAD, OPTIONS")
+	// 404 when no method has the path
+	e.PUT("/nothere").Expect().Status(http.StatusNotFound)
+}
+
+func TestMuxMethodOverride(t *testing.T) {
+	app := iris2.New(iris2.OptionEnableMethodOverride(true))
+
+	app.Delete("/users/:id", func(ctx *iris2.Context) {
+		ctx.WriteString("deleted " + ctx.Param("id"))
+	})
+	app.Put("/users/:id", func(ctx *iris2.Context) {
+		ctx.WriteString("updated " + ctx.Param("id") + " " + ctx.FormValue("name"))
+	})
+
+	e := httptest.New(app, t)
+
+	e.POST("/users/42").WithHeader("X-HTTP-Method-Override", "DELETE").
+		Expect().Status(http.StatusOK).Body().Equal("deleted 42")
+	e.POST("/users/42").WithFormField("_method", "put").WithFormField("name", "kataras").
+		Expect().Status(http.StatusOK).Body().Equal("updated 42 kataras")
+	// only POST requests can be overridden
+	e.GET("/users/42").WithHeader("X-HTTP-Method-Override", "DELETE").
+		Expect().Status(http.StatusNotFound)
+}
+
+func TestMuxMethodOverrideSafeMethods(t *testing.T) {
+	app := iris2.New(iris2.OptionEnableMethodOverride(true))
+
+	app.Get("/users/:id", func(ctx *iris2.Context) {
+		ctx.WriteString("user " + ctx.Param("id"))
+	})
+	app.Post("/users/:id", func(ctx *iris2.Context) {
+		ctx.WriteString("posted " + ctx.Param("id"))
+	})
+
+	e := httptest.New(app, t)
+
+	// a POST can be overridden only to the PUT, PATCH and DELETE
+	for _, method := range []string{"GET", "HEAD", "CONNECT", "TRACE", "OPTIONS"} {
+		e.POST("/users/42").WithHeader("X-HTTP-Method-Override", method).
+			Expect().Status(http.StatusOK).Body().Equal("posted 42")
+	}
+}
+
+func TestMuxMethodNotAllowedTypedParameters(t *testing.T) {
+	app := iris2.New(iris2.OptionFireMethodNotAllowed(true))
+
+	app.Get("/users/:id:int", func(ctx *iris2.Context) {
+		ctx.WriteString("user " + ctx.Param("id"))
+	})
+	app.Delete("/users/:id:int", func(ctx *iris2.Context) {
+		ctx.WriteString("deleted " + ctx.Param("id"))
+	})
+	app.Post("/users/:name:alphabetical", func(ctx *iris2.Context) {
+		ctx.WriteString("created " + ctx.Param("name"))
+	})
+
+	e := httptest.New(app, t)
+
+	e.PUT("/users/42").Expect().Status(http.StatusMethodNotAllowed).
+		Header("Allow").Equal("GET, DELETE, HEAD, OPTIONS")
+	e.PUT("/users/kataras").Expect().Status(http.StatusMethodNotAllowed).
+		Header("Allow").Equal("POST, OPTIONS")
+	e.OPTIONS("/users/42").Expect().Status(http.StatusOK).
+		Header("Allow").Equal("GET, DELETE, HEAD, OPTIONS")
+	// no route passes the constraints
+	e.PUT("/users/42a").Expect().Status(http.StatusNotFound)
+	e.OPTIONS("/users/42a").Expect().Status(http.StatusNotFound)
+}
+
+func TestMuxGetCurrentRoute(t *testing.T) {
+	app := newApp()
+
+	routeName := func(ctx *iris2.Context) {
+		if r := ctx.GetCurrentRoute(); r != nil {
+			ctx.WriteString(r.Name())
+			return
+		}
+		ctx.WriteString("no route")
+	}
+
+	app.Get("/home", routeName).ChangeName("home")
+	app.Get("/users/:id:int", routeName).ChangeName("user")
+	app.Get("/users/:username:alphabetical", routeName).ChangeName("username")
+	app.Get("/static/*file", routeName).ChangeName("static")
+	app.OnError(http.StatusNotFound, routeName)
+
+	e := httptest.New(app, t)
+
+	e.GET("/home").Expect().Status(http.StatusOK).Body().Equal("home")
+	e.GET("/users/42").Expect().Status(http.StatusOK).Body().Equal("user")
+	e.GET("/users/kataras").Expect().Status(http.StatusOK).Body().Equal("username")
+	e.GET("/static/css/main.css").Expect().Status(http.StatusOK).Body().Equal("static")
+	e.GET("/nothere").Expect().Status(http.StatusNotFound).Body().Equal("no route")
+}
+
+func TestMuxStaticFS(t *testing.T) {
+	app := newApp()
+
+	gz := new(bytes.Buffer)
+	w := gzip.NewWriter(gz)
+	w.Write([]byte("console.log('app')"))
+	w.Close()
+
+	fsys := fstest.MapFS{
+		"index.html":      {Data: []byte("<h1>index</h1>")},
+		"js/app.js":       {Data: []byte("console.log('app')")},
+		"js/app.js.br":    {Data: []byte("brotli")},
+		"js/app.js.gz":    {Data: gz.Bytes()},
+		"css/main.css":    {Data: []byte("body{}"), ModTime: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)},
+		"docs/index.html": {Data: []byte("<h1>docs</h1>")},
+	}
+	app.StaticFS("/static", fsys)
+	app.StaticFS("/app", fsys, iris2.StaticFSOptions{SPA: true})
+
+	e := httptest.New(app, t)
+
+	r := e.GET("/static/css/main.css").Expect().Status(http.StatusOK)
+	r.ContentType("text/css")
+	r.Body().Equal("body{}")
+	etag := r.Raw().Header.Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected an ETag")
+	}
+	r.Header("Last-Modified").Equal("Wed, 01 Mar 2017 00:00:00 GMT")
+	e.GET("/static/css/main.css").WithHeader("If-None-Match", etag).Expect().Status(http.StatusNotModified)
+	e.GET("/static/css/main.css").WithHeader("If-Modified-Since", "Wed, 01 Mar 2017 00:00:00 GMT").
+		Expect().Status(http.StatusNotModified)
+
+	e.GET("/static/js/app.js").WithHeader("Accept-Encoding", "gzip, br").Expect().Status(http.StatusOK).
+		Header("Content-Encoding").Equal("br")
+	e.GET("/static/js/app.js").WithHeader("Accept-Encoding", "gzip, br;q=0").Expect().Status(http.StatusOK).
+		Header("Content-Encoding").Equal("gzip")
+	r = e.GET("/static/js/app.js").WithHeader("Accept-Encoding", "identity").Expect().Status(http.StatusOK)
+	r.Header("Content-Encoding").Empty()
+	r.Header("Vary").Equal("Accept-Encoding")
+	r.Body().Equal("console.log('app')")
+
+	e.GET("/static/").Expect().Status(http.StatusOK).Body().Equal("<h1>index</h1>")
+	e.GET("/static/docs").Expect().Status(http.StatusOK).Body().Equal("<h1>docs</h1>")
+	e.GET("/static/nothere").Expect().Status(http.StatusNotFound)
+
+	e.GET("/app/users/42").Expect().Status(http.StatusOK).Body().Equal("<h1>index</h1>")
+	e.GET("/app/missing.js").Expect().Status(http.StatusNotFound)
+	e.HEAD("/app/js/app.js").WithHeader("Accept-Encoding", "identity").Expect().Status(http.StatusOK).
+		Header("Content-Length").Equal("18")
+}
+
+func TestMuxStaticRange(t *testing.T) {
+	app := newApp()
+
+	dir := t.TempDir()
+	content := "0123456789abcdefghijklmnopqrstuvwxyz"
+	if err := os.WriteFile(filepath.Join(dir, "video.txt"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	app.StaticWeb("/static", dir)
+	app.Get("/gzip/*file", app.StaticHandler("/gzip", dir, false, true))
+	app.Get("/file", func(ctx *iris2.Context) {
+		ctx.ServeFile(filepath.Join(dir, "video.txt"), true)
+	})
+
+	e := httptest.New(app, t)
+
+	for _, p := range []string{"/static/video.txt", "/file"} {
+		r := e.GET(p).WithHeader("Accept-Encoding", "identity").Expect().Status(http.StatusOK)
+		r.Body().Equal(content)
+		r.Header("Accept-Ranges").Equal("bytes")
+		etag := r.Raw().Header.Get("ETag")
+		if etag == "" {
+			t.Fatalf("%s: expected an ETag", p)
+		}
+		e.GET(p).WithHeader("If-None-Match", etag).Expect().Status(http.StatusNotModified).Body().Empty()
+
+		r = e.GET(p).WithHeader("Range", "bytes=0-9").Expect().Status(http.StatusPartialContent)
+		r.Header("Content-Range").Equal("bytes 0-9/36")
+		r.Body().Equal("0123456789")
+		e.GET(p).WithHeader("Range", "bytes=-6").Expect().Status(http.StatusPartialContent).Body().Equal("uvwxyz")
+
+		r = e.GET(p).WithHeader("Range", "bytes=0-1,10-11").Expect().Status(http.StatusPartialContent)
+		r.ContentType("multipart/byteranges")
+		r.Body().Contains("01").Contains("ab").Contains("Content-Range: bytes 10-11/36")
+
+		e.GET(p).WithHeader("Range", "bytes=100-200").Expect().Status(http.StatusRequestedRangeNotSatisfiable).
+			Header("Content-Range").Equal("bytes */36")
+
+		// If-Range with the current ETag serves the range, with an old one the whole file.
+		e.GET(p).WithHeader("Range", "bytes=10-11").WithHeader("If-Range", etag).
+			Expect().Status(http.StatusPartialContent).Body().Equal("ab")
+		e.GET(p).WithHeader("Range", "bytes=10-11").WithHeader("If-Range", `"old"`).
+			Expect().Status(http.StatusOK).Body().Equal(content)
+	}
+
+	r := e.GET("/gzip/video.txt").WithHeader("Accept-Encoding", "gzip").Expect().Status(http.StatusOK)
+	r.Header("Content-Encoding").Equal("gzip")
+	gzipETag := r.Raw().Header.Get("ETag")
+	e.GET("/gzip/video.txt").WithHeader("Accept-Encoding", "gzip").WithHeader("If-None-Match", gzipETag).
+		Expect().Status(http.StatusNotModified)
+
+	// the ranges are not compressed and the compressed response's ETag doesn't match the file's bytes.
+	r = e.GET("/gzip/video.txt").WithHeader("Accept-Encoding", "gzip").WithHeader("Range", "bytes=0-1").
+		Expect().Status(http.StatusPartialContent)
+	r.Header("Content-Encoding").Empty()
+	r.Body().Equal("01")
+	e.GET("/gzip/video.txt").WithHeader("Accept-Encoding", "gzip").WithHeader("Range", "bytes=0-1").
+		WithHeader("If-Range", gzipETag).Expect().Status(http.StatusOK).Body().Equal(content)
+	e.GET("/file").WithHeader("Accept-Encoding", "gzip").WithHeader("Range", "bytes=0-1").
+		Expect().Status(http.StatusPartialContent).Body().Equal("01")
+}
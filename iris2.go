@@ -17,7 +17,6 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"os/signal"
 	"strconv"
 	"strings"
 	"sync"
@@ -104,7 +103,8 @@ type Framework struct {
 	// ConnState type and associated constants for details.
 	ConnState func(net.Conn, http.ConnState) // same as http.Server.ConnState
 
-	// Shutdown gracefully shuts down the server without interrupting any
+	// Shutdown gracefully shuts down all of the servers (see Serve and the Listen functions)
+	// without interrupting any
 	// active connections. Shutdown works by first closing all open
 	// listeners, then closing all idle connections, and then waiting
 	// indefinitely for connections to return to idle and then shut down.
@@ -119,6 +119,13 @@ type Framework struct {
 
 	closedManually bool // true if closed via .Shutdown, used to not throw a panic on s.handlePanic when closing the app's server
 
+	// lifecycle keeps the readiness and the shutdown hooks, see Close, Wait and OnShutdown.
+	lifecycle *lifecycle
+
+	// servers are all of the running servers, one per Serve call.
+	servers   []*http.Server
+	serversMu sync.Mutex
+
 	once sync.Once // used to 'Boot' once
 
 	beforeRenderer HandlerFuncMap
@@ -135,10 +142,15 @@ func (f *Framework) BeforeRender(handlerFn HandlerFuncMap) {
 func New(setters ...OptionSetter) *Framework {
 	cfg := DefaultConfiguration()
 	s := &Framework{
-		Config: &cfg,
-		logger: log.New(os.Stdout, "[iris2] ", log.LstdFlags),
+		Config:    &cfg,
+		logger:    log.New(os.Stdout, "[iris2] ", log.LstdFlags),
+		lifecycle: newLifecycle(),
 	}
 
+	// Set the grace shutdown, it's just a func no need to make things complicated
+	// all are managed by net/http now.
+	s.Shutdown = s.shutdownServers
+
 	//  +------------------------------------------------------------+
 	//  | Set the config passed from setters                         |
 	//  | or use the default one                                     |
@@ -164,6 +176,8 @@ func New(setters ...OptionSetter) *Framework {
 	//  +------------------------------------------------------------+
 	s.Adapt(EventPolicy{Boot: func(s *Framework) {
 		// set the host and scheme
+		s.serversMu.Lock()
+		defer s.serversMu.Unlock()
 		if s.Config.VHost == "" { // if not setted by Listen functions
 			s.Config.VHost = DefaultServerAddr
 		}
@@ -321,7 +335,11 @@ func (f *Framework) handlePanic(err error) {
 	// 	return
 	// }
 
-	if err.Error() == http.ErrServerClosed.Error() && f.closedManually {
+	f.serversMu.Lock()
+	closedManually := f.closedManually
+	f.serversMu.Unlock()
+
+	if err.Error() == http.ErrServerClosed.Error() && closedManually {
 		//.Shutdown was called, log to dev not in prod (prod is only for critical errors.)
 		// also do not try to recover from this error, remember, Shutdown was called manually here.
 		f.Log("HTTP Server closed manually")
@@ -360,9 +378,7 @@ func (f *Framework) Boot() (firstTime bool) {
 	return
 }
 
-func (s *Framework) setupServe() (srv *http.Server, deferFn func()) {
-	s.closedManually = false
-
+func (s *Framework) setupServe(handler http.Handler) (srv *http.Server, deferFn func()) {
 	s.Boot()
 
 	deferFn = func() {
@@ -374,6 +390,7 @@ func (s *Framework) setupServe() (srv *http.Server, deferFn func()) {
 		}
 	}
 
+	s.serversMu.Lock()
 	srv = &http.Server{
 		ReadTimeout:    s.Config.ReadTimeout,
 		WriteTimeout:   s.Config.WriteTimeout,
@@ -382,55 +399,71 @@ func (s *Framework) setupServe() (srv *http.Server, deferFn func()) {
 		ConnState:      s.ConnState,
 		Addr:           s.Config.VHost,
 		ErrorLog:       s.logger,
-		Handler:        s.Router,
+		Handler:        handler,
 	}
+	s.closedManually = false
+	s.servers = append(s.servers, srv)
+	s.serversMu.Unlock()
 
-	// Set the grace shutdown, it's just a func no need to make things complicated
-	// all are managed by net/http now.
-	s.Shutdown = func(ctx context.Context) error {
-		// order matters, look s.handlePanic
-		s.closedManually = true
-		err := srv.Shutdown(ctx)
-		return err
+	s.lifecycle.setReady(true)
+	return
+}
+
+// shutdownServers gracefully shuts down all of the servers at the same time,
+// it returns the first error, if any.
+func (s *Framework) shutdownServers(ctx context.Context) error {
+	s.serversMu.Lock()
+	// order matters, look s.handlePanic
+	s.closedManually = true
+	servers := s.servers
+	s.serversMu.Unlock()
+
+	errCh := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			errCh <- srv.Shutdown(ctx)
+		}(srv)
 	}
 
-	return
+	var err error
+	for range servers {
+		if srvErr := <-errCh; srvErr != nil && err == nil {
+			err = srvErr
+		}
+	}
+	return err
 }
 
 // Serve serves incoming connections from the given listener.
 //
+// Serve can be called more than once, with different listeners,
+// i.e an http and an https listener, all of them are shut down together by the Shutdown/Close.
+//
 // Serve blocks until the given listener returns permanent error.
 func (s *Framework) Serve(ln net.Listener) error {
+	return s.serve(ln, s.Router)
+}
+
+func (s *Framework) serve(ln net.Listener, handler http.Handler) error {
 	if ln == nil {
 		return errors.New("nil net.Listener on Serve")
 	}
 
 	// if user called .Serve and doesn't uses any nginx-like balancers.
+	s.serversMu.Lock()
 	if s.Config.VHost == "" {
 		s.Config.VHost = ParseHost(ln.Addr().String())
 	} // Scheme will be checked from Boot state.
+	s.serversMu.Unlock()
 
-	srv, fn := s.setupServe()
+	srv, fn := s.setupServe(handler)
 	defer fn()
 
-	// print the banner and wait for system channel interrupt
-	go s.postServe()
+	// wait for system channel interrupt, once for all of the servers
+	s.lifecycle.interrupt.Do(func() { go s.postServe() })
 	return srv.Serve(ln)
 }
 
-func (s *Framework) postServe() {
-	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, os.Interrupt)
-	<-ch
-
-	// fire any custom interrupted events and at the end close and exit
-	// if the custom event blocks then it decides what to do next.
-	s.policies.Fire(s.policies.Interrupted, s)
-
-	s.Shutdown(context.Background())
-	os.Exit(1)
-}
-
 // Listen starts the standalone http server
 // which listens to the addr parameter which as the form of
 // host:port
@@ -441,10 +474,12 @@ func (s *Framework) Listen(addr string) {
 
 	// if .Listen called normally and VHost is not setted,
 	// so it's Host is the Real listening addr and user-given
+	s.serversMu.Lock()
 	if s.Config.VHost == "" {
 		s.Config.VHost = addr // as it is
 		// this will be set as the front-end listening addr
 	} // VScheme will be checked on Boot.
+	s.serversMu.Unlock()
 
 	// this check, only here, other Listen functions should throw an error if port is missing.
 	if portIdx := strings.IndexByte(addr, ':'); portIdx < 0 {
@@ -460,6 +495,91 @@ func (s *Framework) Listen(addr string) {
 	s.Must(s.Serve(ln))
 }
 
+// ListenTLS starts the secure, standalone, http server
+// which listens to the addr parameter which as the form of
+// host:port, with the given certificate and key files.
+//
+// It can be used at the same time with other Listen functions, i.e:
+// go app.Listen(":80")
+// app.ListenTLS(":443", "./cert.pem", "./key.pem")
+//
+// If you need to manually monitor any error please use `.Serve(iris2.TLS(...))` instead.
+func (s *Framework) ListenTLS(addr string, certFile, keyFile string) {
+	addr = ParseHost(addr)
+	s.setupListen(addr, SchemeHTTPS)
+
+	ln, err := TLS(addr, certFile, keyFile)
+	if err != nil {
+		s.handlePanic(err)
+		return
+	}
+
+	s.Must(s.Serve(ln))
+}
+
+// ListenAutoTLS starts the secure, standalone, http server
+// which listens to the addr parameter, with certificates which are
+// automatically obtained and renewed by an ACME (i.e Let's Encrypt) CertManager,
+// the golang.org/x/crypto/acme/autocert.Manager is a valid CertManager,
+// its cache (i.e autocert.DirCache) is where the certificates are stored.
+//
+// The optional challengeAddr, usually ":http", starts a second server which
+// answers the ACME "http-01" challenges and redirects the rest of the requests to https.
+//
+// If you need to manually monitor any error please use `.Serve(iris2.AutoTLS(...))` instead.
+func (s *Framework) ListenAutoTLS(addr string, manager CertManager, challengeAddr ...string) {
+	addr = ParseHost(addr)
+	s.setupListen(addr, SchemeHTTPS)
+
+	if len(challengeAddr) > 0 && challengeAddr[0] != "" {
+		challengeLn, err := TCPKeepAlive(challengeAddr[0])
+		if err != nil {
+			s.handlePanic(err)
+			return
+		}
+		go func() {
+			s.Must(s.serve(challengeLn, manager.HTTPHandler(nil)))
+		}()
+	}
+
+	ln, err := AutoTLS(addr, manager)
+	if err != nil {
+		s.handlePanic(err)
+		return
+	}
+
+	s.Must(s.Serve(ln))
+}
+
+// ListenUNIX starts the standalone http server
+// which listens to the unix socket file with the given permissions' mode.
+//
+// If you need to manually monitor any error please use `.Serve(iris2.UNIX(...))` instead.
+func (s *Framework) ListenUNIX(socketFile string, mode os.FileMode) {
+	ln, err := UNIX(socketFile, mode)
+	if err != nil {
+		s.handlePanic(err)
+		return
+	}
+
+	s.Must(s.Serve(ln))
+}
+
+// setupListen sets the VHost and the VScheme, if not setted already, before a Listen.
+func (s *Framework) setupListen(addr string, scheme string) {
+	s.serversMu.Lock()
+	defer s.serversMu.Unlock()
+	// if .Listen called normally and VHost is not setted,
+	// so it's Host is the Real listening addr and user-given
+	if s.Config.VHost == "" {
+		s.Config.VHost = addr // as it is
+		// this will be set as the front-end listening addr
+	}
+	if s.Config.VScheme == "" {
+		s.Config.VScheme = scheme
+	}
+}
+
 func (s *Framework) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.Router.ServeHTTP(w, r)
 }
@@ -511,6 +631,8 @@ func (c *cachedMuxEntry) Serve(ctx *Context) {
 // Note that it depends on a station instance's cache service.
 // Do not try to call it from default' station if you use the form of app := iris2.New(),
 // use the app.Cache instead of iris2.Cache
+//
+// See the middleware/cache for a cache with pluggable stores, tags, ETags and Cache-Control support.
 func (s *Framework) Cache(bodyHandler HandlerFunc, expiration time.Duration) HandlerFunc {
 	ce := newCachedMuxEntry(s, bodyHandler, expiration)
 	return ce.Serve
@@ -0,0 +1,205 @@
+package iris2
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/go-iris2/iris2/errors"
+)
+
+var (
+	errShutdownHook = errors.New("Shutdown hook '%s' failed. Trace: %s")
+	errShutdown     = errors.New("Shutdown failed. Trace: %s")
+)
+
+type (
+	// ShutdownHook is the signature of the functions which are
+	// executed, in order of registration, when the Framework is closing.
+	//
+	// The context is canceled when the hook's deadline is exceeded.
+	ShutdownHook func(ctx context.Context) error
+
+	shutdownHook struct {
+		name    string
+		timeout time.Duration
+		hook    ShutdownHook
+	}
+
+	// lifecycle keeps the state of the Framework's servers,
+	// see Framework.Close and Framework.Wait.
+	lifecycle struct {
+		ready int32 // 1 when serving and not closing
+		mu    sync.Mutex
+		hooks []shutdownHook
+		once  sync.Once
+		done  chan struct{}
+		err   error
+		// interrupt starts the interrupt handler once, for all of the servers.
+		interrupt sync.Once
+	}
+)
+
+func newLifecycle() *lifecycle {
+	return &lifecycle{done: make(chan struct{})}
+}
+
+func (l *lifecycle) setReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&l.ready, v)
+}
+
+func (l *lifecycle) isReady() bool {
+	return atomic.LoadInt32(&l.ready) == 1
+}
+
+// run executes the hook and returns its error,
+// or the context's error if the hook didn't finish before its deadline.
+func (h shutdownHook) run() error {
+	ctx := context.Background()
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- h.hook(ctx)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// OnShutdown registers a hook which is executed when the Framework is closing,
+// after the servers have been drained.
+// Hooks are executed in the order of their registration, each one with its own
+// deadline (a zero or negative timeout means no deadline),
+// i.e app.OnShutdown("database", 5*time.Second, func(ctx context.Context) error { return db.Close() })
+func (f *Framework) OnShutdown(name string, timeout time.Duration, hook ShutdownHook) {
+	f.lifecycle.mu.Lock()
+	f.lifecycle.hooks = append(f.lifecycle.hooks, shutdownHook{name: name, timeout: timeout, hook: hook})
+	f.lifecycle.mu.Unlock()
+}
+
+// IsReady returns true when the Framework is serving requests and it's not closing.
+func (f *Framework) IsReady() bool {
+	return f.lifecycle.isReady()
+}
+
+// ReadinessHandler returns a handler, to be used on readiness probes,
+// which sends 200 OK when the Framework is ready
+// and 503 Service Unavailable when it is closing, see IsReady.
+func (f *Framework) ReadinessHandler() HandlerFunc {
+	return func(ctx *Context) {
+		if f.IsReady() {
+			ctx.SetStatusCode(http.StatusOK)
+			return
+		}
+		ctx.EmitError(http.StatusServiceUnavailable)
+	}
+}
+
+// Close gracefully closes the Framework, only the first call has effect.
+//
+// The readiness is flipped first (see IsReady), after the Configuration.ShutdownDelay
+// the servers are drained, waiting for the active connections
+// until the Configuration.ShutdownTimeout and, at the end,
+// the shutdown hooks are executed (see OnShutdown).
+//
+// It returns the errors of the servers' shutdown and the hooks, if any.
+func (f *Framework) Close() error {
+	l := f.lifecycle
+	l.once.Do(func() {
+		l.setReady(false)
+		if delay := f.Config.ShutdownDelay; delay > 0 {
+			time.Sleep(delay)
+		}
+
+		var errs []string
+		if f.Shutdown != nil {
+			ctx := context.Background()
+			if timeout := f.Config.ShutdownTimeout; timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+			if err := f.Shutdown(ctx); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+
+		l.mu.Lock()
+		hooks := l.hooks
+		l.mu.Unlock()
+		for _, h := range hooks {
+			if err := h.run(); err != nil {
+				errs = append(errs, errShutdownHook.Format(h.name, err.Error()).Error())
+			}
+		}
+
+		if len(errs) > 0 {
+			err := errShutdown.Format(errs[0])
+			for _, e := range errs[1:] {
+				err = err.Append("\n%s", e)
+			}
+			l.err = err
+		}
+		close(l.done)
+	})
+
+	<-l.done
+	return l.err
+}
+
+// Wait blocks until the Framework is closed, manually by Close
+// or by an interrupt signal, and returns the result of the Close.
+func (f *Framework) Wait() error {
+	<-f.lifecycle.done
+	return f.lifecycle.err
+}
+
+// postServe waits for an interrupt (or SIGTERM) signal and closes the Framework,
+// unless the Configuration.DisableInterruptHandler is true.
+func (s *Framework) postServe() {
+	if s.Config.DisableInterruptHandler {
+		return
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(ch)
+
+	select {
+	case <-ch:
+	case <-s.lifecycle.done:
+		// closed manually
+		return
+	}
+
+	// fire any custom interrupted events and at the end close
+	// (and exit, if not disabled by the Configuration.DisableExitOnInterrupt)
+	// if the custom event blocks then it decides what to do next.
+	s.policies.Fire(s.policies.Interrupted, s)
+
+	if err := s.Close(); err != nil {
+		s.Log(err.Error())
+	}
+
+	if !s.Config.DisableExitOnInterrupt {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,160 @@
+// Black-box Testing
+package iris2_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2"
+)
+
+func TestLifecycleCloseAndWait(t *testing.T) {
+	app := iris2.New(iris2.OptionDisableInterruptHandler(true), iris2.OptionShutdownTimeout(time.Second))
+	app.Get("/ready", app.ReadinessHandler())
+
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) {
+		mu.Lock()
+		order = append(order, name)
+		mu.Unlock()
+	}
+	app.OnShutdown("first", 0, func(context.Context) error {
+		record("first")
+		return nil
+	})
+	// exceeds its deadline, the next hooks should not wait for it.
+	app.OnShutdown("slow", 20*time.Millisecond, func(ctx context.Context) error {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	app.OnShutdown("failed", 0, func(context.Context) error {
+		record("failed")
+		return errors.New("failed to close")
+	})
+
+	ln, err := iris2.TCP4("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- app.Serve(ln) }()
+
+	url := "http://" + ln.Addr().String() + "/ready"
+	for i := 0; i < 50 && !app.IsReady(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected readiness status %d but got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- app.Wait() }()
+
+	closeErr := app.Close()
+	if closeErr == nil {
+		t.Fatalf("expected the errors of the slow and the failed hooks")
+	}
+	for _, expected := range []string{"'slow'", context.DeadlineExceeded.Error(), "'failed'", "failed to close"} {
+		if !strings.Contains(closeErr.Error(), expected) {
+			t.Fatalf("expected the Close's error to contain %q but got: %s", expected, closeErr)
+		}
+	}
+	if app.IsReady() {
+		t.Fatalf("expected the framework to be not ready after Close")
+	}
+
+	select {
+	case err := <-waitErr:
+		if err != closeErr {
+			t.Fatalf("expected Wait to return the Close's error '%v' but got '%v'", closeErr, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Wait didn't return after Close")
+	}
+
+	if err := <-serveErr; err != http.ErrServerClosed {
+		t.Fatalf("expected %v but got %v", http.ErrServerClosed, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"first", "failed"}
+	if len(order) != len(expected) {
+		t.Fatalf("expected hooks %v but got %v", expected, order)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Fatalf("expected hooks %v but got %v", expected, order)
+		}
+	}
+
+	// only the first Close has effect
+	if err := app.Close(); err != closeErr {
+		t.Fatalf("expected the same error on the second Close but got %v", err)
+	}
+}
+
+func TestLifecycleInterruptOnce(t *testing.T) {
+	// keep the test process alive if the signal arrives before the Framework's handler.
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	defer signal.Stop(ch)
+
+	app := iris2.New(iris2.OptionDisableExitOnInterrupt(true), iris2.OptionShutdownTimeout(time.Second))
+	var interrupted int32
+	app.Adapt(iris2.EventPolicy{Interrupted: func(*iris2.Framework) {
+		atomic.AddInt32(&interrupted, 1)
+	}})
+
+	serveErr := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		ln, err := iris2.TCP4("127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() { serveErr <- app.Serve(ln) }()
+	}
+	for i := 0; i < 50 && !app.IsReady(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// give the interrupt handler the time to be registered.
+	time.Sleep(50 * time.Millisecond)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+
+	waitErr := make(chan error, 1)
+	go func() { waitErr <- app.Wait() }()
+	select {
+	case <-waitErr:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Wait didn't return after the interrupt")
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-serveErr; err != http.ErrServerClosed {
+			t.Fatalf("expected %v but got %v", http.ErrServerClosed, err)
+		}
+	}
+
+	if n := atomic.LoadInt32(&interrupted); n != 1 {
+		t.Fatalf("expected the Interrupted event to be fired once but fired %d times", n)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/middleware/cache"
+)
+
+func main() {
+	app := iris2.New()
+
+	// the responses of the products are cached for a minute, per page and language.
+	products := cache.New(cache.Config{
+		Expiration:  time.Minute,
+		QueryParams: []string{"page"},
+		VaryHeaders: []string{"Accept-Language"},
+	})
+
+	app.Get("/products", products.Serve, func(ctx *iris2.Context) {
+		// tag the response in order to invalidate it when a product changes.
+		cache.Tag(ctx, "products")
+		ctx.Writef("products of page %s, at %s", ctx.URLParam("page"), time.Now())
+	}).ChangeName("products")
+
+	app.Get("/products/:id", products.Serve, func(ctx *iris2.Context) {
+		cache.Tag(ctx, "products", "product:"+ctx.Param("id"))
+		// the max-age of the response is the life of the entry.
+		ctx.SetHeader("Cache-Control", "public, max-age=300")
+		ctx.Writef("product %s, at %s", ctx.Param("id"), time.Now())
+	})
+
+	app.Post("/products/:id", func(ctx *iris2.Context) {
+		// update the product and remove its responses, the list's ones too.
+		products.InvalidateTag("product:" + ctx.Param("id"))
+		products.Invalidate("products")
+		ctx.Writef("product %s updated", ctx.Param("id"))
+	})
+
+	// the dashboard is cached per session, the sessions adaptor is required.
+	dashboard := cache.New(cache.Config{Expiration: 10 * time.Second, KeyFunc: cache.BySession})
+	app.Get("/dashboard", dashboard.Serve, func(ctx *iris2.Context) {
+		ctx.SetHeader("Cache-Control", "private")
+		ctx.Writef("your dashboard, at %s", time.Now())
+	})
+
+	app.Listen(":8080")
+}
@@ -0,0 +1,375 @@
+// Package cache provides a response cache middleware for Iris,
+// with pluggable stores, tags and strong ETags.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-iris2/iris2"
+)
+
+const (
+	// StatusHeader is the response header which tells if the response is served by the cache,
+	// its value is "HIT", or "MISS" when the response is stored.
+	StatusHeader = "X-Cache"
+	// TagsContextKey is the context-key of the response's tags, see Tag.
+	TagsContextKey = "cache_tags"
+)
+
+// cacheableStatus are the status codes which can be cached, see RFC 7231 section 6.1.
+var cacheableStatus = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+}
+
+// Cache is the response cache middleware, one cache per New,
+// register it to a route or to a Party in order to cache their GET and HEAD responses, i.e
+// c := cache.New(cache.Config{Expiration: time.Minute})
+// app.Get("/", c.Serve, handler).
+//
+// The responses are cached unless the request or the response has a no-store Cache-Control directive,
+// or the response is private, without a KeyFunc, or it sets cookies,
+// or it varies by request headers which are not VaryHeaders, see Config.VaryHeaders.
+// The max-age, and the s-maxage, of the response are the life of the entry.
+// The no-cache requests are passed to the handler, their response replaces the entry.
+type Cache struct {
+	config Config
+	store  Store
+}
+
+var _ iris2.Handler = &Cache{}
+
+// New returns a new response cache middleware
+func New(cfg ...Config) *Cache {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = c.MergeSingle(cfg[0])
+	}
+
+	store := c.Store
+	if store == nil {
+		store = NewMemoryStore(DefaultMaxEntries, 0)
+	}
+	return &Cache{config: c, store: store}
+}
+
+// Tag adds invalidation tags to the response of the request, see Cache.InvalidateTag.
+func Tag(ctx *iris2.Context, tags ...string) {
+	existing, _ := ctx.Get(TagsContextKey).([]string)
+	ctx.Set(TagsContextKey, append(existing, tags...))
+}
+
+// BySession is the KeyFunc which caches the responses per session,
+// it starts a session if the client has not any, the sessions adaptor is required.
+func BySession(ctx *iris2.Context) string {
+	if s := ctx.Session(); s != nil {
+		return "session:" + s.ID()
+	}
+	return ""
+}
+
+// ByBasicAuthUser is the KeyFunc which caches the responses per basic authentication's username.
+//
+// Register it after the basicauth middleware, the credentials are not validated here.
+func ByBasicAuthUser(ctx *iris2.Context) string {
+	if user, _, ok := ctx.Request.BasicAuth(); ok {
+		return "user:" + user
+	}
+	return ""
+}
+
+// Serve serves the response from the cache, if any, otherwise it calls the next handlers
+// and stores their response.
+func (c *Cache) Serve(ctx *iris2.Context) {
+	method := ctx.Method()
+	if method != http.MethodGet && method != http.MethodHead {
+		ctx.Next()
+		return
+	}
+
+	reqDirectives := parseCacheControl(ctx.RequestHeader("Cache-Control"))
+	if _, ok := reqDirectives["no-store"]; ok {
+		ctx.Next()
+		return
+	}
+
+	client := ""
+	if c.config.KeyFunc != nil {
+		client = c.config.KeyFunc(ctx)
+	}
+	key := c.key(ctx, client)
+
+	if !reqDirectives.noCache() {
+		entry, err := c.store.Get(key)
+		if err != nil {
+			// serve the request without the cache.
+			ctx.Log("cache: %s", err)
+		} else if entry != nil {
+			c.serveEntry(ctx, entry)
+			return
+		}
+	}
+
+	ctx.Record()
+	ctx.Next()
+	c.save(ctx, key, client)
+}
+
+// Invalidate removes the cached responses of the route with this name, see RouteInfo.ChangeName.
+func (c *Cache) Invalidate(routeName string) error {
+	return c.InvalidateTag(routeTag(routeName))
+}
+
+// InvalidateTag removes the cached responses with these tags, see Tag.
+func (c *Cache) InvalidateTag(tags ...string) error {
+	for _, tag := range tags {
+		if err := c.store.DeleteTag(c.config.Prefix + tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func routeTag(name string) string {
+	return "route:" + name
+}
+
+// key returns the entry's key of the request,
+// the method, the host, the path, the query parameters, the VaryHeaders and the client.
+func (c *Cache) key(ctx *iris2.Context, client string) string {
+	parts := url.Values{}
+	if !c.config.IgnoreQuery {
+		query := ctx.Request.URL.Query()
+		if len(c.config.QueryParams) > 0 {
+			for _, name := range c.config.QueryParams {
+				if values, ok := query[name]; ok {
+					parts["q:"+name] = values
+				}
+			}
+		} else {
+			for name, values := range query {
+				parts["q:"+name] = values
+			}
+		}
+	}
+	for _, name := range c.config.VaryHeaders {
+		if values, ok := ctx.Request.Header[http.CanonicalHeaderKey(name)]; ok {
+			parts["h:"+name] = values
+		}
+	}
+	if client != "" {
+		parts.Set("c", client)
+	}
+
+	// the HEAD requests are served by the GET responses.
+	return c.config.Prefix + http.MethodGet + " " + ctx.Host() + ctx.Request.URL.Path + "?" + parts.Encode()
+}
+
+// save stores the recorded response, if it can be cached.
+func (c *Cache) save(ctx *iris2.Context, key string, client string) {
+	w, ok := ctx.IsRecording()
+	if !ok || ctx.Method() != http.MethodGet {
+		return
+	}
+	status := w.StatusCode()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	header := w.Header()
+	if !cacheableStatus[status] || header.Get("Set-Cookie") != "" {
+		return
+	}
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return
+	}
+	if _, ok := directives["private"]; ok && client == "" {
+		return
+	}
+	ttl, ok := directives.maxAge()
+	if !ok {
+		ttl = c.config.Expiration
+	}
+	if ttl <= 0 || !c.covers(header) {
+		return
+	}
+
+	vary := varyHeaders(header)
+	for _, name := range c.config.VaryHeaders {
+		if !containsHeader(vary, name) {
+			header.Add("Vary", name)
+		}
+	}
+	body := w.Body()
+	if !c.config.DisableETag && header.Get("ETag") == "" {
+		header.Set("ETag", strongETag(body))
+	}
+
+	entry := &Entry{
+		StatusCode: status,
+		Header:     make(http.Header, len(header)),
+		Body:       append([]byte(nil), body...),
+		Created:    time.Now(),
+	}
+	for k, values := range header {
+		if k != StatusHeader {
+			entry.Header[k] = append([]string(nil), values...)
+		}
+	}
+	tags, _ := ctx.Get(TagsContextKey).([]string)
+	if r := ctx.GetCurrentRoute(); r != nil && r.Name() != "" {
+		tags = append(tags, routeTag(r.Name()))
+	}
+	for _, tag := range tags {
+		entry.Tags = append(entry.Tags, c.config.Prefix+tag)
+	}
+
+	if err := c.store.Set(key, entry, ttl); err != nil {
+		ctx.Log("cache: %s", err)
+	} else {
+		header.Set(StatusHeader, "MISS")
+	}
+
+	if !c.config.DisableETag && etagMatch(ctx.RequestHeader("If-None-Match"), header.Get("ETag")) {
+		w.ResetBody()
+		w.WriteHeader(http.StatusNotModified)
+	}
+}
+
+// covers reports whether the entry's key, by the VaryHeaders, selects the response,
+// the response's Vary and the Accept-Encoding of a Content-Encoding must be VaryHeaders,
+// otherwise the response could be served to clients which can't accept it.
+func (c *Cache) covers(header http.Header) bool {
+	for _, name := range varyHeaders(header) {
+		if name == "*" || !containsHeader(c.config.VaryHeaders, name) {
+			return false
+		}
+	}
+	if encoding := header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return containsHeader(c.config.VaryHeaders, "Accept-Encoding")
+	}
+	return true
+}
+
+// varyHeaders returns the header names of the response's Vary.
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// containsHeader reports whether the names contain the header name, case-insensitive.
+func containsHeader(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// serveEntry writes the cached response, or a 304 Not Modified if the client's one is fresh.
+func (c *Cache) serveEntry(ctx *iris2.Context, entry *Entry) {
+	header := ctx.ResponseWriter.Header()
+	for k, values := range entry.Header {
+		header[k] = append([]string(nil), values...)
+	}
+	header.Set(StatusHeader, "HIT")
+	header.Set("Age", strconv.Itoa(int(time.Since(entry.Created)/time.Second)))
+	if header.Get("Last-Modified") == "" {
+		header.Set("Last-Modified", entry.Created.UTC().Format(http.TimeFormat))
+	}
+
+	if !c.config.DisableETag && etagMatch(ctx.RequestHeader("If-None-Match"), header.Get("ETag")) {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		ctx.SetStatusCode(http.StatusNotModified)
+		return
+	}
+
+	ctx.SetStatusCode(entry.StatusCode)
+	if ctx.Method() != http.MethodHead {
+		ctx.Write(entry.Body)
+	}
+}
+
+// strongETag returns a strong ETag of the body.
+func strongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+}
+
+// etagMatch reports whether the If-None-Match header matches the etag, by the weak comparison.
+func etagMatch(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheControl are the directives of a Cache-Control header.
+type cacheControl map[string]string
+
+func parseCacheControl(header string) cacheControl {
+	cc := cacheControl{}
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value := part, ""
+		if idx := strings.IndexByte(part, '='); idx > 0 {
+			name, value = part[:idx], strings.Trim(part[idx+1:], `"`)
+		}
+		cc[strings.ToLower(name)] = value
+	}
+	return cc
+}
+
+// noCache reports whether the request asks for a fresh response.
+func (cc cacheControl) noCache() bool {
+	if _, ok := cc["no-cache"]; ok {
+		return true
+	}
+	return cc["max-age"] == "0"
+}
+
+// maxAge returns the s-maxage, or the max-age, of the response.
+func (cc cacheControl) maxAge() (time.Duration, bool) {
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if value, ok := cc[name]; ok {
+			if seconds, err := strconv.Atoi(value); err == nil {
+				return time.Duration(seconds) * time.Second, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,204 @@
+package cache_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/httptest"
+	"github.com/go-iris2/iris2/middleware/cache"
+)
+
+func TestCacheETag(t *testing.T) {
+	app := iris2.New()
+	c := cache.New()
+	n := 0
+	app.Get("/", c.Serve, func(ctx *iris2.Context) {
+		n++
+		ctx.Writef("hello %d", n)
+	})
+
+	e := httptest.New(app, t)
+	r := e.GET("/").Expect().Status(http.StatusOK)
+	r.Body().Equal("hello 1")
+	r.Header(cache.StatusHeader).Equal("MISS")
+	etag := r.Raw().Header.Get("ETag")
+	if etag == "" {
+		t.Fatalf("expected a strong ETag on the stored response")
+	}
+
+	r = e.GET("/").Expect().Status(http.StatusOK)
+	r.Body().Equal("hello 1")
+	r.Header(cache.StatusHeader).Equal("HIT")
+	r.Header("ETag").Equal(etag)
+
+	e.GET("/").WithHeader("If-None-Match", etag).Expect().
+		Status(http.StatusNotModified).Body().Empty()
+	e.GET("/").WithHeader("If-None-Match", `"other"`).Expect().
+		Status(http.StatusOK).Body().Equal("hello 1")
+	e.HEAD("/").Expect().Status(http.StatusOK).Header(cache.StatusHeader).Equal("HIT")
+
+	// a no-cache request replaces the entry, its fresh ETag is matched too.
+	r = e.GET("/").WithHeader("Cache-Control", "no-cache").Expect().Status(http.StatusOK)
+	r.Body().Equal("hello 2")
+	e.GET("/").WithHeader("If-None-Match", r.Raw().Header.Get("ETag")).Expect().
+		Status(http.StatusNotModified).Body().Empty()
+}
+
+func TestCacheNotStored(t *testing.T) {
+	app := iris2.New()
+	c := cache.New()
+	n := 0
+	app.Get("/nostore", c.Serve, func(ctx *iris2.Context) {
+		n++
+		ctx.SetHeader("Cache-Control", "no-store")
+		ctx.Writef("%d", n)
+	})
+	app.Get("/cookie", c.Serve, func(ctx *iris2.Context) {
+		n++
+		ctx.SetCookieKV("name", "value")
+		ctx.Writef("%d", n)
+	})
+	app.Get("/error", c.Serve, func(ctx *iris2.Context) {
+		n++
+		ctx.SetStatusCode(http.StatusInternalServerError)
+		ctx.Writef("%d", n)
+	})
+
+	e := httptest.New(app, t)
+	for _, path := range []string{"/nostore", "/cookie", "/error"} {
+		first := e.GET(path).Expect()
+		first.Header(cache.StatusHeader).Empty()
+		second := e.GET(path).Expect()
+		second.Header(cache.StatusHeader).Empty()
+		if first.Raw().Header.Get("ETag") != "" {
+			t.Fatalf("%s: expected no ETag on a response which is not stored", path)
+		}
+	}
+	if n != 6 {
+		t.Fatalf("expected the handlers to be called on each request but called %d times", n)
+	}
+}
+
+func TestCacheHost(t *testing.T) {
+	app := iris2.New(iris2.Configuration{VHost: "example.com:9999"})
+	c := cache.New()
+	app.Party("*.").Get("/", c.Serve, func(ctx *iris2.Context) {
+		ctx.WriteString(ctx.Subdomain())
+	})
+
+	e := httptest.New(app, t)
+	e.GET("/").WithURL("http://a.example.com:9999").Expect().Body().Equal("a")
+	r := e.GET("/").WithURL("http://b.example.com:9999").Expect()
+	r.Body().Equal("b")
+	r.Header(cache.StatusHeader).Equal("MISS")
+	r = e.GET("/").WithURL("http://a.example.com:9999").Expect()
+	r.Body().Equal("a")
+	r.Header(cache.StatusHeader).Equal("HIT")
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	app := iris2.New()
+	c := cache.New()
+	n := 0
+	app.Get("/home", c.Serve, func(ctx *iris2.Context) {
+		n++
+		ctx.Writef("home %d", n)
+	}).ChangeName("home")
+	app.Get("/list", c.Serve, func(ctx *iris2.Context) {
+		n++
+		cache.Tag(ctx, "list", "items")
+		ctx.Writef("list %d", n)
+	})
+
+	e := httptest.New(app, t)
+	e.GET("/home").Expect().Body().Equal("home 1")
+	e.GET("/list").Expect().Body().Equal("list 2")
+	e.GET("/home").Expect().Body().Equal("home 1")
+	e.GET("/list").Expect().Body().Equal("list 2")
+
+	if err := c.Invalidate("home"); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/home").Expect().Body().Equal("home 3")
+	e.GET("/list").Expect().Body().Equal("list 2")
+
+	if err := c.InvalidateTag("items"); err != nil {
+		t.Fatal(err)
+	}
+	e.GET("/list").Expect().Body().Equal("list 4")
+	e.GET("/home").Expect().Body().Equal("home 3")
+}
+
+func TestCacheHeadersNotDuplicated(t *testing.T) {
+	app := iris2.New()
+	c := cache.New(cache.Config{VaryHeaders: []string{"Accept-Language"}})
+	app.Get("/", c.Serve, func(ctx *iris2.Context) {
+		ctx.SetHeader("X-Custom", "value")
+		ctx.WriteString("body")
+	})
+
+	e := httptest.New(app, t)
+	for _, status := range []string{"MISS", "HIT"} {
+		r := e.GET("/").Expect().Status(http.StatusOK)
+		r.Header(cache.StatusHeader).Equal(status)
+		header := r.Raw().Header
+		for _, name := range []string{"X-Custom", "Vary", "ETag", cache.StatusHeader} {
+			if values := header[http.CanonicalHeaderKey(name)]; len(values) != 1 {
+				t.Fatalf("%s: expected one %s header but got %v", status, name, values)
+			}
+		}
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	app := iris2.New()
+	c := cache.New()
+	n := 0
+	app.Get("/vary", c.Serve, func(ctx *iris2.Context) {
+		n++
+		ctx.SetHeader("Vary", "Accept-Language")
+		ctx.Writef("%d", n)
+	})
+	app.Get("/encoded", c.Serve, func(ctx *iris2.Context) {
+		n++
+		ctx.SetHeader("Content-Encoding", "gzip")
+		ctx.Writef("%d", n)
+	})
+
+	e := httptest.New(app, t)
+	for _, path := range []string{"/vary", "/encoded"} {
+		e.GET(path).Expect().Header(cache.StatusHeader).Empty()
+		e.GET(path).Expect().Header(cache.StatusHeader).Empty()
+	}
+	if n != 4 {
+		t.Fatalf("expected the responses which vary by other headers not to be stored but the handlers were called %d times", n)
+	}
+}
+
+func TestCacheVaryHeaders(t *testing.T) {
+	app := iris2.New()
+	c := cache.New(cache.Config{VaryHeaders: []string{"Accept-Encoding", "accept-language"}})
+	app.Get("/", c.Serve, func(ctx *iris2.Context) {
+		ctx.SetHeader("Vary", "Accept-Language")
+		if ctx.RequestHeader("Accept-Encoding") == "gzip" {
+			ctx.SetHeader("Content-Encoding", "gzip")
+		}
+		ctx.WriteString(ctx.RequestHeader("Accept-Encoding"))
+	})
+
+	e := httptest.New(app, t)
+	for _, status := range []string{"MISS", "HIT"} {
+		r := e.GET("/").WithHeader("Accept-Encoding", "gzip").Expect().Status(http.StatusOK)
+		r.Header(cache.StatusHeader).Equal(status)
+		r.Header("Content-Encoding").Equal("gzip")
+		// the handler's Vary is kept, once.
+		if vary := r.Raw().Header["Vary"]; len(vary) != 2 || vary[0] != "Accept-Language" || vary[1] != "Accept-Encoding" {
+			t.Fatalf("%s: expected the Vary of the handler and the Accept-Encoding but got %v", status, vary)
+		}
+	}
+	// the clients which don't accept the gzip are not served by the compressed response.
+	r := e.GET("/").WithHeader("Accept-Encoding", "identity").Expect().Status(http.StatusOK)
+	r.Header(cache.StatusHeader).Equal("MISS")
+	r.Header("Content-Encoding").Empty()
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/imdario/mergo"
+)
+
+const (
+	// DefaultExpiration is the life of the entries without a max-age, five minutes
+	DefaultExpiration = 5 * time.Minute
+	// DefaultMaxEntries is the max number of the entries of the default in-memory Store, 1024
+	DefaultMaxEntries = 1024
+	// DefaultPrefix is the prefix of the keys, "cache:"
+	DefaultPrefix = "cache:"
+)
+
+// KeyFunc returns the part of the entry's key which identifies the client, i.e the session or the user,
+// the responses are shared between all the clients with the same key.
+type KeyFunc func(ctx *iris2.Context) string
+
+// Config the configs for the cache middleware
+type Config struct {
+	// Expiration is the life of the entries when the response has not a max-age or s-maxage
+	// Cache-Control directive. Default is five minutes
+	Expiration time.Duration
+	// QueryParams are the url query parameters of the entry's key,
+	// the other parameters are ignored. Default is nil, all the parameters
+	QueryParams []string
+	// IgnoreQuery, if true, the url query is not part of the entry's key. Default is false
+	IgnoreQuery bool
+	// VaryHeaders are the request headers of the entry's key, i.e "Accept-Language",
+	// they are added to the response's Vary header too.
+	// The responses which vary by other headers, by their own Vary, are not stored,
+	// as the compressed responses without the "Accept-Encoding". Default is nil
+	VaryHeaders []string
+	// KeyFunc returns the part of the entry's key which identifies the client, i.e BySession or ByBasicAuthUser,
+	// the private responses are cached only with a KeyFunc. Default is nil, the responses are shared
+	KeyFunc KeyFunc
+	// Store keeps the entries. Default is a new in-memory Store with DefaultMaxEntries
+	Store Store
+	// Prefix is the prefix of the keys, use a different one for each cache which shares the same Store.
+	// Default is "cache:"
+	Prefix string
+	// DisableETag, if true, the ETag header is not generated
+	// and the conditional requests are passed to the handler. Default is false
+	DisableETag bool
+}
+
+// DefaultConfig returns the default configs for the cache middleware
+func DefaultConfig() Config {
+	return Config{
+		Expiration: DefaultExpiration,
+		Prefix:     DefaultPrefix,
+	}
+}
+
+// MergeSingle merges the default with the given config and returns the result
+func (c Config) MergeSingle(cfg Config) (config Config) {
+	config = cfg
+	mergo.Merge(&config, c)
+	// the funcs are not merged.
+	if config.KeyFunc == nil {
+		config.KeyFunc = c.KeyFunc
+	}
+	return
+}
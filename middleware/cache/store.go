@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response.
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// Tags are the invalidation tags of the entry, its route's name too, see Cache.InvalidateTag.
+	Tags []string
+	// Created is the time of the response, it's the Last-Modified header.
+	Created time.Time
+	// Expires is the expiration time of the entry.
+	Expires time.Time
+}
+
+// Store is the backend of the entries,
+// it should be safe for use by multiple goroutines.
+type Store interface {
+	// Get returns the entry of the key, nil if it doesn't exist or it's expired.
+	// The returned entry should not be modified.
+	Get(key string) (*Entry, error)
+	// Set stores the entry, it expires after the ttl.
+	Set(key string, entry *Entry, ttl time.Duration) error
+	// Delete removes the entry of the key.
+	Delete(key string) error
+	// DeleteTag removes the entries with the tag.
+	DeleteTag(tag string) error
+}
+
+type (
+	memoryStore struct {
+		mu         sync.Mutex
+		maxEntries int
+		maxBytes   int
+		bytes      int
+		// lru's front is the most recently used element.
+		lru     *list.List
+		entries map[string]*list.Element
+		// tags are the keys of each tag.
+		tags map[string]map[string]struct{}
+	}
+
+	memoryItem struct {
+		key   string
+		entry *Entry
+	}
+)
+
+var _ Store = &memoryStore{}
+
+// NewMemoryStore returns a new in-memory Store,
+// the least recently used entries are removed when there are more than maxEntries entries
+// or their bodies are larger than maxBytes, zero means no limit.
+func NewMemoryStore(maxEntries int, maxBytes int) Store {
+	return &memoryStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+func (s *memoryStore) Get(key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	item := el.Value.(*memoryItem)
+	if !time.Now().Before(item.entry.Expires) {
+		s.remove(el)
+		return nil, nil
+	}
+	s.lru.MoveToFront(el)
+	return item.entry, nil
+}
+
+func (s *memoryStore) Set(key string, entry *Entry, ttl time.Duration) error {
+	e := *entry
+	e.Expires = time.Now().Add(ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	s.entries[key] = s.lru.PushFront(&memoryItem{key: key, entry: &e})
+	s.bytes += len(e.Body)
+	for _, tag := range e.Tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			s.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	for (s.maxEntries > 0 && s.lru.Len() > s.maxEntries) || (s.maxBytes > 0 && s.bytes > s.maxBytes) {
+		s.remove(s.lru.Back())
+	}
+	return nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.mu.Lock()
+	if el, ok := s.entries[key]; ok {
+		s.remove(el)
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *memoryStore) DeleteTag(tag string) error {
+	s.mu.Lock()
+	for key := range s.tags[tag] {
+		s.remove(s.entries[key])
+	}
+	s.mu.Unlock()
+	return nil
+}
+
+// remove removes the element, with the lock.
+func (s *memoryStore) remove(el *list.Element) {
+	item := s.lru.Remove(el).(*memoryItem)
+	delete(s.entries, item.key)
+	s.bytes -= len(item.entry.Body)
+	for _, tag := range item.entry.Tags {
+		if keys, ok := s.tags[tag]; ok {
+			delete(keys, item.key)
+			if len(keys) == 0 {
+				delete(s.tags, tag)
+			}
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package cache_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2/middleware/cache"
+)
+
+func mustGet(t *testing.T, s cache.Store, key string) *cache.Entry {
+	entry, err := s.Get(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry
+}
+
+func TestMemoryStoreMaxEntries(t *testing.T) {
+	s := cache.NewMemoryStore(2, 0)
+	s.Set("a", &cache.Entry{Body: []byte("a")}, time.Minute)
+	s.Set("b", &cache.Entry{Body: []byte("b")}, time.Minute)
+	// "a" is the most recently used now.
+	if mustGet(t, s, "a") == nil {
+		t.Fatalf("expected the entry 'a'")
+	}
+	s.Set("c", &cache.Entry{Body: []byte("c")}, time.Minute)
+
+	if mustGet(t, s, "b") != nil {
+		t.Fatalf("expected the least recently used entry 'b' to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if mustGet(t, s, key) == nil {
+			t.Fatalf("expected the entry '%s' to be kept", key)
+		}
+	}
+}
+
+func TestMemoryStoreMaxBytes(t *testing.T) {
+	s := cache.NewMemoryStore(0, 10)
+	s.Set("a", &cache.Entry{Body: []byte("12345")}, time.Minute)
+	s.Set("b", &cache.Entry{Body: []byte("12345")}, time.Minute)
+	s.Set("c", &cache.Entry{Body: []byte("1")}, time.Minute)
+
+	if mustGet(t, s, "a") != nil {
+		t.Fatalf("expected the entry 'a' to be evicted, the bodies are larger than the maxBytes")
+	}
+	if mustGet(t, s, "b") == nil || mustGet(t, s, "c") == nil {
+		t.Fatalf("expected the entries 'b' and 'c' to be kept")
+	}
+
+	// replacing an entry doesn't count its previous body.
+	s.Set("b", &cache.Entry{Body: []byte("1234567890")}, time.Minute)
+	if mustGet(t, s, "b") == nil {
+		t.Fatalf("expected the replaced entry 'b'")
+	}
+	if mustGet(t, s, "c") != nil {
+		t.Fatalf("expected the entry 'c' to be evicted")
+	}
+}
+
+func TestMemoryStoreExpiration(t *testing.T) {
+	s := cache.NewMemoryStore(0, 0)
+	s.Set("a", &cache.Entry{}, 10*time.Millisecond)
+	if mustGet(t, s, "a") == nil {
+		t.Fatalf("expected the entry 'a'")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if mustGet(t, s, "a") != nil {
+		t.Fatalf("expected the entry 'a' to be expired")
+	}
+}
+
+func TestMemoryStoreDeleteTag(t *testing.T) {
+	s := cache.NewMemoryStore(0, 0)
+	s.Set("a", &cache.Entry{Tags: []string{"x", "y"}}, time.Minute)
+	s.Set("b", &cache.Entry{Tags: []string{"y"}}, time.Minute)
+	s.Set("c", &cache.Entry{Tags: []string{"z"}}, time.Minute)
+
+	if err := s.DeleteTag("y"); err != nil {
+		t.Fatal(err)
+	}
+	if mustGet(t, s, "a") != nil || mustGet(t, s, "b") != nil {
+		t.Fatalf("expected the entries of the tag to be removed")
+	}
+	if mustGet(t, s, "c") == nil {
+		t.Fatalf("expected the entry 'c' to be kept")
+	}
+
+	if err := s.Delete("c"); err != nil {
+		t.Fatal(err)
+	}
+	if mustGet(t, s, "c") != nil {
+		t.Fatalf("expected the entry 'c' to be removed")
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/middleware/compress"
+)
+
+func main() {
+	// the renders are compressed by the client's preferred encoder, "br" and "zstd" too.
+	app := iris2.New(iris2.Configuration{Gzip: true})
+
+	// the bodies larger than 512 bytes are compressed.
+	app.Use(compress.New(compress.Config{MinSize: 512}))
+
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.WriteString(strings.Repeat("Hello compressed world! ", 100))
+	})
+
+	app.Get("/products", func(ctx *iris2.Context) {
+		products := make([]iris2.Map, 100)
+		for i := range products {
+			products[i] = iris2.Map{"id": i, "name": "product"}
+		}
+		ctx.JSON(iris2.StatusOK, products)
+	})
+
+	// the streamed responses are compressed on each flush.
+	app.Get("/stream", func(ctx *iris2.Context) {
+		ctx.SetContentType("text/plain")
+		i := 0
+		ctx.StreamWriter(func(w io.Writer) bool {
+			io.WriteString(w, time.Now().String()+"\n")
+			time.Sleep(500 * time.Millisecond)
+			i++
+			return i < 10
+		})
+	})
+
+	app.Listen(":8080")
+}
@@ -0,0 +1,59 @@
+// Package compress provides a response compression middleware for Iris,
+// which negotiates the "br", "zstd", "gzip" and "deflate" encodings by the Accept-Encoding header.
+//
+// The "br" and "zstd" encoders are registered to Iris by the import of this package,
+// they are negotiated by the renders too, see iris2.Configuration.Gzip.
+package compress
+
+import (
+	"io"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-iris2/iris2"
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	iris2.RegisterEncoder("br", func(w io.Writer, level int) (iris2.EncoderWriter, error) {
+		if level == iris2.DefaultCompression {
+			level = brotli.DefaultCompression
+		}
+		return brotli.NewWriterLevel(w, level), nil
+	})
+	iris2.RegisterEncoder("zstd", func(w io.Writer, level int) (iris2.EncoderWriter, error) {
+		zlevel := zstd.SpeedDefault
+		if level != iris2.DefaultCompression {
+			zlevel = zstd.EncoderLevelFromZstd(level)
+		}
+		// one goroutine per response, the responses are compressed concurrently already.
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zlevel), zstd.WithEncoderConcurrency(1))
+	})
+}
+
+// New returns a new compression middleware, which compresses the responses of the next handlers,
+// their streamed responses too, by the client's preferred encoder, see iris2.Context.Compress.
+//
+// Register it after the cache middleware in order to cache the compressed responses,
+// with the "Accept-Encoding" as one of the cache's VaryHeaders.
+func New(cfg ...Config) iris2.HandlerFunc {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = c.MergeSingle(cfg[0])
+	}
+	opts := iris2.CompressOptions{
+		Level:        c.Level,
+		MinSize:      c.MinSize,
+		ContentTypes: c.ContentTypes,
+	}
+
+	return func(ctx *iris2.Context) {
+		if !ctx.Compress(opts) {
+			ctx.Next()
+			return
+		}
+		ctx.Next()
+		if err := ctx.EndCompress(); err != nil {
+			ctx.Log("compress: %s", err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package compress
+
+import (
+	"github.com/go-iris2/iris2"
+	"github.com/imdario/mergo"
+)
+
+// Config the configs for the compression middleware
+type Config struct {
+	// Level is the compression level of all the encoders,
+	// iris2.DefaultCompression is the default level of each encoder. Default is iris2.DefaultCompression
+	Level int
+	// MinSize is the min size of the compressed bodies, the smaller ones are sent as they are,
+	// the streamed responses are compressed regardless. Default is iris2.DefaultCompressMinSize, 1KB
+	MinSize int
+	// ContentTypes are the compressed media types, a "text/*" matches all the text types.
+	// Default is iris2.DefaultCompressContentTypes
+	ContentTypes []string
+}
+
+// DefaultConfig returns the default configs for the compression middleware
+func DefaultConfig() Config {
+	opts := iris2.DefaultCompressOptions()
+	return Config{
+		Level:        opts.Level,
+		MinSize:      opts.MinSize,
+		ContentTypes: opts.ContentTypes,
+	}
+}
+
+// MergeSingle merges the default with the given config and returns the result
+func (c Config) MergeSingle(cfg Config) (config Config) {
+	config = cfg
+	mergo.Merge(&config, c)
+	return
+}
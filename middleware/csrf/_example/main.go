@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/sessions"
+	"github.com/go-iris2/iris2/adaptors/view"
+	"github.com/go-iris2/iris2/middleware/csrf"
+)
+
+func main() {
+	app := iris2.New()
+	// the tokens are stored on the sessions,
+	// without sessions a double-submit cookie is used instead.
+	app.Adapt(sessions.New(sessions.Config{Cookie: "mysessionid"}))
+	app.Adapt(view.HTML("./templates", ".html"))
+
+	protect := csrf.New()
+	// registers the csrf_field and csrf_token template funcs.
+	app.Adapt(protect)
+
+	app.OnError(http.StatusForbidden, func(ctx *iris2.Context) {
+		ctx.Writef("Forbidden: %s", csrf.Error(ctx))
+	})
+
+	app.Get("/signup", func(ctx *iris2.Context) {
+		// the token is passed to the template's data as "csrf_token".
+		ctx.MustRender("signup.html", iris2.Map{"title": "Sign up"})
+	})
+
+	app.Post("/signup", func(ctx *iris2.Context) {
+		ctx.Writef("welcome %s", ctx.FormValue("username"))
+	})
+
+	// XHR clients can send the token by the X-CSRF-Token header.
+	app.Get("/token", func(ctx *iris2.Context) {
+		ctx.JSON(http.StatusOK, iris2.Map{"token": csrf.Token(ctx)})
+	})
+
+	// protect all the routes, register it after the routes.
+	app.UseGlobal(protect)
+
+	// http://localhost:8080/signup
+	app.Listen(":8080")
+}
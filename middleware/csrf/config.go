@@ -0,0 +1,65 @@
+package csrf
+
+import (
+	"net/http"
+
+	"github.com/imdario/mergo"
+)
+
+const (
+	// DefaultFieldName is the form field and the template data key of the token, "csrf_token"
+	DefaultFieldName = "csrf_token"
+	// DefaultHeaderName is the header which the token can be sent, "X-CSRF-Token"
+	DefaultHeaderName = "X-CSRF-Token"
+	// DefaultCookieName is the double-submit cookie's name, "csrf_token"
+	DefaultCookieName = "csrf_token"
+	// DefaultSessionKey is the session's key which the token is stored, "csrf_token"
+	DefaultSessionKey = "csrf_token"
+	// DefaultTokenLength is the number of the random bytes of a token, 32
+	DefaultTokenLength = 32
+)
+
+// Config the configs for the csrf middleware
+type Config struct {
+	// FieldName is the form field which the token is read from
+	// and the key of the token on the templates' data (ViewData). Default is "csrf_token"
+	FieldName string
+	// HeaderName is the header which the token is read from, before the form field. Default is "X-CSRF-Token"
+	HeaderName string
+	// SessionKey is the session's key which the token is stored,
+	// when a SessionsPolicy is adapted. Default is "csrf_token"
+	SessionKey string
+	// CookieName is the double-submit cookie's name,
+	// used when no SessionsPolicy is adapted. Default is "csrf_token"
+	CookieName string
+	// CookiePath is the double-submit cookie's path. Default is "/"
+	CookiePath string
+	// CookieDomain is the double-submit cookie's domain. Default is empty, the request's host
+	CookieDomain string
+	// CookieSecure sets the Secure flag of the double-submit cookie, enable it when serving over TLS. Default is false
+	CookieSecure bool
+	// CookieSameSite is the SameSite attribute of the double-submit cookie. Default is http.SameSiteLaxMode
+	CookieSameSite http.SameSite
+	// TokenLength is the number of the random bytes of a token. Default is 32
+	TokenLength int
+}
+
+// DefaultConfig returns the default configs for the csrf middleware
+func DefaultConfig() Config {
+	return Config{
+		FieldName:      DefaultFieldName,
+		HeaderName:     DefaultHeaderName,
+		SessionKey:     DefaultSessionKey,
+		CookieName:     DefaultCookieName,
+		CookiePath:     "/",
+		CookieSameSite: http.SameSiteLaxMode,
+		TokenLength:    DefaultTokenLength,
+	}
+}
+
+// MergeSingle merges the default with the given config and returns the result
+func (c Config) MergeSingle(cfg Config) (config Config) {
+	config = cfg
+	mergo.Merge(&config, c)
+	return
+}
@@ -0,0 +1,167 @@
+// Package csrf provides a Cross-Site Request Forgery protection middleware for Iris,
+// the tokens are kept on the sessions or, when no SessionsPolicy is adapted, on a double-submit cookie.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+
+	"github.com/go-iris2/iris2"
+)
+
+const (
+	// TokenContextKey is the context's value key of the request's token, see Token
+	TokenContextKey = "csrf_token"
+	// ErrorContextKey is the context's value key of the reason of a 403 error, see Error
+	ErrorContextKey = "csrf_error"
+
+	// ReasonTokenMissing is the reason of the 403 error when the request has no token
+	ReasonTokenMissing = "CSRF token missing"
+	// ReasonTokenInvalid is the reason of the 403 error when the request's token doesn't match
+	ReasonTokenInvalid = "CSRF token invalid"
+)
+
+// CSRF is the csrf middleware and policy adaptor, see New.
+type CSRF struct {
+	config   Config
+	policies *iris2.Policies
+}
+
+var _ iris2.Handler = &CSRF{}
+
+// New returns the csrf middleware, it should be adapted too
+// in order to register the template funcs and to use the sessions, i.e
+//
+// protect := csrf.New()
+// app.Adapt(protect)
+// app.UseGlobal(protect)
+//
+// The templates can embed the token by {{ csrf_field . }} or {{ csrf_token . }}
+// (the argument is the template's data or the token itself).
+func New(cfg ...Config) *CSRF {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = c.MergeSingle(cfg[0])
+	}
+	return &CSRF{config: c}
+}
+
+// Adapt implements the iris' adaptor, it registers the "csrf_field" and the "csrf_token" template funcs.
+func (c *CSRF) Adapt(frame *iris2.Policies) {
+	c.policies = frame
+
+	iris2.TemplateFuncsPolicy{
+		"csrf_field": c.field,
+		"csrf_token": c.token,
+	}.Adapt(frame)
+}
+
+// Serve serves the middleware, it issues the request's token
+// and validates the tokens of the unsafe (not GET, HEAD, OPTIONS or TRACE) requests,
+// the invalid requests are answered with a 403 Forbidden through the framework's error handlers.
+func (c *CSRF) Serve(ctx *iris2.Context) {
+	token := c.issue(ctx)
+	ctx.Set(TokenContextKey, token)
+	ctx.ViewData(c.config.FieldName, token)
+
+	switch ctx.Method() {
+	case iris2.MethodGet, iris2.MethodHead, iris2.MethodOptions, iris2.MethodTrace:
+		ctx.Next()
+		return
+	}
+
+	sent := ctx.RequestHeader(c.config.HeaderName)
+	if sent == "" {
+		sent = ctx.FormValue(c.config.FieldName)
+	}
+	if sent == "" {
+		c.reject(ctx, ReasonTokenMissing)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+		c.reject(ctx, ReasonTokenInvalid)
+		return
+	}
+
+	ctx.Next()
+}
+
+func (c *CSRF) reject(ctx *iris2.Context, reason string) {
+	ctx.Set(ErrorContextKey, reason)
+	ctx.EmitError(http.StatusForbidden)
+}
+
+func (c *CSRF) hasSessions() bool {
+	return c.policies != nil && c.policies.SessionsPolicy.Start != nil
+}
+
+// issue returns the client's token, a new one is stored if it has not any.
+func (c *CSRF) issue(ctx *iris2.Context) string {
+	if c.hasSessions() {
+		s := ctx.Session()
+		if token := s.GetString(c.config.SessionKey); token != "" {
+			return token
+		}
+		token := c.generate()
+		s.Set(c.config.SessionKey, token)
+		return token
+	}
+
+	if token := ctx.GetCookie(c.config.CookieName); token != "" {
+		return token
+	}
+	token := c.generate()
+	ctx.SetCookie(&http.Cookie{
+		Name:     c.config.CookieName,
+		Value:    token,
+		Path:     c.config.CookiePath,
+		Domain:   c.config.CookieDomain,
+		Secure:   c.config.CookieSecure,
+		HttpOnly: true,
+		SameSite: c.config.CookieSameSite,
+	})
+	return token
+}
+
+func (c *CSRF) generate() string {
+	b := make([]byte, c.config.TokenLength)
+	if _, err := rand.Read(b); err != nil {
+		panic("csrf: unable to generate a token: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// token is the "csrf_token" template func, it returns the token of the template's data.
+func (c *CSRF) token(data interface{}) string {
+	switch v := data.(type) {
+	case string:
+		return v
+	case iris2.Map:
+		s, _ := v[c.config.FieldName].(string)
+		return s
+	case map[string]interface{}:
+		s, _ := v[c.config.FieldName].(string)
+		return s
+	}
+	return ""
+}
+
+// field is the "csrf_field" template func, it returns the hidden input of the template's data token.
+func (c *CSRF) field(data interface{}) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(c.config.FieldName) +
+		`" value="` + template.HTMLEscapeString(c.token(data)) + `">`)
+}
+
+// Token returns the request's token, the middleware should be executed before.
+func Token(ctx *iris2.Context) string {
+	return ctx.GetString(TokenContextKey)
+}
+
+// Error returns the reason of the csrf's 403 error, to be used inside the error handler, i.e
+// app.OnError(http.StatusForbidden, func(ctx *iris2.Context) { ctx.Writef(csrf.Error(ctx)) }).
+func Error(ctx *iris2.Context) string {
+	return ctx.GetString(ErrorContextKey)
+}
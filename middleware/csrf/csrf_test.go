@@ -0,0 +1,131 @@
+package csrf_test
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/sessions"
+	"github.com/go-iris2/iris2/httptest"
+	"github.com/go-iris2/iris2/middleware/csrf"
+)
+
+func newApp(protect *csrf.CSRF, policies ...iris2.Policy) *iris2.Framework {
+	app := iris2.New()
+	app.Adapt(policies...)
+	app.Adapt(protect)
+	app.OnError(http.StatusForbidden, func(ctx *iris2.Context) {
+		ctx.Writef("Forbidden: %s", csrf.Error(ctx))
+	})
+	app.Get("/token", func(ctx *iris2.Context) {
+		ctx.WriteString(csrf.Token(ctx))
+	})
+	app.Any("/submit", func(ctx *iris2.Context) {
+		ctx.WriteString("submitted")
+	})
+	app.UseGlobal(protect)
+	return app
+}
+
+func TestCSRFCookie(t *testing.T) {
+	e := httptest.New(newApp(csrf.New()), t)
+
+	r := e.GET("/token").Expect().Status(http.StatusOK)
+	token := r.Body().Raw()
+	if len(token) != 43 {
+		t.Fatalf("expected a base64 token of 32 bytes but got '%s'", token)
+	}
+	cookie := r.Cookie(csrf.DefaultCookieName)
+	cookie.Value().Equal(token)
+	cookie.Path().Equal("/")
+	setCookie := r.Raw().Header.Get("Set-Cookie")
+	if !strings.Contains(setCookie, "HttpOnly") || !strings.Contains(setCookie, "SameSite=Lax") {
+		t.Fatalf("expected an HttpOnly and SameSite=Lax cookie but got '%s'", setCookie)
+	}
+
+	// the token is kept by the cookie.
+	e.GET("/token").Expect().Status(http.StatusOK).Body().Equal(token)
+
+	// the safe methods are not validated.
+	e.GET("/submit").Expect().Status(http.StatusOK)
+	e.HEAD("/submit").Expect().Status(http.StatusOK)
+	e.OPTIONS("/submit").Expect().Status(http.StatusOK)
+
+	e.POST("/submit").Expect().Status(http.StatusForbidden).
+		Body().Equal("Forbidden: " + csrf.ReasonTokenMissing)
+	e.POST("/submit").WithHeader(csrf.DefaultHeaderName, token+"x").Expect().Status(http.StatusForbidden).
+		Body().Equal("Forbidden: " + csrf.ReasonTokenInvalid)
+	e.POST("/submit").WithFormField(csrf.DefaultFieldName, "forged").Expect().Status(http.StatusForbidden).
+		Body().Equal("Forbidden: " + csrf.ReasonTokenInvalid)
+
+	e.POST("/submit").WithHeader(csrf.DefaultHeaderName, token).Expect().Status(http.StatusOK).
+		Body().Equal("submitted")
+	e.POST("/submit").WithFormField(csrf.DefaultFieldName, token).Expect().Status(http.StatusOK)
+	e.PUT("/submit").WithHeader(csrf.DefaultHeaderName, token).Expect().Status(http.StatusOK)
+	e.DELETE("/submit").Expect().Status(http.StatusForbidden)
+	// the header is read before the form field.
+	e.POST("/submit").WithHeader(csrf.DefaultHeaderName, "forged").WithFormField(csrf.DefaultFieldName, token).
+		Expect().Status(http.StatusForbidden).Body().Equal("Forbidden: " + csrf.ReasonTokenInvalid)
+}
+
+func TestCSRFCookieFirstRequest(t *testing.T) {
+	e := httptest.New(newApp(csrf.New(csrf.Config{CookieName: "xsrf", HeaderName: "X-XSRF-Token"})), t)
+
+	// a client without the cookie gets a new token, its token can't match.
+	r := e.POST("/submit").WithHeader("X-XSRF-Token", "guessed").Expect().Status(http.StatusForbidden)
+	r.Body().Equal("Forbidden: " + csrf.ReasonTokenInvalid)
+	token := r.Cookie("xsrf").Value().Raw()
+
+	e.POST("/submit").WithHeader("X-XSRF-Token", token).Expect().Status(http.StatusOK)
+	e.POST("/submit").WithHeader(csrf.DefaultHeaderName, token).Expect().Status(http.StatusForbidden).
+		Body().Equal("Forbidden: " + csrf.ReasonTokenMissing)
+}
+
+func TestCSRFSessions(t *testing.T) {
+	app := newApp(csrf.New(), sessions.New(sessions.Config{Cookie: "sid"}))
+	app.Get("/session", func(ctx *iris2.Context) {
+		ctx.WriteString(ctx.Session().GetString(csrf.DefaultSessionKey))
+	})
+	e := httptest.New(app, t)
+
+	r := e.GET("/token").Expect().Status(http.StatusOK)
+	token := r.Body().Raw()
+	if token == "" {
+		t.Fatal("expected a token")
+	}
+	// the token is stored on the session, not on a cookie.
+	if cookies := r.Raw().Cookies(); len(cookies) != 1 || cookies[0].Name != "sid" {
+		t.Fatalf("expected only the session's cookie but got %v", cookies)
+	}
+	e.GET("/session").Expect().Status(http.StatusOK).Body().Equal(token)
+
+	e.POST("/submit").WithCookie(csrf.DefaultCookieName, token).Expect().Status(http.StatusForbidden).
+		Body().Equal("Forbidden: " + csrf.ReasonTokenMissing)
+	e.POST("/submit").WithHeader(csrf.DefaultHeaderName, "forged").Expect().Status(http.StatusForbidden).
+		Body().Equal("Forbidden: " + csrf.ReasonTokenInvalid)
+	e.POST("/submit").WithHeader(csrf.DefaultHeaderName, token).Expect().Status(http.StatusOK)
+}
+
+func TestCSRFTemplateFuncs(t *testing.T) {
+	policies := &iris2.Policies{}
+	csrf.New(csrf.Config{FieldName: `token"`}).Adapt(policies)
+
+	field := policies.TemplateFuncsPolicy["csrf_field"].(func(interface{}) template.HTML)
+	token := policies.TemplateFuncsPolicy["csrf_token"].(func(interface{}) string)
+
+	if got := token(iris2.Map{`token"`: "abc"}); got != "abc" {
+		t.Fatalf("expected the token of the template's data but got '%s'", got)
+	}
+	if got := token("abc"); got != "abc" {
+		t.Fatalf("expected the token itself but got '%s'", got)
+	}
+	if got := token(42); got != "" {
+		t.Fatalf("expected no token but got '%s'", got)
+	}
+	expected := template.HTML(`<input type="hidden" name="token&#34;" value="a&lt;b">`)
+	if got := field(map[string]interface{}{`token"`: "a<b"}); got != expected {
+		t.Fatalf("expected the escaped hidden input %s but got %s", expected, got)
+	}
+}
@@ -35,6 +35,31 @@ func main() {
 		ctx.Writef("hello")
 	})
 
+	// access logs, Apache's Combined Log Format, written to a file
+	// which is rotated every 10MB, the last 5 rotated files are kept.
+	accessLog, err := logger.NewRotateFile("./logs/access.log", 10<<20, 5)
+	if err != nil {
+		panic(err)
+	}
+	defer accessLog.Close()
+
+	api := app.Party("/api", logger.New(logger.Config{
+		Format: logger.Combined, // or logger.Common, logger.JSON
+		Output: accessLog,
+		// don't log the health checks
+		Skip: []string{"/api/healthz"},
+		// log only the 10% of the successful requests, failures are always logged
+		SampleRate: 0.1,
+	}))
+
+	api.Get("/healthz", func(ctx *iris2.Context) {
+		ctx.Writef("ok")
+	})
+
+	api.Get("/users/:id", func(ctx *iris2.Context) {
+		ctx.Writef("user %s", ctx.Param("id"))
+	}).ChangeName("user")
+
 	// log http errors
 	errorLogger := logger.New()
 
@@ -46,6 +71,7 @@ func main() {
 	// http://localhost:8080
 	// http://localhost:8080/1
 	// http://localhost:8080/2
+	// http://localhost:8080/api/users/42
 	app.Listen(":8080")
 
 }
@@ -1,9 +1,20 @@
 package logger
 
-// Config are the options of the logger middlweare
-// contains 4 bools
-// Status, IP, Method, Path
-// if set to true then these will print
+import (
+	"io"
+
+	"github.com/go-iris2/iris2"
+)
+
+// DefaultRequestIDHeader is the default header which the request id is taken from,
+// the response's header is checked first and after that the request's one.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// Config are the options of the logger middlweare.
+//
+// The Status, IP, Method, Path, Bytes, RequestID, UserAgent and Route
+// are used only by the default text format,
+// if set to true then these will print.
 type Config struct {
 	// Status displays status code (bool)
 	Status bool
@@ -13,9 +24,62 @@ type Config struct {
 	Method bool
 	// Path displays the request path (bool)
 	Path bool
+	// Bytes displays the number of the response's body bytes (bool)
+	Bytes bool
+	// RequestID displays the request id, see RequestIDHeader (bool)
+	RequestID bool
+	// UserAgent displays the request's user agent (bool)
+	UserAgent bool
+	// Route displays the name of the served route (bool)
+	Route bool
+
+	// Format formats the log entries,
+	// i.e logger.Common, logger.Combined, logger.JSON or a custom FormatterFunc.
+	//
+	// Defaults to nil, the text format of the above booleans
+	Format Formatter
+	// Output is the destination of the log lines, i.e a RotateFile,
+	// each line is written with one Write call, the calls are serialized.
+	//
+	// Defaults to nil, the lines are printed by the ctx.Log
+	Output io.Writer
+
+	// RequestIDHeader is the header which the request id is taken from,
+	// when the request id is not set by the requestid middleware.
+	//
+	// Defaults to "X-Request-Id"
+	RequestIDHeader string
+	// ContextKeys are the keys of the context's values (ctx.Get) which are logged, if set.
+	//
+	// Defaults to nil
+	ContextKeys []string
+
+	// Skip are the request paths which are not logged,
+	// a path which ends with '*' matches all the paths with that prefix,
+	// i.e []string{"/healthz", "/assets/*"}.
+	//
+	// Defaults to nil
+	Skip []string
+	// SkipFunc, if not nil, is called after the request is served,
+	// if it returns true then the request is not logged.
+	//
+	// Defaults to nil
+	SkipFunc func(ctx *iris2.Context) bool
+	// SampleRate is the fraction, from 0 to 1, of the successful requests which are logged,
+	// the requests with status code 400 and above are always logged.
+	//
+	// Defaults to 0, all requests are logged
+	SampleRate float64
 }
 
-// DefaultConfig returns an options which all properties are true except EnableColors
+// DefaultConfig returns an options which the Status, IP, Method and Path are true,
+// it prints the requests with the text format via the ctx.Log.
 func DefaultConfig() Config {
-	return Config{true, true, true, true}
+	return Config{
+		Status:          true,
+		IP:              true,
+		Method:          true,
+		Path:            true,
+		RequestIDHeader: DefaultRequestIDHeader,
+	}
 }
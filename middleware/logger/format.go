@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type (
+	// Entry is the log record of a served request.
+	Entry struct {
+		// Time is the time which the request is served.
+		Time time.Time
+		// Latency is the time which took to serve the request.
+		Latency time.Duration
+		// Status is the response's status code.
+		Status int
+		// Bytes is the number of the response's body bytes.
+		Bytes int
+		// IP is the request's remote address.
+		IP string
+		// User is the basic authentication's username, if any.
+		User string
+		// Method is the request's http method.
+		Method string
+		// Path is the request path.
+		Path string
+		// URI is the request uri, the path with the query.
+		URI string
+		// Proto is the request's protocol, i.e HTTP/1.1.
+		Proto string
+		// RequestID is the request id, if any, see Config.RequestIDHeader.
+		RequestID string
+		// UserAgent is the request's User-Agent header.
+		UserAgent string
+		// Referer is the request's Referer header.
+		Referer string
+		// Route is the name of the served route, empty if no route was matched.
+		Route string
+		// Values are the context's values of the Config.ContextKeys, if set.
+		Values map[string]interface{}
+	}
+
+	// Formatter formats an Entry to a log line, without the line break.
+	Formatter interface {
+		Format(e *Entry) []byte
+	}
+
+	// FormatterFunc is the func version of the Formatter.
+	FormatterFunc func(e *Entry) []byte
+)
+
+// Format calls the FormatterFunc itself.
+func (f FormatterFunc) Format(e *Entry) []byte {
+	return f(e)
+}
+
+const apacheTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+var (
+	// Common is the Apache's Common Log Format,
+	// i.e 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+	Common Formatter = FormatterFunc(func(e *Entry) []byte {
+		return appendCommon(nil, e)
+	})
+
+	// Combined is the Apache's Combined Log Format, the Common one plus the Referer and the User-Agent,
+	// i.e 127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"
+	Combined Formatter = FormatterFunc(func(e *Entry) []byte {
+		b := appendCommon(nil, e)
+		b = append(b, ' ')
+		b = strconv.AppendQuote(b, orDash(e.Referer))
+		b = append(b, ' ')
+		b = strconv.AppendQuote(b, orDash(e.UserAgent))
+		return b
+	})
+
+	// JSON formats the entries as JSON objects, one per line,
+	// the empty fields are omitted and the latency is in milliseconds.
+	JSON Formatter = FormatterFunc(func(e *Entry) []byte {
+		b, err := json.Marshal(jsonEntry{
+			Time:      e.Time.Format(time.RFC3339Nano),
+			Latency:   float64(e.Latency) / float64(time.Millisecond),
+			Status:    e.Status,
+			Bytes:     e.Bytes,
+			IP:        e.IP,
+			User:      e.User,
+			Method:    e.Method,
+			Path:      e.Path,
+			URI:       e.URI,
+			Proto:     e.Proto,
+			RequestID: e.RequestID,
+			UserAgent: e.UserAgent,
+			Referer:   e.Referer,
+			Route:     e.Route,
+			Values:    e.Values,
+		})
+		if err != nil {
+			// a value which can't be encoded, keep the line.
+			b, _ = json.Marshal(map[string]string{"time": e.Time.Format(time.RFC3339Nano), "error": err.Error()})
+		}
+		return b
+	})
+)
+
+type jsonEntry struct {
+	Time      string                 `json:"time"`
+	Latency   float64                `json:"latency_ms"`
+	Status    int                    `json:"status"`
+	Bytes     int                    `json:"bytes"`
+	IP        string                 `json:"ip,omitempty"`
+	User      string                 `json:"user,omitempty"`
+	Method    string                 `json:"method,omitempty"`
+	Path      string                 `json:"path,omitempty"`
+	URI       string                 `json:"uri,omitempty"`
+	Proto     string                 `json:"proto,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	UserAgent string                 `json:"user_agent,omitempty"`
+	Referer   string                 `json:"referer,omitempty"`
+	Route     string                 `json:"route,omitempty"`
+	Values    map[string]interface{} `json:"values,omitempty"`
+}
+
+func appendCommon(b []byte, e *Entry) []byte {
+	b = append(b, orDash(e.IP)...)
+	b = append(b, " - "...)
+	b = append(b, orDash(e.User)...)
+	b = append(b, " ["...)
+	b = e.Time.AppendFormat(b, apacheTimeFormat)
+	b = append(b, "] \""...)
+	b = append(b, e.Method...)
+	b = append(b, ' ')
+	b = append(b, e.URI...)
+	b = append(b, ' ')
+	b = append(b, e.Proto...)
+	b = append(b, "\" "...)
+	b = strconv.AppendInt(b, int64(e.Status), 10)
+	b = append(b, ' ')
+	if e.Bytes > 0 {
+		b = strconv.AppendInt(b, int64(e.Bytes), 10)
+	} else {
+		b = append(b, '-')
+	}
+	return b
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// textFormatter is the default format, it prints the fields which are enabled on the Config.
+type textFormatter struct {
+	config Config
+}
+
+func (t textFormatter) Format(e *Entry) []byte {
+	var status, ip, method, path string
+	if t.config.Status {
+		status = strconv.Itoa(e.Status)
+	}
+	if t.config.IP {
+		ip = e.IP
+	}
+	if t.config.Method {
+		method = e.Method
+	}
+	if t.config.Path {
+		path = e.Path
+	}
+
+	b := new(bytes.Buffer)
+	fmt.Fprintf(b, "%s %v %4v %s %s %s", e.Time.Format("01/02 - 15:04:05"), status, e.Latency, ip, method, path)
+
+	if t.config.Bytes {
+		fmt.Fprintf(b, " %dB", e.Bytes)
+	}
+	if t.config.RequestID && e.RequestID != "" {
+		fmt.Fprintf(b, " id=%s", e.RequestID)
+	}
+	if t.config.Route && e.Route != "" {
+		fmt.Fprintf(b, " route=%s", e.Route)
+	}
+	if t.config.UserAgent {
+		fmt.Fprintf(b, " %q", e.UserAgent)
+	}
+
+	keys := make([]string, 0, len(e.Values))
+	for k := range e.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, " %s=%v", k, e.Values[k])
+	}
+
+	return b.Bytes()
+}
@@ -1,7 +1,9 @@
 package logger
 
 import (
-	"strconv"
+	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-iris2/iris2"
@@ -9,43 +11,100 @@ import (
 
 type loggerMiddleware struct {
 	config Config
+	mu     sync.Mutex // serializes the writes to the Output
 }
 
 // Serve serves the middleware
 func (l *loggerMiddleware) Serve(ctx *iris2.Context) {
-	//all except latency to string
-	var date, status, ip, method, path string
-	var latency time.Duration
-	var startTime, endTime time.Time
-	path = ctx.Path()
-	method = ctx.Method()
+	path := ctx.Path()
+	if l.skipPath(path) {
+		ctx.Next()
+		return
+	}
 
-	startTime = time.Now()
+	startTime := time.Now()
 
 	ctx.Next()
 	//no time.Since in order to format it well after
-	endTime = time.Now()
-	date = endTime.Format("01/02 - 15:04:05")
-	latency = endTime.Sub(startTime)
+	endTime := time.Now()
+
+	status := ctx.ResponseWriter.StatusCode()
+	if l.config.SkipFunc != nil && l.config.SkipFunc(ctx) {
+		return
+	}
+	if rate := l.config.SampleRate; rate > 0 && rate < 1 && status < 400 && rand.Float64() >= rate {
+		return
+	}
 
-	if l.config.Status {
-		status = strconv.Itoa(ctx.ResponseWriter.StatusCode())
+	e := &Entry{
+		Time:      endTime,
+		Latency:   endTime.Sub(startTime),
+		Status:    status,
+		Bytes:     ctx.ResponseWriter.Written(),
+		IP:        ctx.RemoteAddr(),
+		Method:    ctx.Method(),
+		Path:      path,
+		URI:       ctx.Request.RequestURI,
+		Proto:     ctx.Request.Proto,
+		RequestID: l.requestID(ctx),
+		UserAgent: ctx.Request.UserAgent(),
+		Referer:   ctx.Request.Referer(),
+	}
+	if e.URI == "" {
+		e.URI = ctx.Request.URL.RequestURI()
+	}
+	if user, _, ok := ctx.Request.BasicAuth(); ok {
+		e.User = user
+	}
+	if r := ctx.GetCurrentRoute(); r != nil {
+		e.Route = r.Name()
+	}
+	for _, k := range l.config.ContextKeys {
+		if v := ctx.Get(k); v != nil {
+			if e.Values == nil {
+				e.Values = make(map[string]interface{}, len(l.config.ContextKeys))
+			}
+			e.Values[k] = v
+		}
 	}
 
-	if l.config.IP {
-		ip = ctx.RemoteAddr()
+	//finally print the logs
+	line := l.config.Format.Format(e)
+	if l.config.Output == nil {
+		ctx.Log("%s", line)
+		return
 	}
 
-	if !l.config.Method {
-		method = ""
+	l.mu.Lock()
+	_, err := l.config.Output.Write(append(line, '\n'))
+	l.mu.Unlock()
+	if err != nil {
+		ctx.Log("logger: %s", err)
 	}
+}
 
-	if !l.config.Path {
-		path = ""
+func (l *loggerMiddleware) skipPath(path string) bool {
+	for _, s := range l.config.Skip {
+		if prefix := strings.TrimSuffix(s, "*"); prefix != s {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == s {
+			return true
+		}
 	}
+	return false
+}
 
-	//finally print the logs
-	ctx.Log("%s %v %4v %s %s %s", date, status, latency, ip, method, path)
+func (l *loggerMiddleware) requestID(ctx *iris2.Context) string {
+	// set by the requestid middleware
+	if id := ctx.RequestID(); id != "" {
+		return id
+	}
+	if id := ctx.ResponseWriter.Header().Get(l.config.RequestIDHeader); id != "" {
+		return id
+	}
+	return ctx.RequestHeader(l.config.RequestIDHeader)
 }
 
 // New returns the logger middleware
@@ -55,6 +114,12 @@ func New(cfg ...Config) iris2.HandlerFunc {
 	if len(cfg) > 0 {
 		c = cfg[0]
 	}
+	if c.RequestIDHeader == "" {
+		c.RequestIDHeader = DefaultRequestIDHeader
+	}
+	if c.Format == nil {
+		c.Format = textFormatter{config: c}
+	}
 	l := &loggerMiddleware{config: c}
 
 	return l.Serve
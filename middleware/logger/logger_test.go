@@ -0,0 +1,297 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/httptest"
+	"github.com/go-iris2/iris2/middleware/logger"
+)
+
+// syncBuffer is a bytes.Buffer which is safe for use by the middleware and the test.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// lines returns the written lines and resets the buffer.
+func (b *syncBuffer) lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := strings.TrimSuffix(b.buf.String(), "\n")
+	b.buf.Reset()
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+func newApp(cfg logger.Config) *iris2.Framework {
+	app := iris2.New()
+	app.UseGlobal(logger.New(cfg))
+	app.Get("/users/:id", func(ctx *iris2.Context) {
+		ctx.Set("tenant", "acme")
+		ctx.WriteString("user " + ctx.Param("id"))
+	}).ChangeName("user")
+	app.Get("/healthz", func(ctx *iris2.Context) {
+		ctx.WriteString("ok")
+	})
+	app.Get("/assets/*file", func(ctx *iris2.Context) {
+		ctx.WriteString("asset")
+	})
+	app.Get("/fail", func(ctx *iris2.Context) {
+		ctx.EmitError(http.StatusInternalServerError)
+	})
+	return app
+}
+
+func TestLoggerCommon(t *testing.T) {
+	out := new(syncBuffer)
+	e := httptest.New(newApp(logger.Config{Format: logger.Common, Output: out}), t)
+
+	e.GET("/users/42").WithQuery("q", "1").WithBasicAuth("iris", "pass").Expect().Status(http.StatusOK)
+	lines := out.lines()
+	if len(lines) != 1 {
+		t.Fatalf("expected one line but got %v", lines)
+	}
+	common := regexp.MustCompile(`^\S+ - iris \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [-+]\d{4}\] "GET /users/42\?q=1 HTTP/1\.1" 200 7$`)
+	if !common.MatchString(lines[0]) {
+		t.Fatalf("expected the common log format but got '%s'", lines[0])
+	}
+}
+
+func TestLoggerCombined(t *testing.T) {
+	out := new(syncBuffer)
+	e := httptest.New(newApp(logger.Config{Format: logger.Combined, Output: out}), t)
+
+	e.GET("/users/42").WithHeader("Referer", "http://example.com/start").
+		WithHeader("User-Agent", "Mozilla/4.08").Expect().Status(http.StatusOK)
+	e.GET("/users/42").WithHeader("User-Agent", "").Expect().Status(http.StatusOK)
+	lines := out.lines()
+	if len(lines) != 2 {
+		t.Fatalf("expected two lines but got %v", lines)
+	}
+	if !strings.HasSuffix(lines[0], `"GET /users/42 HTTP/1.1" 200 7 "http://example.com/start" "Mozilla/4.08"`) ||
+		!strings.Contains(lines[0], " - - [") {
+		t.Fatalf("expected the combined log format but got '%s'", lines[0])
+	}
+	// the missing fields are dashes.
+	if !strings.HasSuffix(lines[1], `200 7 "-" "-"`) {
+		t.Fatalf("expected dashes for the missing referer and user agent but got '%s'", lines[1])
+	}
+}
+
+func TestLoggerJSON(t *testing.T) {
+	out := new(syncBuffer)
+	e := httptest.New(newApp(logger.Config{Format: logger.JSON, Output: out, ContextKeys: []string{"tenant", "missing"}}), t)
+
+	e.GET("/users/42").WithHeader(logger.DefaultRequestIDHeader, "req-1").Expect().Status(http.StatusOK)
+	lines := out.lines()
+	if len(lines) != 1 {
+		t.Fatalf("expected one line but got %v", lines)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("expected a JSON line but got '%s': %v", lines[0], err)
+	}
+	expected := map[string]interface{}{
+		"status":     float64(200),
+		"bytes":      float64(7),
+		"method":     "GET",
+		"path":       "/users/42",
+		"uri":        "/users/42",
+		"proto":      "HTTP/1.1",
+		"request_id": "req-1",
+		"route":      "user",
+	}
+	for k, v := range expected {
+		if entry[k] != v {
+			t.Fatalf("expected the %s %v but got %v in '%s'", k, v, entry[k], lines[0])
+		}
+	}
+	if values, ok := entry["values"].(map[string]interface{}); !ok || len(values) != 1 || values["tenant"] != "acme" {
+		t.Fatalf("expected only the set context values but got %v", entry["values"])
+	}
+	if _, ok := entry["latency_ms"].(float64); !ok {
+		t.Fatalf("expected the latency in milliseconds but got %v", entry["latency_ms"])
+	}
+	// the empty fields are omitted.
+	if _, ok := entry["user"]; ok {
+		t.Fatalf("expected no user field but got '%s'", lines[0])
+	}
+}
+
+func TestLoggerText(t *testing.T) {
+	out := new(syncBuffer)
+	cfg := logger.DefaultConfig()
+	cfg.Output = out
+	cfg.Bytes = true
+	cfg.Route = true
+	cfg.ContextKeys = []string{"tenant"}
+	e := httptest.New(newApp(cfg), t)
+
+	e.GET("/users/42").Expect().Status(http.StatusOK)
+	lines := out.lines()
+	if len(lines) != 1 {
+		t.Fatalf("expected one line but got %v", lines)
+	}
+	text := regexp.MustCompile(`^\d{2}/\d{2} - \d{2}:\d{2}:\d{2} 200 .+ GET /users/42 7B route=user tenant=acme$`)
+	if !text.MatchString(lines[0]) {
+		t.Fatalf("expected the text format of the enabled fields but got '%s'", lines[0])
+	}
+}
+
+func TestLoggerSkip(t *testing.T) {
+	out := new(syncBuffer)
+	e := httptest.New(newApp(logger.Config{
+		Format: logger.FormatterFunc(func(e *logger.Entry) []byte {
+			return []byte(e.Path)
+		}),
+		Output: out,
+		Skip:   []string{"/healthz", "/assets/*"},
+		SkipFunc: func(ctx *iris2.Context) bool {
+			return ctx.URLParam("skip") == "true"
+		},
+	}), t)
+
+	e.GET("/healthz").Expect().Status(http.StatusOK)
+	e.GET("/assets/css/main.css").Expect().Status(http.StatusOK)
+	e.GET("/users/1").WithQuery("skip", "true").Expect().Status(http.StatusOK)
+	e.GET("/users/2").Expect().Status(http.StatusOK)
+	if lines := out.lines(); len(lines) != 1 || lines[0] != "/users/2" {
+		t.Fatalf("expected only the /users/2 to be logged but got %v", lines)
+	}
+}
+
+func TestLoggerSampleRate(t *testing.T) {
+	out := new(syncBuffer)
+	e := httptest.New(newApp(logger.Config{
+		Format: logger.FormatterFunc(func(e *logger.Entry) []byte {
+			return []byte(e.Path)
+		}),
+		Output:     out,
+		SampleRate: 0.000001,
+	}), t)
+
+	for i := 0; i < 10; i++ {
+		e.GET("/healthz").Expect().Status(http.StatusOK)
+	}
+	// the errors are always logged.
+	e.GET("/fail").Expect().Status(http.StatusInternalServerError)
+	if lines := out.lines(); len(lines) != 1 || lines[0] != "/fail" {
+		t.Fatalf("expected only the failed request to be logged but got %v", lines)
+	}
+}
+
+func readFile(t *testing.T, filename string) string {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestRotateFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "logs", "access.log")
+	r, err := logger.NewRotateFile(filename, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err = r.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// each line doesn't fit with the previous one, only the last two backups are kept.
+	if got := readFile(t, filename); got != "fourth\n" {
+		t.Fatalf("expected the last line in the file but got '%s'", got)
+	}
+	if got := readFile(t, filename+".1"); got != "third\n" {
+		t.Fatalf("expected the newest backup to be the .1 but got '%s'", got)
+	}
+	if got := readFile(t, filename+".2"); got != "second\n" {
+		t.Fatalf("expected the older backup to be the .2 but got '%s'", got)
+	}
+	if _, err = os.Stat(filename + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected only two backups but got %v", err)
+	}
+
+	// a manual rotation, i.e on SIGHUP.
+	if err = r.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filename); got != "" {
+		t.Fatalf("expected an empty file after the rotation but got '%s'", got)
+	}
+	if got := readFile(t, filename+".1"); got != "fourth\n" {
+		t.Fatalf("expected the rotated file to be the .1 but got '%s'", got)
+	}
+
+	// a Write after the Close re-opens the file.
+	r.Close()
+	if _, err = r.Write([]byte("fifth\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, filename); got != "fifth\n" {
+		t.Fatalf("expected the file to be re-opened but got '%s'", got)
+	}
+}
+
+func TestRotateFileNoBackups(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	if err := ioutil.WriteFile(filename, []byte("old\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := logger.NewRotateFile(filename, 8, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// appends to the existing file, until the size would exceed the limit.
+	r.Write([]byte("new\n"))
+	if got := readFile(t, filename); got != "old\nnew\n" {
+		t.Fatalf("expected the line to be appended but got '%s'", got)
+	}
+	r.Write([]byte("next\n"))
+	if got := readFile(t, filename); got != "next\n" {
+		t.Fatalf("expected the old contents to be removed but got '%s'", got)
+	}
+	if _, err = os.Stat(filename + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("expected no backups but got %v", err)
+	}
+}
+
+func TestLoggerRotateFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	r, err := logger.NewRotateFile(filename, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	e := httptest.New(newApp(logger.Config{Format: logger.Common, Output: r}), t)
+
+	e.GET("/users/1").Expect().Status(http.StatusOK)
+	e.GET("/users/2").Expect().Status(http.StatusOK)
+	lines := strings.Split(strings.TrimSuffix(readFile(t, filename), "\n"), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "/users/1") || !strings.Contains(lines[1], "/users/2") {
+		t.Fatalf("expected one line per request in the file but got %v", lines)
+	}
+}
@@ -0,0 +1,135 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/go-iris2/iris2/errors"
+)
+
+var (
+	errRotateOpen   = errors.New("logger: unable to open the log file '%s'. Trace: %s")
+	errRotateRename = errors.New("logger: unable to rotate the log file '%s'. Trace: %s")
+)
+
+// RotateFile is a file writer, to be used as the Config.Output,
+// which rotates the file when its size would exceed the MaxSize,
+// the rotated files are renamed to filename.1 (the newest), filename.2 and so on.
+//
+// It's safe for use by multiple goroutines.
+type RotateFile struct {
+	filename   string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewRotateFile opens (or creates) the filename for appending,
+// the file is rotated when its size would exceed the maxSize (in bytes, zero means no size limit)
+// and only the last maxBackups rotated files are kept (zero means that the old contents are removed).
+func NewRotateFile(filename string, maxSize int64, maxBackups int) (*RotateFile, error) {
+	r := &RotateFile{filename: filename, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotateFile) open() error {
+	if dir := filepath.Dir(r.filename); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return errRotateOpen.Format(r.filename, err.Error())
+		}
+	}
+
+	f, err := os.OpenFile(r.filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return errRotateOpen.Format(r.filename, err.Error())
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errRotateOpen.Format(r.filename, err.Error())
+	}
+
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write writes the p to the file, the file is rotated first if the p doesn't fit.
+func (r *RotateFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate rotates the file manually, i.e on SIGHUP.
+func (r *RotateFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rotate()
+}
+
+func (r *RotateFile) rotate() error {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+
+	if r.maxBackups <= 0 {
+		if err := os.Remove(r.filename); err != nil && !os.IsNotExist(err) {
+			return errRotateRename.Format(r.filename, err.Error())
+		}
+		return r.open()
+	}
+
+	// the oldest is overridden.
+	for i := r.maxBackups - 1; i > 0; i-- {
+		from := r.filename + "." + strconv.Itoa(i)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := os.Rename(from, r.filename+"."+strconv.Itoa(i+1)); err != nil {
+			return errRotateRename.Format(r.filename, err.Error())
+		}
+	}
+	if err := os.Rename(r.filename, r.filename+".1"); err != nil && !os.IsNotExist(err) {
+		return errRotateRename.Format(r.filename, err.Error())
+	}
+
+	return r.open()
+}
+
+// Close closes the file, a next Write re-opens it.
+func (r *RotateFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
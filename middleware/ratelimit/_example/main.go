@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/redis/service"
+	"github.com/go-iris2/iris2/middleware/ratelimit"
+)
+
+func main() {
+	app := iris2.New()
+
+	// custom page for the limited requests
+	app.OnError(http.StatusTooManyRequests, func(ctx *iris2.Context) {
+		ctx.Writef("Slow down, retry after %s seconds", ctx.ResponseWriter.Header().Get(ratelimit.RetryAfterHeader))
+	})
+
+	// 5 requests per second per client, bursts up to 10 requests.
+	app.Get("/", ratelimit.New(ratelimit.Config{Limit: 5, Period: time.Second, Burst: 10}), func(ctx *iris2.Context) {
+		ctx.Writef("hello")
+	})
+
+	// 100 requests per minute per client for the whole party,
+	// the counters are shared between the servers through redis.
+	redis := service.New(service.Config{Addr: "127.0.0.1:6379", Prefix: "myapp-"})
+	redis.Connect()
+
+	api := app.Party("/api", ratelimit.New(ratelimit.Config{
+		Algorithm: ratelimit.SlidingWindow,
+		Limit:     100,
+		Period:    time.Minute,
+		Store:     redis,
+		Prefix:    "ratelimit:api:",
+	}))
+
+	api.Get("/users/:id", func(ctx *iris2.Context) {
+		ctx.Writef("user %s", ctx.Param("id"))
+	})
+
+	app.Listen(":8080")
+}
@@ -0,0 +1,77 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/imdario/mergo"
+)
+
+// Algorithm is the rate limiting algorithm.
+type Algorithm uint8
+
+const (
+	// TokenBucket allows bursts up to the Burst requests
+	// and refills Limit requests per Period, its buckets are always in-memory.
+	TokenBucket Algorithm = iota
+	// SlidingWindow allows Limit requests per sliding Period,
+	// its counters are kept on the Store.
+	SlidingWindow
+)
+
+const (
+	// DefaultLimit is the number of the allowed requests per period, 60
+	DefaultLimit = 60
+	// DefaultPeriod is the period of the limit, one minute
+	DefaultPeriod = time.Minute
+	// DefaultPrefix is the prefix of the keys, "ratelimit:"
+	DefaultPrefix = "ratelimit:"
+)
+
+// KeyFunc returns the key of the request's limit,
+// the requests with an empty key are not limited.
+type KeyFunc func(ctx *iris2.Context) string
+
+// Config the configs for the ratelimit middleware
+type Config struct {
+	// Algorithm is the rate limiting algorithm, TokenBucket or SlidingWindow. Default is TokenBucket
+	Algorithm Algorithm
+	// Limit is the number of the allowed requests per Period. Default is 60
+	Limit int
+	// Period is the period of the Limit. Default is one minute
+	Period time.Duration
+	// Burst is the capacity of the token bucket. Default is the Limit
+	Burst int
+	// KeyFunc returns the key of the request's limit, i.e ByIP, BySession, ByBasicAuthUser. Default is ByIP
+	KeyFunc KeyFunc
+	// Store keeps the sliding window's counters. Default is a new in-memory Store
+	Store Store
+	// Prefix is the prefix of the keys, use a different one for each limit which shares the same Store.
+	// Default is "ratelimit:"
+	Prefix string
+	// DisableHeaders, if true, the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+	// are not sent, the Retry-After is always sent with the 429 errors. Default is false
+	DisableHeaders bool
+}
+
+// DefaultConfig returns the default configs for the ratelimit middleware
+func DefaultConfig() Config {
+	return Config{
+		Algorithm: TokenBucket,
+		Limit:     DefaultLimit,
+		Period:    DefaultPeriod,
+		KeyFunc:   ByIP,
+		Prefix:    DefaultPrefix,
+	}
+}
+
+// MergeSingle merges the default with the given config and returns the result
+func (c Config) MergeSingle(cfg Config) (config Config) {
+	config = cfg
+	mergo.Merge(&config, c)
+	// the funcs are not merged.
+	if config.KeyFunc == nil {
+		config.KeyFunc = c.KeyFunc
+	}
+	return
+}
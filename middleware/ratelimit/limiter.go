@@ -0,0 +1,163 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// Result is the state of a key's limit after a request.
+	Result struct {
+		// Allowed is true if the request is allowed.
+		Allowed bool
+		// Limit is the number of the requests which are allowed in a period (the burst for the token bucket).
+		Limit int
+		// Remaining is the number of the requests which are still allowed.
+		Remaining int
+		// Reset is the time until the remaining requests are restored,
+		// the full refill of the token bucket or the start of the next window.
+		Reset time.Duration
+		// RetryAfter is the time until the next request is allowed, zero if allowed.
+		RetryAfter time.Duration
+	}
+
+	// Limiter limits the requests of each key.
+	Limiter interface {
+		// Allow consumes one request of the key's limit.
+		Allow(key string) (Result, error)
+	}
+)
+
+type (
+	tokenBucket struct {
+		burst float64
+		rate  float64 // tokens per second
+
+		mu      sync.Mutex
+		buckets map[string]*bucket
+		ops     int
+	}
+
+	bucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+// NewTokenBucket returns an in-memory token bucket Limiter,
+// the buckets hold up to burst tokens and they are refilled by limit tokens per period.
+func NewTokenBucket(limit int, period time.Duration, burst int) Limiter {
+	if burst <= 0 {
+		burst = limit
+	}
+	return &tokenBucket{
+		burst:   float64(burst),
+		rate:    float64(limit) / period.Seconds(),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+func (t *tokenBucket) Allow(key string) (Result, error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+	b, ok := t.buckets[key]
+	if !ok {
+		b = &bucket{tokens: t.burst, last: now}
+		t.buckets[key] = b
+	} else {
+		b.tokens = math.Min(t.burst, b.tokens+now.Sub(b.last).Seconds()*t.rate)
+		b.last = now
+	}
+
+	res := Result{Limit: int(t.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = t.refill(1 - b.tokens)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = t.refill(t.burst - b.tokens)
+	return res, nil
+}
+
+// refill returns the time which the bucket needs in order to get the tokens.
+func (t *tokenBucket) refill(tokens float64) time.Duration {
+	return time.Duration(tokens / t.rate * float64(time.Second))
+}
+
+// sweep removes the full buckets, periodically, they are equal to the new ones.
+func (t *tokenBucket) sweep(now time.Time) {
+	if t.ops++; t.ops < sweepEvery {
+		return
+	}
+	t.ops = 0
+	for k, b := range t.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*t.rate >= t.burst {
+			delete(t.buckets, k)
+		}
+	}
+}
+
+type slidingWindow struct {
+	limit  int
+	window time.Duration
+	store  Store
+}
+
+// NewSlidingWindow returns a sliding window Limiter which allows limit requests per window,
+// the counters are kept on the store, a nil store means a new in-memory one.
+//
+// The current window's count is added to the previous window's count weighted
+// by its overlap with the sliding window, the rejected requests are counted as well.
+func NewSlidingWindow(limit int, window time.Duration, store Store) Limiter {
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &slidingWindow{limit: limit, window: window, store: store}
+}
+
+func (s *slidingWindow) Allow(key string) (Result, error) {
+	now := time.Now()
+	start := now.Truncate(s.window)
+	elapsed := now.Sub(start)
+
+	current, err := s.store.Incr(s.key(key, start), 2*s.window)
+	if err != nil {
+		return Result{}, err
+	}
+	previous, err := s.store.Count(s.key(key, start.Add(-s.window)))
+	if err != nil {
+		return Result{}, err
+	}
+
+	weight := 1 - float64(elapsed)/float64(s.window)
+	count := float64(previous)*weight + float64(current)
+	limit := float64(s.limit)
+
+	res := Result{
+		Limit:     s.limit,
+		Allowed:   count <= limit,
+		Remaining: int(math.Max(0, math.Floor(limit-count))),
+		Reset:     s.window - elapsed,
+	}
+	if !res.Allowed {
+		if float64(current) > limit || previous == 0 {
+			res.RetryAfter = s.window - elapsed
+		} else {
+			// the time which the previous window's weight needs to drop enough.
+			res.RetryAfter = time.Duration(float64(s.window)*(1-(limit-float64(current))/float64(previous))) - elapsed
+		}
+	}
+	return res, nil
+}
+
+func (s *slidingWindow) key(key string, start time.Time) string {
+	return key + ":" + strconv.FormatInt(start.UnixNano(), 36)
+}
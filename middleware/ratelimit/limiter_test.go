@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+// waitWindow sleeps until the elapsed time of the current window is at least the at.
+func waitWindow(window, at time.Duration) time.Time {
+	now := time.Now()
+	start := now.Truncate(window)
+	if now.Sub(start) > at {
+		start = start.Add(window)
+	}
+	time.Sleep(start.Add(at).Sub(now))
+	return start
+}
+
+func TestTokenBucket(t *testing.T) {
+	// 10 tokens per second, up to 3.
+	l := NewTokenBucket(10, time.Second, 3)
+
+	for i := 2; i >= 0; i-- {
+		res, _ := l.Allow("key")
+		if !res.Allowed || res.Remaining != i || res.Limit != 3 {
+			t.Fatalf("expected the burst to be allowed but got %+v", res)
+		}
+	}
+	res, _ := l.Allow("key")
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected the request after the burst to be rejected but got %+v", res)
+	}
+	if res.RetryAfter <= 0 || res.RetryAfter > 100*time.Millisecond {
+		t.Fatalf("expected to retry after one token's refill but got %v", res.RetryAfter)
+	}
+	if res.Reset < 200*time.Millisecond || res.Reset > 300*time.Millisecond {
+		t.Fatalf("expected the full refill after 300ms but got %v", res.Reset)
+	}
+	// the keys have their own buckets.
+	if res, _ = l.Allow("other"); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("expected a full bucket for another key but got %+v", res)
+	}
+
+	time.Sleep(120 * time.Millisecond)
+	if res, _ = l.Allow("key"); !res.Allowed {
+		t.Fatalf("expected the refilled token to be allowed but got %+v", res)
+	}
+}
+
+func TestTokenBucketSweep(t *testing.T) {
+	l := NewTokenBucket(1000, time.Second, 1).(*tokenBucket)
+
+	l.Allow("key")
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < sweepEvery; i++ {
+		l.Allow("other")
+	}
+	l.mu.Lock()
+	_, found := l.buckets["key"]
+	l.mu.Unlock()
+	if found {
+		t.Fatal("expected the full bucket to be removed")
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	window := time.Second
+	l := NewSlidingWindow(2, window, nil)
+
+	start := waitWindow(window, 0)
+	for i := 1; i >= 0; i-- {
+		res, _ := l.Allow("key")
+		if !res.Allowed || res.Remaining != i || res.Limit != 2 {
+			t.Fatalf("expected the limit to be allowed but got %+v", res)
+		}
+	}
+	res, _ := l.Allow("key")
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected the request after the limit to be rejected but got %+v", res)
+	}
+	// without a previous window, the next window is waited.
+	if next := start.Add(window).Sub(time.Now()); res.RetryAfter < next || res.RetryAfter > next+100*time.Millisecond {
+		t.Fatalf("expected to retry at the next window (%v) but got %v", next, res.RetryAfter)
+	}
+	if res.Reset != res.RetryAfter {
+		t.Fatalf("expected the reset at the next window but got %v", res.Reset)
+	}
+}
+
+func TestSlidingWindowPrevious(t *testing.T) {
+	window := time.Second
+	store := NewMemoryStore()
+	l := NewSlidingWindow(10, window, store).(*slidingWindow)
+
+	start := waitWindow(window, window/2)
+	for i := 0; i < 10; i++ {
+		store.Incr(l.key("half", start.Add(-window)), 2*window)
+	}
+	for i := 0; i < 40; i++ {
+		store.Incr(l.key("full", start.Add(-window)), 2*window)
+	}
+
+	// the previous 10 requests weight 5 at the middle of the window, 4 more are allowed after this one.
+	res, _ := l.Allow("half")
+	if !res.Allowed || res.Remaining != 4 {
+		t.Fatalf("expected the weighted previous requests to be counted but got %+v", res)
+	}
+
+	// the previous 40 requests weight 20, the 9 requests fit when their weight drops to 9,
+	// at the 77.5% of the window.
+	res, _ = l.Allow("full")
+	if res.Allowed {
+		t.Fatalf("expected the request to be rejected but got %+v", res)
+	}
+	if res.RetryAfter < 150*time.Millisecond || res.RetryAfter > 300*time.Millisecond {
+		t.Fatalf("expected to retry after ~250ms but got %v", res.RetryAfter)
+	}
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	s := NewMemoryStore()
+
+	for i := int64(1); i <= 2; i++ {
+		if n, err := s.Incr("key", 50*time.Millisecond); err != nil || n != i {
+			t.Fatalf("expected the counter %d but got %d, %v", i, n, err)
+		}
+	}
+	if n, _ := s.Count("key"); n != 2 {
+		t.Fatalf("expected the count 2 but got %d", n)
+	}
+	if n, _ := s.Count("none"); n != 0 {
+		t.Fatalf("expected zero for a missing counter but got %d", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if n, _ := s.Count("key"); n != 0 {
+		t.Fatalf("expected zero for an expired counter but got %d", n)
+	}
+	// an expired counter starts again, with a new ttl.
+	if n, _ := s.Incr("key", time.Hour); n != 1 {
+		t.Fatalf("expected a new counter but got %d", n)
+	}
+
+	s.Incr("expired", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	for i := 0; i < sweepEvery; i++ {
+		s.Incr("key", time.Hour)
+	}
+	m := s.(*memoryStore)
+	m.mu.Lock()
+	_, found := m.counters["expired"]
+	m.mu.Unlock()
+	if found {
+		t.Fatal("expected the expired counter to be removed")
+	}
+}
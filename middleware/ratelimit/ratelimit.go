@@ -0,0 +1,118 @@
+// Package ratelimit provides a rate limiting middleware for Iris,
+// with token bucket and sliding window limiters and pluggable stores.
+package ratelimit
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-iris2/iris2"
+)
+
+const (
+	// LimitHeader is the header with the number of the allowed requests per period.
+	LimitHeader = "RateLimit-Limit"
+	// RemainingHeader is the header with the number of the remaining requests.
+	RemainingHeader = "RateLimit-Remaining"
+	// ResetHeader is the header with the seconds until the remaining requests are restored.
+	ResetHeader = "RateLimit-Reset"
+	// RetryAfterHeader is the header with the seconds until the next request is allowed, sent with the 429 errors.
+	RetryAfterHeader = "Retry-After"
+)
+
+// New returns the ratelimit middleware, one limit per New,
+// register it to a route or to a Party in order to limit them, i.e
+// api := app.Party("/api", ratelimit.New(ratelimit.Config{Limit: 100, Period: time.Minute})).
+//
+// The limited requests are answered with a 429 Too Many Requests through the
+// framework's error handlers, so app.OnError(http.StatusTooManyRequests, ...) applies.
+func New(cfg ...Config) iris2.HandlerFunc {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = c.MergeSingle(cfg[0])
+	}
+
+	var limiter Limiter
+	if c.Algorithm == SlidingWindow {
+		limiter = NewSlidingWindow(c.Limit, c.Period, c.Store)
+	} else {
+		limiter = NewTokenBucket(c.Limit, c.Period, c.Burst)
+	}
+
+	return NewWithLimiter(limiter, c)
+}
+
+// NewWithLimiter same as New but it accepts a custom Limiter,
+// the Algorithm, Limit, Period, Burst and Store of the config are ignored.
+func NewWithLimiter(limiter Limiter, cfg ...Config) iris2.HandlerFunc {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = c.MergeSingle(cfg[0])
+	}
+
+	return func(ctx *iris2.Context) {
+		key := c.KeyFunc(ctx)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		res, err := limiter.Allow(c.Prefix + key)
+		if err != nil {
+			// don't reject the requests because of the store's failures.
+			ctx.Log("ratelimit: %s", err)
+			ctx.Next()
+			return
+		}
+
+		if !c.DisableHeaders {
+			ctx.SetHeader(LimitHeader, strconv.Itoa(res.Limit))
+			ctx.SetHeader(RemainingHeader, strconv.Itoa(res.Remaining))
+			ctx.SetHeader(ResetHeader, seconds(res.Reset))
+		}
+
+		if !res.Allowed {
+			ctx.SetHeader(RetryAfterHeader, seconds(res.RetryAfter))
+			ctx.EmitError(http.StatusTooManyRequests)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// seconds returns the d in seconds, rounded up, at least one second.
+func seconds(d time.Duration) string {
+	s := int64(math.Ceil(d.Seconds()))
+	if s < 1 {
+		s = 1
+	}
+	return strconv.FormatInt(s, 10)
+}
+
+// ByIP is the KeyFunc which limits the requests by the client's remote address.
+func ByIP(ctx *iris2.Context) string {
+	return ctx.RemoteAddr()
+}
+
+// BySession is the KeyFunc which limits the requests by the session's id,
+// it starts a session if the client has not any, the sessions adaptor is required.
+func BySession(ctx *iris2.Context) string {
+	if s := ctx.Session(); s != nil {
+		return s.ID()
+	}
+	return ""
+}
+
+// ByBasicAuthUser is the KeyFunc which limits the requests by the basic authentication's username,
+// the requests without credentials are limited by the client's remote address.
+//
+// Register it after the basicauth middleware, the credentials are not validated here.
+func ByBasicAuthUser(ctx *iris2.Context) string {
+	if user, _, ok := ctx.Request.BasicAuth(); ok && user != "" {
+		return "user:" + user
+	}
+	return ByIP(ctx)
+}
@@ -0,0 +1,151 @@
+package ratelimit_test
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/sessions/sessiondb/redis/service"
+	"github.com/go-iris2/iris2/httptest"
+	"github.com/go-iris2/iris2/middleware/ratelimit"
+)
+
+func newApp(limit iris2.HandlerFunc) *iris2.Framework {
+	app := iris2.New()
+	app.Get("/", limit, func(ctx *iris2.Context) {
+		ctx.WriteString("ok")
+	})
+	app.OnError(http.StatusTooManyRequests, func(ctx *iris2.Context) {
+		ctx.WriteString("slow down")
+	})
+	return app
+}
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	e := httptest.New(newApp(ratelimit.New(ratelimit.Config{Limit: 1, Period: time.Hour, Burst: 2})), t)
+
+	for _, remaining := range []string{"1", "0"} {
+		r := e.GET("/").WithHeader("X-Real-Ip", "10.0.0.1").Expect().Status(http.StatusOK)
+		r.Header(ratelimit.LimitHeader).Equal("2")
+		r.Header(ratelimit.RemainingHeader).Equal(remaining)
+		r.Body().Equal("ok")
+	}
+
+	// the error handlers apply to the rejected requests.
+	r := e.GET("/").WithHeader("X-Real-Ip", "10.0.0.1").Expect().Status(http.StatusTooManyRequests)
+	r.Body().Equal("slow down")
+	r.Header(ratelimit.RemainingHeader).Equal("0")
+	// one token per hour.
+	retryAfter, _ := strconv.Atoi(r.Raw().Header.Get(ratelimit.RetryAfterHeader))
+	if retryAfter < 3590 || retryAfter > 3600 {
+		t.Fatalf("expected to retry after one hour but got %d seconds", retryAfter)
+	}
+	reset, _ := strconv.Atoi(r.Raw().Header.Get(ratelimit.ResetHeader))
+	if reset < 7190 || reset > 7200 {
+		t.Fatalf("expected the full refill after two hours but got %d seconds", reset)
+	}
+
+	// the clients are limited by their IP by default.
+	e.GET("/").WithHeader("X-Real-Ip", "10.0.0.2").Expect().Status(http.StatusOK)
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	store := ratelimit.NewMemoryStore()
+	byUser := func(ctx *iris2.Context) string {
+		return ctx.RequestHeader("X-User")
+	}
+	app := newApp(ratelimit.New(ratelimit.Config{Algorithm: ratelimit.SlidingWindow, Limit: 1, Period: time.Hour,
+		KeyFunc: byUser, Store: store}))
+	// another limit of the same store, with its own prefix.
+	app.Get("/other", ratelimit.New(ratelimit.Config{Algorithm: ratelimit.SlidingWindow, Limit: 1, Period: time.Hour,
+		KeyFunc: byUser, Store: store, Prefix: "other:"}), func(ctx *iris2.Context) {
+		ctx.WriteString("ok")
+	})
+	e := httptest.New(app, t)
+
+	e.GET("/").WithHeader("X-User", "iris").Expect().Status(http.StatusOK).
+		Header(ratelimit.RemainingHeader).Equal("0")
+	r := e.GET("/").WithHeader("X-User", "iris").Expect().Status(http.StatusTooManyRequests)
+	// the next window.
+	retryAfter, _ := strconv.Atoi(r.Raw().Header.Get(ratelimit.RetryAfterHeader))
+	if retryAfter < 1 || retryAfter > 3600 {
+		t.Fatalf("expected to retry at the next window but got %d seconds", retryAfter)
+	}
+	r.Header(ratelimit.ResetHeader).Equal(strconv.Itoa(retryAfter))
+
+	e.GET("/other").WithHeader("X-User", "iris").Expect().Status(http.StatusOK)
+	e.GET("/").WithHeader("X-User", "iris2").Expect().Status(http.StatusOK)
+	// the requests with an empty key are not limited.
+	for i := 0; i < 3; i++ {
+		e.GET("/").Expect().Status(http.StatusOK).Header(ratelimit.LimitHeader).Empty()
+	}
+}
+
+func TestRateLimitDisableHeaders(t *testing.T) {
+	e := httptest.New(newApp(ratelimit.New(ratelimit.Config{Limit: 1, Period: time.Hour, DisableHeaders: true})), t)
+
+	e.GET("/").WithHeader("X-Real-Ip", "10.0.0.1").Expect().Status(http.StatusOK).Header(ratelimit.LimitHeader).Empty()
+	r := e.GET("/").WithHeader("X-Real-Ip", "10.0.0.1").Expect().Status(http.StatusTooManyRequests)
+	r.Header(ratelimit.RemainingHeader).Empty()
+	// the Retry-After is always sent.
+	r.Header(ratelimit.RetryAfterHeader).NotEmpty()
+}
+
+type failingStore struct{}
+
+func (failingStore) Incr(key string, ttl time.Duration) (int64, error) {
+	return 0, errors.New("store is down")
+}
+
+func (failingStore) Count(key string) (int64, error) {
+	return 0, errors.New("store is down")
+}
+
+func TestRateLimitStoreFailure(t *testing.T) {
+	e := httptest.New(newApp(ratelimit.New(ratelimit.Config{Algorithm: ratelimit.SlidingWindow, Limit: 1,
+		Store: failingStore{}})), t)
+
+	// the requests are not rejected because of the store's failures.
+	for i := 0; i < 3; i++ {
+		e.GET("/").Expect().Status(http.StatusOK).Header(ratelimit.LimitHeader).Empty()
+	}
+}
+
+func TestRedisStoreExpiry(t *testing.T) {
+	s := service.New(service.Config{Prefix: "ratelimit-test-" + strconv.FormatInt(time.Now().UnixNano(), 36) + ":"})
+	s.Connect()
+	defer s.CloseConnection()
+	if ok, err := s.PingPong(); !ok {
+		t.Skipf("redis is not available at %s: %v", s.Config.Addr, err)
+	}
+	var store ratelimit.Store = s
+
+	for i := int64(1); i <= 2; i++ {
+		if n, err := store.Incr("key", 200*time.Millisecond); err != nil || n != i {
+			t.Fatalf("expected the counter %d but got %d, %v", i, n, err)
+		}
+	}
+	if n, err := store.Count("key"); err != nil || n != 2 {
+		t.Fatalf("expected the count 2 but got %d, %v", n, err)
+	}
+	if n, err := store.Count("none"); err != nil || n != 0 {
+		t.Fatalf("expected zero for a missing counter but got %d, %v", n, err)
+	}
+
+	// the ttl is set once, by the first Incr.
+	time.Sleep(250 * time.Millisecond)
+	if n, err := store.Count("key"); err != nil || n != 0 {
+		t.Fatalf("expected zero for an expired counter but got %d, %v", n, err)
+	}
+	if n, err := store.Incr("key", time.Minute); err != nil || n != 1 {
+		t.Fatalf("expected a new counter but got %d, %v", n, err)
+	}
+
+	e := httptest.New(newApp(ratelimit.New(ratelimit.Config{Algorithm: ratelimit.SlidingWindow, Limit: 1,
+		Period: time.Hour, Store: store})), t)
+	e.GET("/").WithHeader("X-Real-Ip", "10.0.0.1").Expect().Status(http.StatusOK)
+	e.GET("/").WithHeader("X-Real-Ip", "10.0.0.1").Expect().Status(http.StatusTooManyRequests).Header(ratelimit.RetryAfterHeader).NotEmpty()
+}
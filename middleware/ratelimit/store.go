@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Store is the backend of the sliding window's counters,
+// it should be safe for use by multiple goroutines.
+//
+// The redis' service.Service (adaptors/sessions/sessiondb/redis/service) implements it,
+// in order to share the limits between many servers.
+type Store interface {
+	// Incr increments the counter of the key by one and returns its new value,
+	// a new counter expires after the ttl.
+	Incr(key string, ttl time.Duration) (int64, error)
+	// Count returns the value of the counter of the key,
+	// zero if it doesn't exist or it's expired.
+	Count(key string) (int64, error)
+}
+
+// sweepEvery is the number of the operations after which the expired entries are removed.
+const sweepEvery = 1024
+
+type (
+	memoryStore struct {
+		mu       sync.Mutex
+		counters map[string]*counter
+		ops      int
+	}
+
+	counter struct {
+		value   int64
+		expires time.Time
+	}
+)
+
+var _ Store = &memoryStore{}
+
+// NewMemoryStore returns a new in-memory Store, the counters are not shared between processes.
+func NewMemoryStore() Store {
+	return &memoryStore{counters: make(map[string]*counter)}
+}
+
+func (s *memoryStore) Incr(key string, ttl time.Duration) (int64, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+	c, ok := s.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &counter{expires: now.Add(ttl)}
+		s.counters[key] = c
+	}
+	c.value++
+	return c.value, nil
+}
+
+func (s *memoryStore) Count(key string) (int64, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.counters[key]; ok && now.Before(c.expires) {
+		return c.value, nil
+	}
+	return 0, nil
+}
+
+// sweep removes the expired counters, periodically.
+func (s *memoryStore) sweep(now time.Time) {
+	if s.ops++; s.ops < sweepEvery {
+		return
+	}
+	s.ops = 0
+	for k, c := range s.counters {
+		if !now.Before(c.expires) {
+			delete(s.counters, k)
+		}
+	}
+}
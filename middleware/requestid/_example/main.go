@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/middleware/recover"
+	"github.com/go-iris2/iris2/middleware/requestid"
+)
+
+func main() {
+	app := iris2.New()
+
+	// register it first, the recover middleware and the ctx.Log
+	// prefix their messages with the request id and the trace id.
+	app.UseGlobal(requestid.New(), recover.New())
+
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.Log("serving the index")
+		ctx.Writef("request id: %s, trace id: %s", ctx.RequestID(), ctx.TraceID())
+	})
+
+	app.Get("/downstream", func(ctx *iris2.Context) {
+		req, _ := http.NewRequest("GET", "http://localhost:8080/", nil)
+		// continue the trace and the correlation id.
+		req.Header.Set(requestid.TraceParentHeader, requestid.TraceParent(ctx))
+		req.Header.Set(requestid.DefaultHeader, ctx.RequestID())
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			ctx.Log("downstream failed: %v", err)
+			ctx.EmitError(http.StatusBadGateway)
+			return
+		}
+		resp.Body.Close()
+		ctx.Writef("downstream status: %d", resp.StatusCode)
+	})
+
+	app.Get("/panic", func(ctx *iris2.Context) {
+		panic("the logs of this panic have the request id")
+	})
+
+	// curl -i http://localhost:8080 -H "X-Request-ID: my-id"
+	// curl -i http://localhost:8080 -H "traceparent: 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	app.Listen(":8080")
+}
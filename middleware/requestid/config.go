@@ -0,0 +1,48 @@
+package requestid
+
+import (
+	"github.com/imdario/mergo"
+)
+
+const (
+	// DefaultHeader is "X-Request-ID"
+	DefaultHeader = "X-Request-ID"
+	// DefaultMaxLength is the max length of an incoming request id, 128
+	DefaultMaxLength = 128
+)
+
+// Config the configs for the requestid middleware
+type Config struct {
+	// Header is the header which the request id is read from and echoed to. Default is "X-Request-ID"
+	Header string
+	// Generator generates the request ids. Default is a random (version 4) UUID
+	Generator func() string
+	// IgnoreIncoming, if true, a new request id is always generated,
+	// otherwise the incoming header is used if it's valid. Default is false
+	IgnoreIncoming bool
+	// MaxLength is the max length of an incoming request id,
+	// longer ids are replaced by a generated one. Default is 128
+	MaxLength int
+	// DisableTraceContext disables the W3C trace context ('traceparent' and 'tracestate' headers). Default is false
+	DisableTraceContext bool
+}
+
+// DefaultConfig returns the default configs for the requestid middleware
+func DefaultConfig() Config {
+	return Config{
+		Header:    DefaultHeader,
+		Generator: NewUUID,
+		MaxLength: DefaultMaxLength,
+	}
+}
+
+// MergeSingle merges the default with the given config and returns the result
+func (c Config) MergeSingle(cfg Config) (config Config) {
+	config = cfg
+	mergo.Merge(&config, c)
+	// the funcs are not merged.
+	if config.Generator == nil {
+		config.Generator = c.Generator
+	}
+	return
+}
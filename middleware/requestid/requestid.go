@@ -0,0 +1,166 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/go-iris2/iris2"
+)
+
+const (
+	// TraceParentHeader is the W3C trace context's header which identifies the incoming request
+	// in a tracing system, i.e 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+	TraceParentHeader = "traceparent"
+	// TraceStateHeader is the W3C trace context's header with the vendor specific trace data,
+	// it's propagated as it's.
+	TraceStateHeader = "tracestate"
+
+	traceVersion        = "00"
+	invalidTraceVersion = "ff"
+	traceParentLen      = 55 // version-traceid-spanid-flags
+	traceIDLen          = 32
+	spanIDLen           = 16
+	defaultTraceFlags   = "01" // sampled
+	invalidTraceID      = "00000000000000000000000000000000"
+	invalidSpanID       = "0000000000000000"
+)
+
+// New returns the requestid middleware,
+// it reads (or generates) the request id and the W3C trace context,
+// stores them to the context's values (see iris2.RequestIDContextKey, iris2.TraceIDContextKey and iris2.SpanIDContextKey)
+// and echoes them to the response's headers.
+//
+// The ctx.Log and the recover middleware include them automatically,
+// register it before any other middleware, i.e app.UseGlobal(requestid.New()).
+func New(cfg ...Config) iris2.HandlerFunc {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = c.MergeSingle(cfg[0])
+	}
+
+	return func(ctx *iris2.Context) {
+		var id string
+		if !c.IgnoreIncoming {
+			id = ctx.RequestHeader(c.Header)
+			if !validID(id, c.MaxLength) {
+				id = ""
+			}
+		}
+		if id == "" {
+			id = c.Generator()
+		}
+		ctx.Set(iris2.RequestIDContextKey, id)
+		ctx.SetHeader(c.Header, id)
+
+		if !c.DisableTraceContext {
+			traceID, flags, ok := parseTraceParent(ctx.RequestHeader(TraceParentHeader))
+			if ok {
+				if state := ctx.RequestHeader(TraceStateHeader); state != "" {
+					ctx.SetHeader(TraceStateHeader, state)
+				}
+			} else {
+				traceID, flags = randomID(traceIDLen, invalidTraceID), defaultTraceFlags
+			}
+			// this request is a new span of the trace.
+			spanID := randomID(spanIDLen, invalidSpanID)
+
+			ctx.Set(iris2.TraceIDContextKey, traceID)
+			ctx.Set(iris2.SpanIDContextKey, spanID)
+			ctx.SetHeader(TraceParentHeader, traceVersion+"-"+traceID+"-"+spanID+"-"+flags)
+		}
+
+		ctx.Next()
+	}
+}
+
+// TraceParent returns the 'traceparent' of the request's span,
+// it should be sent to the outgoing requests in order to continue the trace, i.e
+// req.Header.Set(requestid.TraceParentHeader, requestid.TraceParent(ctx)).
+func TraceParent(ctx *iris2.Context) string {
+	return ctx.ResponseWriter.Header().Get(TraceParentHeader)
+}
+
+// NewUUID returns a random (version 4) UUID, it's the default request id generator.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic("requestid: unable to generate a random id: " + err.Error())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant RFC 4122
+
+	dst := make([]byte, 36)
+	hex.Encode(dst, b[:4])
+	dst[8] = '-'
+	hex.Encode(dst[9:13], b[4:6])
+	dst[13] = '-'
+	hex.Encode(dst[14:18], b[6:8])
+	dst[18] = '-'
+	hex.Encode(dst[19:23], b[8:10])
+	dst[23] = '-'
+	hex.Encode(dst[24:], b[10:])
+	return string(dst)
+}
+
+// validID accepts the non empty ids of visible ASCII characters, up to the maxLength.
+func validID(id string, maxLength int) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceParent returns the trace id and the flags of a valid 'traceparent',
+// see https://www.w3.org/TR/trace-context/#traceparent-header.
+func parseTraceParent(h string) (traceID string, flags string, ok bool) {
+	h = strings.TrimSpace(h)
+	if len(h) < traceParentLen {
+		return
+	}
+	version := h[:2]
+	if !isHex(version) || version == invalidTraceVersion {
+		return
+	}
+	// future versions may append fields, the known ones are kept.
+	if (version == traceVersion && len(h) != traceParentLen) || (len(h) > traceParentLen && h[traceParentLen] != '-') {
+		return
+	}
+	if h[2] != '-' || h[35] != '-' || h[52] != '-' {
+		return
+	}
+
+	traceID, spanID, flags := h[3:35], h[36:52], h[53:55]
+	if !isHex(traceID) || traceID == invalidTraceID || !isHex(spanID) || spanID == invalidSpanID || !isHex(flags) {
+		return "", "", false
+	}
+	return traceID, flags, true
+}
+
+// isHex accepts only the lowercase hex characters, as the trace context requires.
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// randomID returns a random lowercase hex id of n characters, which is not the invalid (all zeros) one.
+func randomID(n int, invalid string) string {
+	b := make([]byte, n/2)
+	if _, err := rand.Read(b); err != nil {
+		panic("requestid: unable to generate a random id: " + err.Error())
+	}
+	if id := hex.EncodeToString(b); id != invalid {
+		return id
+	}
+	b[len(b)-1] = 1
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,72 @@
+package requestid_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/httptest"
+	"github.com/go-iris2/iris2/middleware/requestid"
+)
+
+func newApp(cfg ...requestid.Config) *iris2.Framework {
+	app := iris2.New()
+	app.UseGlobal(requestid.New(cfg...))
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.WriteString(ctx.GetString(iris2.RequestIDContextKey))
+	})
+	return app
+}
+
+func TestRequestIDPartialConfig(t *testing.T) {
+	// the default Generator is kept.
+	e := httptest.New(newApp(requestid.Config{Header: "X-Req"}), t)
+	r := e.GET("/").Expect().Status(http.StatusOK)
+	id := r.Raw().Header.Get("X-Req")
+	if len(id) != 36 || strings.Count(id, "-") != 4 {
+		t.Fatalf("expected a generated UUID but got '%s'", id)
+	}
+	r.Body().Equal(id)
+	if r.Raw().Header.Get(requestid.DefaultHeader) != "" {
+		t.Fatalf("expected only the configured header")
+	}
+}
+
+func TestRequestIDIncoming(t *testing.T) {
+	e := httptest.New(newApp(requestid.Config{Generator: func() string { return "generated" }, MaxLength: 8}), t)
+
+	e.GET("/").WithHeader(requestid.DefaultHeader, "incoming").Expect().Status(http.StatusOK).
+		Header(requestid.DefaultHeader).Equal("incoming")
+	// too long or invalid ids are replaced.
+	e.GET("/").WithHeader(requestid.DefaultHeader, "too long id").Expect().Status(http.StatusOK).
+		Header(requestid.DefaultHeader).Equal("generated")
+	e.GET("/").WithHeader(requestid.DefaultHeader, "a\tb").Expect().Status(http.StatusOK).
+		Header(requestid.DefaultHeader).Equal("generated")
+	e.GET("/").Expect().Status(http.StatusOK).Body().Equal("generated")
+}
+
+func TestRequestIDTraceContext(t *testing.T) {
+	e := httptest.New(newApp(), t)
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	r := e.GET("/").WithHeader(requestid.TraceParentHeader, parent).
+		WithHeader(requestid.TraceStateHeader, "vendor=value").Expect().Status(http.StatusOK)
+	traceParent := r.Raw().Header.Get(requestid.TraceParentHeader)
+	if !strings.HasPrefix(traceParent, "00-4bf92f3577b34da6a3ce929d0e0e4736-") || traceParent == parent {
+		t.Fatalf("expected a new span of the trace but got '%s'", traceParent)
+	}
+	r.Header(requestid.TraceStateHeader).Equal("vendor=value")
+
+	// an invalid trace parent starts a new trace.
+	r = e.GET("/").WithHeader(requestid.TraceParentHeader, "ff-"+parent[3:]).Expect().Status(http.StatusOK)
+	if traceParent := r.Raw().Header.Get(requestid.TraceParentHeader); len(traceParent) != 55 ||
+		strings.Contains(traceParent, "4bf92f3577b34da6a3ce929d0e0e4736") {
+		t.Fatalf("expected a new trace but got '%s'", traceParent)
+	}
+
+	e = httptest.New(newApp(requestid.Config{DisableTraceContext: true}), t)
+	if traceParent := e.GET("/").Expect().Status(http.StatusOK).Raw().Header.Get(requestid.TraceParentHeader); traceParent != "" {
+		t.Fatalf("expected no trace context but got '%s'", traceParent)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/adaptors/view"
+	"github.com/go-iris2/iris2/middleware/secure"
+)
+
+func main() {
+	app := iris2.New()
+	app.Adapt(view.HTML("./templates", ".html"))
+
+	cfg := secure.DefaultConfig()
+	cfg.FrameOptions = "DENY"
+	// redirect the http:// requests to the https:// scheme
+	cfg.SSLRedirect = true
+	// the app is behind a proxy which terminates the TLS
+	cfg.SSLProxyHeaders = map[string]string{"X-Forwarded-Proto": "https"}
+	s := secure.New(cfg)
+	// registers the csp_nonce template func.
+	app.Adapt(s)
+
+	app.Get("/", func(ctx *iris2.Context) {
+		// the nonce is passed to the template's data as "csp_nonce".
+		ctx.MustRender("index.html", iris2.Map{"title": "Secure"})
+	})
+
+	app.UseGlobal(s)
+
+	app.Listen(":8080")
+}
@@ -0,0 +1,88 @@
+package secure
+
+const (
+	// NoncePlaceholder is replaced by the request's nonce ('nonce-...') on the ContentSecurityPolicy
+	NoncePlaceholder = "$NONCE"
+
+	// DefaultSTSSeconds is the max-age of the Strict-Transport-Security header, one year
+	DefaultSTSSeconds = 31536000
+	// DefaultFrameOptions is the X-Frame-Options header, "SAMEORIGIN"
+	DefaultFrameOptions = "SAMEORIGIN"
+	// DefaultReferrerPolicy is the Referrer-Policy header, "strict-origin-when-cross-origin"
+	DefaultReferrerPolicy = "strict-origin-when-cross-origin"
+	// DefaultPermissionsPolicy is the Permissions-Policy header, "camera=(), geolocation=(), microphone=()"
+	DefaultPermissionsPolicy = "camera=(), geolocation=(), microphone=()"
+	// DefaultContentSecurityPolicy is the Content-Security-Policy header,
+	// only the same origin and the nonce-tagged inline scripts are allowed
+	DefaultContentSecurityPolicy = "default-src 'self'; script-src 'self' " + NoncePlaceholder + "; object-src 'none'; base-uri 'self'"
+	// DefaultNonceLength is the number of the random bytes of a nonce, 16
+	DefaultNonceLength = 16
+)
+
+// Config the configs for the secure middleware,
+// the empty headers are not sent, start from the DefaultConfig in order to change some of them, i.e
+//
+// cfg := secure.DefaultConfig()
+// cfg.FrameOptions = "DENY"
+// app.Use(secure.New(cfg))
+type Config struct {
+	// STSSeconds is the max-age of the Strict-Transport-Security header,
+	// 0 disables it. Default is one year
+	//
+	// The header is sent only to the secure requests, when the app's
+	// Configuration.VScheme is "https://", the request is over TLS or it matches the SSLProxyHeaders.
+	STSSeconds int64
+	// STSIncludeSubdomains appends the "includeSubDomains" directive to the Strict-Transport-Security header.
+	// Default is true
+	STSIncludeSubdomains bool
+	// STSPreload appends the "preload" directive to the Strict-Transport-Security header. Default is false
+	STSPreload bool
+	// ForceSTS sends the Strict-Transport-Security header to the insecure requests too. Default is false
+	ForceSTS bool
+
+	// SSLRedirect, if true, redirects the insecure requests to the https:// scheme. Default is false
+	SSLRedirect bool
+	// SSLTemporaryRedirect, if true, the SSLRedirect is 307 Temporary Redirect,
+	// otherwise 301 Moved Permanently. Default is false
+	SSLTemporaryRedirect bool
+	// SSLHost is the host of the SSLRedirect, i.e "mydomain.com:443".
+	// Default is empty, the request's host without its port
+	SSLHost string
+	// SSLProxyHeaders are the headers and their values which mark a request as secure,
+	// when the app is behind a proxy, i.e map[string]string{"X-Forwarded-Proto": "https"}.
+	// Default is nil
+	SSLProxyHeaders map[string]string
+
+	// FrameOptions is the X-Frame-Options header. Default is "SAMEORIGIN"
+	FrameOptions string
+	// ContentTypeNosniff sends the "X-Content-Type-Options: nosniff" header. Default is true
+	ContentTypeNosniff bool
+	// ReferrerPolicy is the Referrer-Policy header. Default is "strict-origin-when-cross-origin"
+	ReferrerPolicy string
+	// PermissionsPolicy is the Permissions-Policy header. Default is "camera=(), geolocation=(), microphone=()"
+	PermissionsPolicy string
+
+	// ContentSecurityPolicy is the Content-Security-Policy header,
+	// the NoncePlaceholder ("$NONCE") is replaced by the request's nonce.
+	// Default is "default-src 'self'; script-src 'self' $NONCE; object-src 'none'; base-uri 'self'"
+	ContentSecurityPolicy string
+	// ContentSecurityPolicyReportOnly, if true, the ContentSecurityPolicy is sent
+	// by the Content-Security-Policy-Report-Only header instead. Default is false
+	ContentSecurityPolicyReportOnly bool
+	// NonceLength is the number of the random bytes of a nonce. Default is 16
+	NonceLength int
+}
+
+// DefaultConfig returns the default configs for the secure middleware
+func DefaultConfig() Config {
+	return Config{
+		STSSeconds:            DefaultSTSSeconds,
+		STSIncludeSubdomains:  true,
+		FrameOptions:          DefaultFrameOptions,
+		ContentTypeNosniff:    true,
+		ReferrerPolicy:        DefaultReferrerPolicy,
+		PermissionsPolicy:     DefaultPermissionsPolicy,
+		ContentSecurityPolicy: DefaultContentSecurityPolicy,
+		NonceLength:           DefaultNonceLength,
+	}
+}
@@ -0,0 +1,165 @@
+// Package secure provides a security headers middleware for Iris,
+// it sends the HSTS, X-Frame-Options, X-Content-Type-Options, Referrer-Policy, Permissions-Policy
+// and a Content-Security-Policy with a per-request nonce, optionally it redirects HTTP to HTTPS.
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-iris2/iris2"
+)
+
+// NonceContextKey is the context's value key of the request's nonce
+// and the key of the nonce on the templates' data (ViewData), see Nonce
+const NonceContextKey = "csp_nonce"
+
+// Secure is the secure middleware and policy adaptor, see New.
+type Secure struct {
+	config Config
+	sts    string
+}
+
+var _ iris2.Handler = &Secure{}
+
+// New returns the secure middleware, it can be adapted too
+// in order to register the "csp_nonce" template func, i.e
+//
+// s := secure.New()
+// app.Adapt(s)
+// app.UseGlobal(s)
+//
+// The templates can tag their inline scripts by <script nonce="{{ csp_nonce . }}">
+// (the argument is the template's data or the nonce itself).
+func New(cfg ...Config) *Secure {
+	c := DefaultConfig()
+	if len(cfg) > 0 {
+		c = cfg[0]
+	}
+	if c.NonceLength <= 0 {
+		c.NonceLength = DefaultNonceLength
+	}
+
+	s := &Secure{config: c}
+	if c.STSSeconds > 0 {
+		s.sts = "max-age=" + strconv.FormatInt(c.STSSeconds, 10)
+		if c.STSIncludeSubdomains {
+			s.sts += "; includeSubDomains"
+		}
+		if c.STSPreload {
+			s.sts += "; preload"
+		}
+	}
+	return s
+}
+
+// Adapt implements the iris' adaptor, it registers the "csp_nonce" template func.
+func (s *Secure) Adapt(frame *iris2.Policies) {
+	iris2.TemplateFuncsPolicy{
+		"csp_nonce": nonceOf,
+	}.Adapt(frame)
+}
+
+// Serve serves the middleware, it redirects the insecure requests if SSLRedirect is enabled
+// and sends the security headers.
+func (s *Secure) Serve(ctx *iris2.Context) {
+	tls := s.isSecure(ctx)
+	if s.config.SSLRedirect && !tls {
+		s.redirect(ctx)
+		return
+	}
+
+	if s.sts != "" && (s.config.ForceSTS || tls || ctx.Framework().Config.VScheme == iris2.SchemeHTTPS) {
+		ctx.SetHeader("Strict-Transport-Security", s.sts)
+	}
+	if s.config.FrameOptions != "" {
+		ctx.SetHeader("X-Frame-Options", s.config.FrameOptions)
+	}
+	if s.config.ContentTypeNosniff {
+		ctx.SetHeader("X-Content-Type-Options", "nosniff")
+	}
+	if s.config.ReferrerPolicy != "" {
+		ctx.SetHeader("Referrer-Policy", s.config.ReferrerPolicy)
+	}
+	if s.config.PermissionsPolicy != "" {
+		ctx.SetHeader("Permissions-Policy", s.config.PermissionsPolicy)
+	}
+
+	if csp := s.config.ContentSecurityPolicy; csp != "" {
+		if strings.Contains(csp, NoncePlaceholder) {
+			nonce := s.generate()
+			ctx.Set(NonceContextKey, nonce)
+			ctx.ViewData(NonceContextKey, nonce)
+			csp = strings.Replace(csp, NoncePlaceholder, "'nonce-"+nonce+"'", -1)
+		}
+
+		header := "Content-Security-Policy"
+		if s.config.ContentSecurityPolicyReportOnly {
+			header += "-Report-Only"
+		}
+		ctx.SetHeader(header, csp)
+	}
+
+	ctx.Next()
+}
+
+// isSecure reports whether the request is over TLS, directly or behind a proxy.
+func (s *Secure) isSecure(ctx *iris2.Context) bool {
+	if ctx.Request.TLS != nil {
+		return true
+	}
+	for k, v := range s.config.SSLProxyHeaders {
+		if strings.EqualFold(ctx.RequestHeader(k), v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Secure) redirect(ctx *iris2.Context) {
+	host := s.config.SSLHost
+	if host == "" {
+		host = ctx.Host()
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+
+	status := http.StatusMovedPermanently
+	if s.config.SSLTemporaryRedirect {
+		status = http.StatusTemporaryRedirect
+	}
+	ctx.Redirect(iris2.SchemeHTTPS+host+ctx.Request.URL.RequestURI(), status)
+}
+
+func (s *Secure) generate() string {
+	b := make([]byte, s.config.NonceLength)
+	if _, err := rand.Read(b); err != nil {
+		panic("secure: unable to generate a nonce: " + err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// nonceOf is the "csp_nonce" template func, it returns the nonce of the template's data.
+func nonceOf(data interface{}) string {
+	switch v := data.(type) {
+	case string:
+		return v
+	case iris2.Map:
+		s, _ := v[NonceContextKey].(string)
+		return s
+	case map[string]interface{}:
+		s, _ := v[NonceContextKey].(string)
+		return s
+	}
+	return ""
+}
+
+// Nonce returns the request's Content-Security-Policy nonce, the middleware should be executed before.
+func Nonce(ctx *iris2.Context) string {
+	return ctx.GetString(NonceContextKey)
+}
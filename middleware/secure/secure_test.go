@@ -0,0 +1,171 @@
+package secure_test
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"net/http"
+	nethttptest "net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-iris2/iris2"
+	"github.com/go-iris2/iris2/httptest"
+	"github.com/go-iris2/iris2/middleware/secure"
+)
+
+func newApp(s *secure.Secure) *iris2.Framework {
+	app := iris2.New()
+	app.Adapt(s)
+	app.Get("/nonce", func(ctx *iris2.Context) {
+		ctx.WriteString(secure.Nonce(ctx))
+	})
+	app.UseGlobal(s)
+	return app
+}
+
+// serve serves the request directly, the redirects are not followed.
+func serve(app *iris2.Framework, req *http.Request) *nethttptest.ResponseRecorder {
+	app.Boot()
+	res := nethttptest.NewRecorder()
+	app.Router.ServeHTTP(res, req)
+	return res
+}
+
+func TestSecureHeaders(t *testing.T) {
+	e := httptest.New(newApp(secure.New()), t)
+
+	r := e.GET("/nonce").Expect().Status(http.StatusOK)
+	r.Header("X-Frame-Options").Equal(secure.DefaultFrameOptions)
+	r.Header("X-Content-Type-Options").Equal("nosniff")
+	r.Header("Referrer-Policy").Equal(secure.DefaultReferrerPolicy)
+	r.Header("Permissions-Policy").Equal(secure.DefaultPermissionsPolicy)
+	// the request is not secure.
+	r.Header("Strict-Transport-Security").Empty()
+
+	// the empty headers are not sent.
+	cfg := secure.DefaultConfig()
+	cfg.FrameOptions = ""
+	cfg.ContentSecurityPolicy = ""
+	cfg.ForceSTS = true
+	cfg.STSPreload = true
+	e = httptest.New(newApp(secure.New(cfg)), t)
+	r = e.GET("/nonce").Expect().Status(http.StatusOK)
+	r.Header("X-Frame-Options").Empty()
+	r.Header("Content-Security-Policy").Empty()
+	r.Header("Strict-Transport-Security").Equal("max-age=31536000; includeSubDomains; preload")
+	r.Body().Empty()
+}
+
+func TestSecureNonce(t *testing.T) {
+	e := httptest.New(newApp(secure.New()), t)
+
+	var nonces []string
+	for i := 0; i < 2; i++ {
+		r := e.GET("/nonce").Expect().Status(http.StatusOK)
+		nonce := r.Body().Raw()
+		if b, err := base64.StdEncoding.DecodeString(nonce); err != nil || len(b) != secure.DefaultNonceLength {
+			t.Fatalf("expected a base64 nonce of %d bytes but got '%s'", secure.DefaultNonceLength, nonce)
+		}
+		expected := strings.Replace(secure.DefaultContentSecurityPolicy, secure.NoncePlaceholder, "'nonce-"+nonce+"'", -1)
+		r.Header("Content-Security-Policy").Equal(expected)
+		nonces = append(nonces, nonce)
+	}
+	if nonces[0] == nonces[1] {
+		t.Fatalf("expected a new nonce per request but got '%s' twice", nonces[0])
+	}
+
+	// all the placeholders are replaced, the report only header is used instead.
+	cfg := secure.DefaultConfig()
+	cfg.ContentSecurityPolicy = "script-src $NONCE; style-src $NONCE"
+	cfg.ContentSecurityPolicyReportOnly = true
+	cfg.NonceLength = 8
+	e = httptest.New(newApp(secure.New(cfg)), t)
+	r := e.GET("/nonce").Expect().Status(http.StatusOK)
+	nonce := r.Body().Raw()
+	if b, _ := base64.StdEncoding.DecodeString(nonce); len(b) != 8 {
+		t.Fatalf("expected a nonce of 8 bytes but got '%s'", nonce)
+	}
+	r.Header("Content-Security-Policy").Empty()
+	r.Header("Content-Security-Policy-Report-Only").Equal("script-src 'nonce-" + nonce + "'; style-src 'nonce-" + nonce + "'")
+
+	// without the placeholder, no nonce is generated.
+	cfg.ContentSecurityPolicy = "default-src 'self'"
+	e = httptest.New(newApp(secure.New(cfg)), t)
+	e.GET("/nonce").Expect().Status(http.StatusOK).Body().Empty()
+}
+
+func TestSecureSSLRedirect(t *testing.T) {
+	cfg := secure.DefaultConfig()
+	cfg.SSLRedirect = true
+	cfg.SSLProxyHeaders = map[string]string{"X-Forwarded-Proto": "https"}
+	app := newApp(secure.New(cfg))
+
+	res := serve(app, nethttptest.NewRequest(http.MethodGet, "http://example.com:8080/nonce?a=b", nil))
+	if res.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected a permanent redirect but got %d", res.Code)
+	}
+	if location := res.Header().Get("Location"); location != "https://example.com/nonce?a=b" {
+		t.Fatalf("expected the redirect to the https host without the port but got '%s'", location)
+	}
+	// the insecure requests are redirected before the headers.
+	if sts := res.Header().Get("Strict-Transport-Security"); sts != "" {
+		t.Fatalf("expected no Strict-Transport-Security on the redirect but got '%s'", sts)
+	}
+
+	// the proxy header marks the request as secure, case-insensitive.
+	req := nethttptest.NewRequest(http.MethodGet, "http://example.com/nonce", nil)
+	req.Header.Set("X-Forwarded-Proto", "HTTPS")
+	res = serve(app, req)
+	if res.Code != http.StatusOK {
+		t.Fatalf("expected the request behind the proxy to be served but got %d", res.Code)
+	}
+	if sts := res.Header().Get("Strict-Transport-Security"); sts != "max-age=31536000; includeSubDomains" {
+		t.Fatalf("expected the Strict-Transport-Security on the secure request but got '%s'", sts)
+	}
+
+	// any other value is not secure.
+	req = nethttptest.NewRequest(http.MethodGet, "http://example.com/nonce", nil)
+	req.Header.Set("X-Forwarded-Proto", "http")
+	if res = serve(app, req); res.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected the insecure request behind the proxy to be redirected but got %d", res.Code)
+	}
+
+	// over TLS.
+	req = nethttptest.NewRequest(http.MethodGet, "https://example.com/nonce", nil)
+	req.TLS = &tls.ConnectionState{}
+	if res = serve(app, req); res.Code != http.StatusOK || res.Header().Get("Strict-Transport-Security") == "" {
+		t.Fatalf("expected the TLS request to be served with the Strict-Transport-Security but got %d", res.Code)
+	}
+}
+
+func TestSecureSSLHost(t *testing.T) {
+	cfg := secure.DefaultConfig()
+	cfg.SSLRedirect = true
+	cfg.SSLTemporaryRedirect = true
+	cfg.SSLHost = "secure.example.com:8443"
+	app := newApp(secure.New(cfg))
+
+	res := serve(app, nethttptest.NewRequest(http.MethodGet, "http://example.com/nonce", nil))
+	if res.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("expected a temporary redirect but got %d", res.Code)
+	}
+	if location := res.Header().Get("Location"); location != "https://secure.example.com:8443/nonce" {
+		t.Fatalf("expected the redirect to the SSLHost but got '%s'", location)
+	}
+}
+
+func TestSecureTemplateFunc(t *testing.T) {
+	policies := &iris2.Policies{}
+	secure.New().Adapt(policies)
+	nonce := policies.TemplateFuncsPolicy["csp_nonce"].(func(interface{}) string)
+
+	if got := nonce(iris2.Map{secure.NonceContextKey: "abc"}); got != "abc" {
+		t.Fatalf("expected the nonce of the template's data but got '%s'", got)
+	}
+	if got := nonce("abc"); got != "abc" {
+		t.Fatalf("expected the nonce itself but got '%s'", got)
+	}
+	if got := nonce(nil); got != "" {
+		t.Fatalf("expected no nonce but got '%s'", got)
+	}
+}
@@ -93,8 +93,10 @@ type (
 		Build EventListener
 		// Interrupted with a listener type of EventListener.
 		//   Fires after the terminal is interrupted manually by Ctrl/Cmd + C
+		//   or when the process receives a SIGTERM,
 		//   which should be used to release external resources.
-		// Iris will close and os.Exit at the end of custom interrupted events.
+		// Iris will Close (gracefully) and os.Exit at the end of custom interrupted events,
+		// see Configuration.DisableExitOnInterrupt and Framework.OnShutdown.
 		// If you want to prevent the default behavior just block on the custom Interrupted event.
 		Interrupted EventListener
 		// Recover with a listener type of func(*Framework, interface{}).
@@ -413,6 +415,16 @@ type (
 		// the values at realtime and if manager doesn't supports these
 		// then the user manually have to call its 'done' func inside the handler.
 		Destroy func(http.ResponseWriter, *http.Request)
+
+		// Regenerate should give a new id to the request's session, which is passed,
+		// keeping its values, and send the new id to the client.
+		// It's optional, it's used by the context.SessionRegenerate, i.e after a login.
+		Regenerate func(http.ResponseWriter, *http.Request, Session) Session
+
+		// End is called when the request which started the session is served,
+		// i.e to store the session's changes in one batch.
+		// It's optional.
+		End func(Session)
 	}
 )
 
@@ -427,4 +439,10 @@ func (s SessionsPolicy) Adapt(frame *Policies) {
 	if s.Destroy != nil {
 		frame.SessionsPolicy.Destroy = s.Destroy
 	}
+	if s.Regenerate != nil {
+		frame.SessionsPolicy.Regenerate = s.Regenerate
+	}
+	if s.End != nil {
+		frame.SessionsPolicy.End = s.End
+	}
 }
@@ -3,6 +3,7 @@ package iris2
 import (
 	"fmt"
 	"net/http"
+	"reflect"
 	"sync"
 )
 
@@ -97,6 +98,12 @@ func (w *ResponseRecorder) Write(contents []byte) (int, error) {
 	return len(w.chunks), nil
 }
 
+// Written returns the number of the body's bytes written so far,
+// the recorded body is included although it's not flushed yet.
+func (w *ResponseRecorder) Written() int {
+	return w.responseWriter.written + len(w.chunks)
+}
+
 // Body returns the body tracked from the writer so far
 // do not use this for edit.
 func (w *ResponseRecorder) Body() []byte {
@@ -134,7 +141,9 @@ func (w *ResponseRecorder) Reset() {
 // flushResponse the full body, headers and status code to the underline response writer
 // called automatically at the end of each request, see ReleaseCtx
 func (w *ResponseRecorder) flushResponse() {
-	if w.headers != nil {
+	// the saved headers are the underline's headers unless they are replaced,
+	// they are not added twice.
+	if w.headers != nil && reflect.ValueOf(w.headers).Pointer() != reflect.ValueOf(w.responseWriter.Header()).Pointer() {
 		for k, values := range w.headers {
 			for i := range values {
 				w.responseWriter.Header().Add(k, values[i])
@@ -153,8 +162,13 @@ func (w *ResponseRecorder) flushResponse() {
 	}
 }
 
-// Flush sends any buffered data to the client.
+// Flush sends any buffered data to the client,
+// the recorded body is sent and reset.
 func (w *ResponseRecorder) Flush() {
+	if len(w.chunks) > 0 {
+		// ignore error
+		w.responseWriter.Write(w.chunks)
+	}
 	w.responseWriter.Flush()
 	w.ResetBody()
 }
@@ -201,6 +215,17 @@ func (w *ResponseRecorder) clone() ResponseWriter {
 
 // writeTo writes a response writer (temp: status code, headers and body) to another response writer
 func (w *ResponseRecorder) writeTo(res ResponseWriter) {
+	if c, ok := res.(*compressResponseWriter); ok {
+		// the status code and the headers to the underline writer, the body is compressed.
+		body := w.chunks
+		w.chunks = nil
+		w.writeTo(c.ResponseWriter)
+		w.chunks = body
+		if len(body) > 0 {
+			c.Write(body)
+		}
+		return
+	}
 
 	if to, ok := res.(*ResponseRecorder); ok {
 
@@ -227,8 +252,8 @@ func (w *ResponseRecorder) writeTo(res ResponseWriter) {
 			to.statusCodeSent = w.statusCodeSent
 		}
 
-		// append the headers
-		if w.headers != nil {
+		// append the headers, the clones share them unless they are replaced.
+		if w.headers != nil && reflect.ValueOf(w.headers).Pointer() != reflect.ValueOf(to.headers).Pointer() {
 			for k, values := range w.headers {
 				for _, v := range values {
 					if to.headers.Get(v) == "" {
@@ -45,6 +45,7 @@ func acquireResponseWriter(underline http.ResponseWriter) *responseWriter {
 
 func releaseResponseWriter(w *responseWriter) {
 	w.statusCodeSent = false
+	w.written = 0
 	w.beforeFlush = nil
 	w.statusCode = http.StatusOK
 	rpool.Put(w)
@@ -69,6 +70,8 @@ type ResponseWriter interface {
 	SetContentType(cType string)
 	ContentType() string
 	StatusCode() int
+	// Written returns the number of the body's bytes written so far.
+	Written() int
 	SetBeforeFlush(cb func())
 	flushResponse()
 	clone() ResponseWriter
@@ -82,6 +85,7 @@ type responseWriter struct {
 	http.ResponseWriter
 	statusCode     int  // the saved status code which will be used from the cache service
 	statusCodeSent bool // reply header has been (logically) written
+	written        int  // the number of the body's bytes written to the underline http.ResponseWriter
 	// yes only one callback, we need simplicity here because on EmitError the beforeFlush events should NOT be cleared
 	// but the response is cleared.
 	// Sometimes is useful to keep the event,
@@ -96,12 +100,19 @@ func (w *responseWriter) StatusCode() int {
 	return w.statusCode
 }
 
+// Written returns the number of the body's bytes written so far.
+func (w *responseWriter) Written() int {
+	return w.written
+}
+
 // Writef formats according to a format specifier and writes to the response.
 //
 // Returns the number of bytes written and any write error encountered
 func (w *responseWriter) Writef(format string, a ...interface{}) (n int, err error) {
 	w.tryWriteHeader()
-	return fmt.Fprintf(w.ResponseWriter, format, a...)
+	n, err = fmt.Fprintf(w.ResponseWriter, format, a...)
+	w.written += n
+	return
 }
 
 // WriteString writes a simple string to the response.
@@ -131,7 +142,9 @@ func (w *responseWriter) WriteString(s string) (n int, err error) {
 // possible to maximize compatibility.
 func (w *responseWriter) Write(contents []byte) (int, error) {
 	w.tryWriteHeader()
-	return w.ResponseWriter.Write(contents)
+	n, err := w.ResponseWriter.Write(contents)
+	w.written += n
+	return n, err
 }
 
 // prin to write na benei to write header
@@ -284,6 +297,7 @@ func (w *responseWriter) clone() ResponseWriter {
 	wc.statusCode = w.statusCode
 	wc.beforeFlush = w.beforeFlush
 	wc.statusCodeSent = w.statusCodeSent
+	wc.written = w.written
 	return wc
 }
 
@@ -162,3 +162,48 @@ func ExampleResponseWriter_WriteHeader() {
 	// Output:
 	// Hey
 }
+
+func TestResponseWriterWritten(t *testing.T) {
+	app := iris2.New()
+	app.Adapt(newTestNativeRouter())
+
+	written := func(ctx *iris2.Context) {
+		ctx.Next()
+		ctx.Writef(" %d", ctx.ResponseWriter.Written())
+	}
+
+	app.Get("/", written, func(ctx *iris2.Context) {
+		ctx.WriteString("hello")
+	})
+	app.Get("/recorder", written, func(ctx *iris2.Context) {
+		ctx.Record()
+		ctx.Writef("hello %s", "world")
+	})
+
+	e := httptest.New(app, t)
+
+	e.GET("/").Expect().Status(http.StatusOK).Body().Equal("hello 5")
+	e.GET("/recorder").Expect().Status(http.StatusOK).Body().Equal("hello world 11")
+}
+
+func TestResponseRecorderHeadersNotDuplicated(t *testing.T) {
+	app := iris2.New()
+	app.Adapt(newTestNativeRouter())
+
+	app.Get("/", func(ctx *iris2.Context) {
+		ctx.Record()
+		ctx.SetHeader("X-Custom", "value")
+		ctx.ResponseWriter.Header().Add("X-Multi", "1")
+		ctx.ResponseWriter.Header().Add("X-Multi", "2")
+		ctx.WriteString("body")
+	})
+
+	e := httptest.New(app, t)
+	header := e.GET("/").Expect().Status(http.StatusOK).Raw().Header
+	if values := header["X-Custom"]; len(values) != 1 {
+		t.Fatalf("expected one X-Custom header but got %v", values)
+	}
+	if values := header["X-Multi"]; len(values) != 2 {
+		t.Fatalf("expected two X-Multi headers but got %v", values)
+	}
+}
@@ -580,7 +580,7 @@ func (router *Router) StaticWeb(reqPath string, systemPath string, exceptRoutes
 	paramName := "file"
 	routePath := validateWildcard(reqPath, paramName)
 	handler := func(ctx *Context) {
-		h(ctx)
+		// set before the file server, which keeps it for the file and for the parts of the multipart ranges.
 		if fname := ctx.Param(paramName); fname != "" {
 			cType := TypeByExtension(fname)
 			if cType != contentBinary && !strings.Contains(cType, "charset") {
@@ -589,7 +589,7 @@ func (router *Router) StaticWeb(reqPath string, systemPath string, exceptRoutes
 
 			ctx.SetContentType(cType)
 		}
-
+		h(ctx)
 	}
 
 	return router.registerResourceRoute(routePath, handler)
@@ -0,0 +1,210 @@
+package iris2
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-iris2/iris2/errors"
+)
+
+// DefaultStaticFSIndex is the default index file of the StaticFS, "index.html"
+const DefaultStaticFSIndex = "index.html"
+
+var errStaticFS = errors.New("iris2.StaticFS: unable to index the file system: %s")
+
+// StaticFSOptions are the optional settings of the StaticFS.
+type StaticFSOptions struct {
+	// Index is the file which is served for a directory. Default is "index.html"
+	Index string
+	// SPA, if true, the root's Index is served for the paths which don't match a file
+	// and have no extension, for the client-side routing of the single page applications.
+	// Default is false
+	SPA bool
+}
+
+// staticFSEncodings are the precompressed variants of the StaticFS, by preference,
+// "file.js.br" and "file.js.gz" are the variants of the "file.js".
+var staticFSEncodings = []struct {
+	name      string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+type (
+	// staticFSFile is an indexed file of the StaticFS.
+	staticFSFile struct {
+		name    string
+		size    int64
+		etag    string
+		modtime time.Time
+		cType   string
+		// variants are the precompressed files, by encoding.
+		variants map[string]*staticFSFile
+	}
+
+	staticFS struct {
+		fsys  fs.FS
+		files map[string]*staticFSFile
+		opts  StaticFSOptions
+	}
+)
+
+// StaticFS serves the files of the fsys, i.e an embed.FS, to the requestPath,
+// i.e app.StaticFS("/static", assets) where assets is an embed.FS of the "static" directory
+// or app.StaticFS("/static", fs.Sub(assets, "static")) to strip the directory.
+//
+// The files are indexed on the registration, their ETags are their contents' hashes
+// and the precompressed siblings, "file.js.br" and "file.js.gz",
+// are served instead of the "file.js" to the clients which accept their encoding.
+// The directories are served by their Index file and, with the SPA option,
+// the root's Index is served for the client-side routes.
+//
+// panics if the file system can't be indexed.
+func (router *Router) StaticFS(requestPath string, fsys fs.FS, options ...StaticFSOptions) RouteInfo {
+	opts := StaticFSOptions{}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.Index == "" {
+		opts.Index = DefaultStaticFSIndex
+	}
+
+	s := &staticFS{fsys: fsys, files: make(map[string]*staticFSFile), opts: opts}
+	if err := s.index(); err != nil {
+		panic(errStaticFS.Format(err.Error()))
+	}
+
+	paramName := "path"
+	requestPath = router.Context.Framework().policies.RouterReversionPolicy.WildcardPath(requestPath, paramName)
+
+	return router.registerResourceRoute(requestPath, func(ctx *Context) {
+		s.serve(ctx, ctx.Param(paramName))
+	})
+}
+
+// index walks the file system and hashes its files.
+func (s *staticFS) index() error {
+	err := fs.WalkDir(s.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		f, err := s.fsys.Open(name)
+		if err != nil {
+			return err
+		}
+		h := sha256.New()
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+
+		s.files[name] = &staticFSFile{
+			name:    name,
+			size:    info.Size(),
+			etag:    `"` + base64.RawURLEncoding.EncodeToString(h.Sum(nil)[:18]) + `"`,
+			modtime: info.ModTime(),
+			cType:   TypeByExtension(name),
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for name, file := range s.files {
+		for _, enc := range staticFSEncodings {
+			if variant, ok := s.files[name+enc.extension]; ok {
+				if file.variants == nil {
+					file.variants = make(map[string]*staticFSFile)
+				}
+				// the variant keeps its own ETag, they are different representations.
+				file.variants[enc.name] = variant
+			}
+		}
+	}
+	return nil
+}
+
+// lookup returns the file of the request's path, its directory's Index or the SPA's Index.
+func (s *staticFS) lookup(reqPath string) *staticFSFile {
+	name := strings.Trim(reqPath, "/")
+	if name == "" {
+		name = "."
+	}
+	// cleans the ../ too.
+	name = path.Clean(name)
+
+	if file, ok := s.files[name]; ok {
+		return file
+	}
+	if file, ok := s.files[path.Join(name, s.opts.Index)]; ok {
+		return file
+	}
+	if s.opts.SPA && path.Ext(name) == "" {
+		return s.files[s.opts.Index]
+	}
+	return nil
+}
+
+func (s *staticFS) serve(ctx *Context, reqPath string) {
+	file := s.lookup(reqPath)
+	if file == nil {
+		ctx.EmitError(http.StatusNotFound)
+		return
+	}
+
+	header := ctx.ResponseWriter.Header()
+	served := file
+	if len(file.variants) > 0 {
+		header.Add(varyHeader, acceptEncodingHeader)
+		for _, enc := range staticFSEncodings {
+			if variant, ok := file.variants[enc.name]; ok && ctx.clientAcceptsEncoding(enc.name) {
+				header.Set(contentEncodingHeader, enc.name)
+				served = variant
+				break
+			}
+		}
+	}
+
+	header.Set(etagHeader, served.etag)
+	if !file.modtime.IsZero() {
+		header.Set(lastModified, file.modtime.UTC().Format(ctx.framework.Config.TimeFormat))
+	}
+	if ctx.clientCacheFresh(served.etag, file.modtime) {
+		header.Del(contentEncodingHeader)
+		ctx.SetStatusCode(http.StatusNotModified)
+		return
+	}
+
+	header.Set(contentType, file.cType)
+	header.Set(contentLength, strconv.FormatInt(served.size, 10))
+	ctx.SetStatusCode(http.StatusOK)
+	if ctx.Method() == MethodHead {
+		return
+	}
+
+	f, err := s.fsys.Open(served.name)
+	if err != nil {
+		ctx.Log("error while serving via StaticFS: %s", err.Error())
+		return
+	}
+	defer f.Close()
+	if _, err = io.Copy(ctx.ResponseWriter, f); err != nil {
+		ctx.Log("error while serving via StaticFS: %s", err.Error())
+	}
+}
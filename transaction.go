@@ -42,29 +42,55 @@ func NewTransactionErrResult() TransactionErrResult {
 //
 // Create and set custom transactions scopes with transaction.SetScope.
 //
+// Transactions can be nested, see Transaction.BeginTransaction,
+// and they can register rollback callbacks, see Transaction.OnRollback.
+//
 // For more information please view the tests
 type Transaction struct {
 	Context  *Context
 	parent   *Context
 	hasError bool
 	scope    TransactionScope
+
+	// outer is the transaction which began this one, nil for the request's transactions.
+	outer *Transaction
+	log   *transactionLog
+	// index is the position of the transaction's result on the log.
+	index     int
+	rollbacks []func()
+	completed bool
 }
 
-func newTransaction(from *Context) *Transaction {
+func newTransaction(from *Context, outer *Transaction, log *transactionLog) *Transaction {
 	tempCtx := *from
 	writer := tempCtx.ResponseWriter.clone()
+	if rec, ok := writer.(*ResponseRecorder); ok {
+		// the transaction's body starts empty, it's appended to the parent's body,
+		// which may be written already, i.e by the outer transaction.
+		rec.chunks = nil
+	}
 	tempCtx.ResponseWriter = writer //from.ResponseWriter.clone() // &(*tempCtx.ResponseWriter.(*ResponseRecorder))
 	t := &Transaction{
 		parent:  from,
 		Context: &tempCtx,
 		scope:   TransientTransactionScope,
+		outer:   outer,
+		log:     log,
 	}
-
+	t.index = log.add(t.depth(), TransactionPending)
 	return t
 }
 
+// depth returns the nesting level of the transaction, 0 for the request's transactions.
+func (t *Transaction) depth() (d int) {
+	for o := t.outer; o != nil; o = o.outer {
+		d++
+	}
+	return
+}
+
 // SetScope sets the current transaction's scope
-// iris2.RequestTransactionScope || iris2.TransientTransactionScope (default)
+// iris2.RequestTransactionScope || iris2.AbortTransactionScope || iris2.TransientTransactionScope (default)
 func (t *Transaction) SetScope(scope TransactionScope) {
 	t.scope = scope
 }
@@ -102,6 +128,9 @@ func (t *Transaction) Complete(err error) {
 		maybeErr.Reason = reason
 		maybeErr.ContentType = cType
 	}
+	if !t.completed {
+		t.end(maybeErr)
+	}
 	// the transaction ends with error or not error, it decides what to do next with its Response
 	// the Response is appended to the parent context an all cases but it checks for empty body,headers and all that,
 	// if they are empty (silent error or not error at all)
@@ -112,6 +141,98 @@ func (t *Transaction) Complete(err error) {
 	}
 }
 
+// OnRollback registers a callback which undoes an action of the transaction, i.e a database write.
+// The callbacks are called in the reverse order when the transaction fails,
+// or, after it's completed successfully, when its outer transaction fails
+// or the request's transactions are rolled back, see Context.RollbackTransactions.
+func (t *Transaction) OnRollback(cb func()) {
+	t.rollbacks = append(t.rollbacks, cb)
+}
+
+// BeginTransaction starts a nested transaction, a savepoint of this one.
+// The nested transaction's response is merged to this transaction's response
+// and, if it fails, only its own rollback callbacks are called and this transaction continues,
+// depending on the nested transaction's scope.
+// If this transaction fails after the nested one is completed,
+// the nested one is rolled back too.
+func (t *Transaction) BeginTransaction(pipe func(transaction *Transaction)) {
+	t.Context.beginTransaction(pipe, t)
+}
+
+// end records the transaction's result and calls the rollback callbacks if it's failed,
+// otherwise it passes them to its outer transaction, if any.
+func (t *Transaction) end(maybeErr TransactionErrResult) {
+	t.completed = true
+	result := &t.log.results[t.index]
+
+	if maybeErr.IsFailure() {
+		t.rollback()
+		result.Status = TransactionRolledBack
+		result.Err = maybeErr
+		return
+	}
+
+	result.Status = TransactionCommitted
+	if t.outer != nil {
+		t.outer.OnRollback(t.compensate)
+		return
+	}
+	t.log.committed = append(t.log.committed, t)
+}
+
+// rollback calls the rollback callbacks in the reverse order, once.
+func (t *Transaction) rollback() {
+	for i := len(t.rollbacks) - 1; i >= 0; i-- {
+		t.rollbacks[i]()
+	}
+	t.rollbacks = nil
+}
+
+// compensate rolls back a completed transaction.
+func (t *Transaction) compensate() {
+	t.rollback()
+	t.log.results[t.index].Status = TransactionCompensated
+}
+
+// TransactionStatus is the outcome of a transaction, see TransactionResult.
+type TransactionStatus uint8
+
+const (
+	// TransactionPending is the status of a transaction which is still running
+	TransactionPending TransactionStatus = iota
+	// TransactionCommitted is the status of a transaction which is completed successfully
+	TransactionCommitted
+	// TransactionRolledBack is the status of a transaction which is failed, or panicked,
+	// its rollback callbacks are called
+	TransactionRolledBack
+	// TransactionCompensated is the status of a transaction which is completed successfully
+	// but it's rolled back later, because of its outer transaction or a later transaction failed
+	TransactionCompensated
+	// TransactionSkipped is the status of a transaction which didn't run because the transactions are skipped
+	TransactionSkipped
+)
+
+// TransactionResult is the outcome of a transaction, see Context.TransactionResults.
+type TransactionResult struct {
+	// Depth is the nesting level of the transaction, 0 for the transactions which are began by the Context.
+	Depth  int
+	Status TransactionStatus
+	// Err is the transaction's failure, if it's rolled back.
+	Err TransactionErrResult
+}
+
+// transactionLog keeps the transactions of a request, it's stored on the request's Context.
+type transactionLog struct {
+	results []TransactionResult
+	// committed are the completed transactions of the request, without the nested ones.
+	committed []*Transaction
+}
+
+func (l *transactionLog) add(depth int, status TransactionStatus) int {
+	l.results = append(l.results, TransactionResult{Depth: depth, Status: status})
+	return len(l.results) - 1
+}
+
 // TransactionScope is the manager of the transaction's response, can be resseted and skipped
 // from its parent context or execute an error or skip other transactions
 type TransactionScope interface {
@@ -140,6 +261,21 @@ var TransientTransactionScope = TransactionScopeFunc(func(maybeErr TransactionEr
 	return true
 })
 
+// AbortTransactionScope explanation:
+//
+// if transaction fails (if transaction.IsFailure() == true)
+// then its response is not written to the real context, like the TransientTransactionScope,
+// the previous completed transactions of the request are rolled back, see Context.RollbackTransactions,
+// and the next transactions are skipped. The normal flow continues and no error is provided to the user.
+var AbortTransactionScope = TransactionScopeFunc(func(maybeErr TransactionErrResult, ctx *Context) bool {
+	if maybeErr.IsFailure() {
+		ctx.Recorder().Reset()
+		ctx.RollbackTransactions()
+		return false
+	}
+	return true
+})
+
 // RequestTransactionScope explanation:
 //
 // if scope fails (if transaction.IsFailure() == true)
@@ -121,3 +121,125 @@ func TestTransaction(t *testing.T) {
 		Body().
 		Equal(customErrorTemplateText)
 }
+
+func TestTransactionRollback(t *testing.T) {
+	app := iris2.New()
+	app.Adapt(newTestNativeRouter())
+
+	var undone []string
+	undo := func(name string) func() {
+		return func() { undone = append(undone, name) }
+	}
+	fail := func(transaction *iris2.Transaction) {
+		err := iris2.NewTransactionErrResult()
+		err.StatusCode = http.StatusConflict
+		err.Reason = "conflict"
+		transaction.Complete(err)
+	}
+
+	app.Get("/nested", func(ctx *iris2.Context) {
+		ctx.BeginTransaction(func(t *iris2.Transaction) {
+			t.OnRollback(undo("outer"))
+			t.Context.WriteString("outer;")
+
+			t.BeginTransaction(func(t *iris2.Transaction) {
+				t.OnRollback(undo("savepoint"))
+				t.Context.WriteString("savepoint;")
+				fail(t)
+			})
+			t.BeginTransaction(func(t *iris2.Transaction) {
+				t.OnRollback(undo("inner"))
+				t.Context.WriteString("inner;")
+			})
+		})
+		ctx.BeginTransaction(func(t *iris2.Transaction) {
+			t.OnRollback(undo("failed"))
+			t.OnRollback(undo("failed first"))
+			t.Context.WriteString("failed;")
+			fail(t)
+		})
+		ctx.Next()
+	})
+
+	app.Get("/abort", func(ctx *iris2.Context) {
+		ctx.BeginTransaction(func(t *iris2.Transaction) {
+			t.OnRollback(undo("first"))
+			t.BeginTransaction(func(t *iris2.Transaction) {
+				t.OnRollback(undo("first nested"))
+			})
+			t.Context.WriteString("first;")
+		})
+		ctx.BeginTransaction(func(t *iris2.Transaction) {
+			t.SetScope(iris2.AbortTransactionScope)
+			t.OnRollback(undo("second"))
+			t.Context.WriteString("second;")
+			fail(t)
+		})
+		ctx.BeginTransaction(func(t *iris2.Transaction) {
+			t.OnRollback(undo("third"))
+			t.Context.WriteString("third;")
+		})
+		ctx.WriteString("flow")
+		ctx.Next()
+	})
+
+	app.Get("/panic", func(ctx *iris2.Context) {
+		ctx.BeginTransaction(func(t *iris2.Transaction) {
+			t.OnRollback(undo("panic"))
+			panic("virtual panic")
+		})
+		ctx.Next()
+	})
+
+	var results []iris2.TransactionResult
+	app.DoneFunc(func(ctx *iris2.Context) {
+		results = ctx.TransactionResults()
+	})
+
+	e := httptest.New(app, t)
+
+	expectUndone := func(expected ...string) {
+		if len(undone) != len(expected) {
+			t.Fatalf("expected rollbacks %v but got %v", expected, undone)
+		}
+		for i := range expected {
+			if undone[i] != expected[i] {
+				t.Fatalf("expected rollbacks %v but got %v", expected, undone)
+			}
+		}
+		undone = nil
+	}
+	expectResults := func(expected ...iris2.TransactionResult) {
+		if len(results) != len(expected) {
+			t.Fatalf("expected results %#v but got %#v", expected, results)
+		}
+		for i := range expected {
+			if results[i].Depth != expected[i].Depth || results[i].Status != expected[i].Status ||
+				results[i].Err.StatusCode != expected[i].Err.StatusCode {
+				t.Fatalf("expected result[%d] %#v but got %#v", i, expected[i], results[i])
+			}
+		}
+	}
+
+	e.GET("/nested").Expect().Status(http.StatusOK).Body().Equal("outer;inner;")
+	expectUndone("savepoint", "failed first", "failed")
+	expectResults(
+		iris2.TransactionResult{Status: iris2.TransactionCommitted},
+		iris2.TransactionResult{Depth: 1, Status: iris2.TransactionRolledBack, Err: iris2.TransactionErrResult{StatusCode: http.StatusConflict}},
+		iris2.TransactionResult{Depth: 1, Status: iris2.TransactionCommitted},
+		iris2.TransactionResult{Status: iris2.TransactionRolledBack, Err: iris2.TransactionErrResult{StatusCode: http.StatusConflict}},
+	)
+
+	e.GET("/abort").Expect().Status(http.StatusOK).Body().Equal("first;flow")
+	expectUndone("second", "first nested", "first")
+	expectResults(
+		iris2.TransactionResult{Status: iris2.TransactionCompensated},
+		iris2.TransactionResult{Depth: 1, Status: iris2.TransactionCompensated},
+		iris2.TransactionResult{Status: iris2.TransactionRolledBack, Err: iris2.TransactionErrResult{StatusCode: http.StatusConflict}},
+		iris2.TransactionResult{Status: iris2.TransactionSkipped},
+	)
+
+	e.GET("/panic").Expect().Status(http.StatusOK)
+	expectUndone("panic")
+	expectResults(iris2.TransactionResult{Status: iris2.TransactionRolledBack, Err: iris2.TransactionErrResult{StatusCode: http.StatusInternalServerError}})
+}